@@ -0,0 +1,99 @@
+/*
+Package abclock adapts an abtime.AbstractTime to the clock.Clock interface
+of github.com/benbjohnson/clock, so libraries expecting one can be driven
+by the same ManualTime as the rest of a test.
+
+clock.Clock's Timer, Ticker, and AfterFunc return concrete structs whose
+Stop and Reset can't be redirected to anything else, so the ones returned
+here only work through their channel C: calling Stop or Reset on them
+panics. Code that needs to stop its timers should be given the
+AbstractTime directly. Everything else is fully supported.
+
+This is a separate module, so that abtime itself doesn't depend on
+benbjohnson/clock.
+*/
+package abclock
+
+import (
+	"context"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/thejerf/abtime"
+)
+
+// Clock wraps an AbstractTime as a clock.Clock. clock has no notion of
+// IDs, so everything created through the Clock is registered under its
+// single ID; use multiple Clocks with different IDs to tell libraries
+// apart.
+type Clock struct {
+	at abtime.AbstractTime
+	id abtime.ID
+}
+
+// New returns a clock.Clock backed by the given AbstractTime, using the
+// given ID for everything it creates.
+func New(at abtime.AbstractTime, id abtime.ID) *Clock {
+	return &Clock{at, id}
+}
+
+var _ clock.Clock = &Clock{}
+
+// After wraps the AbstractTime's After.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.at.After(d, c.id)
+}
+
+// AfterFunc wraps the AbstractTime's AfterFunc. The returned Timer can't
+// be stopped; see the package documentation.
+func (c *Clock) AfterFunc(d time.Duration, f func()) *clock.Timer {
+	c.at.AfterFunc(d, f, c.id)
+	return &clock.Timer{}
+}
+
+// Now wraps the AbstractTime's Now.
+func (c *Clock) Now() time.Time {
+	return c.at.Now()
+}
+
+// Since wraps the AbstractTime's Since.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.at.Since(t)
+}
+
+// Until wraps the AbstractTime's Until.
+func (c *Clock) Until(t time.Time) time.Duration {
+	return c.at.Until(t)
+}
+
+// Sleep wraps the AbstractTime's Sleep.
+func (c *Clock) Sleep(d time.Duration) {
+	c.at.Sleep(d, c.id)
+}
+
+// Tick wraps the AbstractTime's Tick.
+func (c *Clock) Tick(d time.Duration) <-chan time.Time {
+	return c.at.Tick(d, c.id)
+}
+
+// Ticker wraps the AbstractTime's NewTicker. Only the returned Ticker's C
+// works; see the package documentation.
+func (c *Clock) Ticker(d time.Duration) *clock.Ticker {
+	return &clock.Ticker{C: c.at.NewTicker(d, c.id).Channel()}
+}
+
+// Timer wraps the AbstractTime's NewTimer. Only the returned Timer's C
+// works; see the package documentation.
+func (c *Clock) Timer(d time.Duration) *clock.Timer {
+	return &clock.Timer{C: c.at.NewTimer(d, c.id).Channel()}
+}
+
+// WithDeadline wraps the AbstractTime's WithDeadline.
+func (c *Clock) WithDeadline(parent context.Context, d time.Time) (context.Context, context.CancelFunc) {
+	return c.at.WithDeadline(parent, d, c.id)
+}
+
+// WithTimeout wraps the AbstractTime's WithTimeout.
+func (c *Clock) WithTimeout(parent context.Context, t time.Duration) (context.Context, context.CancelFunc) {
+	return c.at.WithTimeout(parent, t, c.id)
+}
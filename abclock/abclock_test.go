@@ -0,0 +1,43 @@
+package abclock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+func TestClock(t *testing.T) {
+	mt := abtime.NewManual()
+	c := New(mt, "clock")
+
+	timer := c.Timer(time.Second)
+	after := c.After(time.Second)
+	mt.Trigger("clock", "clock")
+	<-timer.C
+	<-after
+
+	ticker := c.Ticker(time.Second)
+	mt.Trigger("clock")
+	<-ticker.C
+
+	// the ticker can't be stopped through a clock.Ticker, and would
+	// otherwise take the next Trigger
+	mt.Unregister("clock")
+
+	ran := make(chan struct{})
+	c.AfterFunc(time.Second, func() { close(ran) })
+	mt.Trigger("clock")
+	<-ran
+
+	ctx, cancel := c.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	mt.Trigger("clock")
+	<-ctx.Done()
+
+	mt.Advance(time.Minute)
+	if c.Since(c.Now().Add(-time.Minute)) != time.Minute {
+		t.Fatal("Since is not backed by the clock")
+	}
+}
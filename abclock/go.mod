@@ -0,0 +1,10 @@
+module github.com/thejerf/abtime/abclock
+
+go 1.21
+
+require (
+	github.com/benbjohnson/clock v1.3.5
+	github.com/thejerf/abtime v1.0.7
+)
+
+replace github.com/thejerf/abtime => ../
@@ -0,0 +1,89 @@
+/*
+Package abclockwork adapts an abtime.AbstractTime to the clockwork.Clock
+interface of github.com/jonboulle/clockwork, so libraries expecting one can
+be driven by the same ManualTime as the rest of a test.
+
+This is a separate module, so that abtime itself doesn't depend on
+clockwork.
+*/
+package abclockwork
+
+import (
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/thejerf/abtime"
+)
+
+// Clock wraps an AbstractTime as a clockwork.Clock. clockwork has no
+// notion of IDs, so everything created through the Clock is registered
+// under its single ID; use multiple Clocks with different IDs to tell
+// libraries apart.
+type Clock struct {
+	at abtime.AbstractTime
+	id abtime.ID
+}
+
+// New returns a clockwork.Clock backed by the given AbstractTime, using
+// the given ID for everything it creates.
+func New(at abtime.AbstractTime, id abtime.ID) *Clock {
+	return &Clock{at, id}
+}
+
+var _ clockwork.Clock = &Clock{}
+
+// After wraps the AbstractTime's After.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.at.After(d, c.id)
+}
+
+// Sleep wraps the AbstractTime's Sleep.
+func (c *Clock) Sleep(d time.Duration) {
+	c.at.Sleep(d, c.id)
+}
+
+// Now wraps the AbstractTime's Now.
+func (c *Clock) Now() time.Time {
+	return c.at.Now()
+}
+
+// Since wraps the AbstractTime's Since.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.at.Since(t)
+}
+
+// Until wraps the AbstractTime's Until.
+func (c *Clock) Until(t time.Time) time.Duration {
+	return c.at.Until(t)
+}
+
+// NewTicker wraps the AbstractTime's NewTicker.
+func (c *Clock) NewTicker(d time.Duration) clockwork.Ticker {
+	return ticker{c.at.NewTicker(d, c.id)}
+}
+
+// NewTimer wraps the AbstractTime's NewTimer.
+func (c *Clock) NewTimer(d time.Duration) clockwork.Timer {
+	return timer{c.at.NewTimer(d, c.id)}
+}
+
+// AfterFunc wraps the AbstractTime's AfterFunc.
+func (c *Clock) AfterFunc(d time.Duration, f func()) clockwork.Timer {
+	return timer{c.at.AfterFunc(d, f, c.id)}
+}
+
+type ticker struct {
+	abtime.Ticker
+}
+
+func (t ticker) Chan() <-chan time.Time {
+	return t.Channel()
+}
+
+type timer struct {
+	abtime.Timer
+}
+
+func (t timer) Chan() <-chan time.Time {
+	return t.Channel()
+}
@@ -0,0 +1,34 @@
+package abclockwork
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+func TestClock(t *testing.T) {
+	mt := abtime.NewManual()
+	c := New(mt, "clockwork")
+
+	timer := c.NewTimer(time.Second)
+	after := c.After(time.Second)
+	mt.Trigger("clockwork", "clockwork")
+	<-timer.Chan()
+	<-after
+
+	ticker := c.NewTicker(time.Second)
+	mt.Trigger("clockwork")
+	<-ticker.Chan()
+	ticker.Stop()
+
+	ran := make(chan struct{})
+	c.AfterFunc(time.Second, func() { close(ran) })
+	mt.Trigger("clockwork")
+	<-ran
+
+	mt.Advance(time.Minute)
+	if c.Since(c.Now().Add(-time.Minute)) != time.Minute {
+		t.Fatal("Since is not backed by the clock")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/thejerf/abtime/abclockwork
+
+go 1.21
+
+require (
+	github.com/jonboulle/clockwork v0.5.0
+	github.com/thejerf/abtime v1.0.7
+)
+
+replace github.com/thejerf/abtime => ../
@@ -0,0 +1,148 @@
+package abtimetest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// A Canary is an AbstractTime that reports every use of it as a test
+// failure, then carries on as a RealTime.
+//
+// Install it wherever code under test falls back to the real clock when
+// none is injected, such as a package-level default, so that a forgotten
+// injection fails the test on the spot, with the location of the call,
+// rather than quietly producing a slow, flaky test. It fails with Errorf
+// rather than Fatalf, since the clock may well be used from goroutines
+// other than the test's.
+type Canary struct {
+	t    testing.TB
+	real abtime.RealTime
+}
+
+// NewCanary returns a Canary reporting to the given test. If t is nil,
+// the Canary panics instead, for use as a default set up in a TestMain,
+// where there is no test to fail yet.
+func NewCanary(t testing.TB) *Canary {
+	return &Canary{t: t, real: abtime.NewRealTime()}
+}
+
+// chirp reports a use of the named method.
+func (c *Canary) chirp(method string) {
+	caller := "unknown location"
+	if _, file, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%s:%d", file, line)
+	}
+	msg := fmt.Sprintf("real clock used: %s called at %s; was a clock not injected?", method, caller)
+	if c.t == nil {
+		panic(msg)
+	}
+	c.t.Helper()
+	c.t.Errorf("%s", msg)
+}
+
+// Now reports the use, then wraps time.Now.
+func (c *Canary) Now() time.Time {
+	c.chirp("Now")
+	return c.real.Now()
+}
+
+// NowIn reports the use, then wraps time.Now.
+func (c *Canary) NowIn(loc *time.Location) time.Time {
+	c.chirp("NowIn")
+	return c.real.NowIn(loc)
+}
+
+// Since reports the use, then wraps time.Since.
+func (c *Canary) Since(t time.Time) time.Duration {
+	c.chirp("Since")
+	return c.real.Since(t)
+}
+
+// Until reports the use, then wraps time.Until.
+func (c *Canary) Until(t time.Time) time.Duration {
+	c.chirp("Until")
+	return c.real.Until(t)
+}
+
+// After reports the use, then wraps time.After.
+func (c *Canary) After(d time.Duration, id abtime.ID) <-chan time.Time {
+	c.chirp("After")
+	return c.real.After(d, id)
+}
+
+// Sleep reports the use, then wraps time.Sleep.
+func (c *Canary) Sleep(d time.Duration, id abtime.ID) {
+	c.chirp("Sleep")
+	c.real.Sleep(d, id)
+}
+
+// SleepContext reports the use, then sleeps in real time.
+func (c *Canary) SleepContext(ctx context.Context, d time.Duration, id abtime.ID) error {
+	c.chirp("SleepContext")
+	return c.real.SleepContext(ctx, d, id)
+}
+
+// Tick reports the use, then wraps time.Tick.
+func (c *Canary) Tick(d time.Duration, id abtime.ID) <-chan time.Time {
+	c.chirp("Tick")
+	return c.real.Tick(d, id)
+}
+
+// NewTicker reports the use, then wraps time.NewTicker.
+func (c *Canary) NewTicker(d time.Duration, id abtime.ID) abtime.Ticker {
+	c.chirp("NewTicker")
+	return c.real.NewTicker(d, id)
+}
+
+// AfterFunc reports the use, then wraps time.AfterFunc.
+func (c *Canary) AfterFunc(d time.Duration, f func(), id abtime.ID) abtime.Timer {
+	c.chirp("AfterFunc")
+	return c.real.AfterFunc(d, f, id)
+}
+
+// NewTimer reports the use, then wraps time.NewTimer.
+func (c *Canary) NewTimer(d time.Duration, id abtime.ID) abtime.Timer {
+	c.chirp("NewTimer")
+	return c.real.NewTimer(d, id)
+}
+
+// NewTimerAt reports the use, then creates a real timer firing at t.
+func (c *Canary) NewTimerAt(t time.Time, id abtime.ID) abtime.Timer {
+	c.chirp("NewTimerAt")
+	return c.real.NewTimerAt(t, id)
+}
+
+// WithCancel reports the use, then wraps context.WithCancel.
+func (c *Canary) WithCancel(parent context.Context, id abtime.ID) (context.Context, context.CancelFunc) {
+	c.chirp("WithCancel")
+	return c.real.WithCancel(parent, id)
+}
+
+// WithDeadline reports the use, then wraps context.WithDeadline.
+func (c *Canary) WithDeadline(parent context.Context, deadline time.Time, id abtime.ID) (context.Context, context.CancelFunc) {
+	c.chirp("WithDeadline")
+	return c.real.WithDeadline(parent, deadline, id)
+}
+
+// WithTimeout reports the use, then wraps context.WithTimeout.
+func (c *Canary) WithTimeout(parent context.Context, timeout time.Duration, id abtime.ID) (context.Context, context.CancelFunc) {
+	c.chirp("WithTimeout")
+	return c.real.WithTimeout(parent, timeout, id)
+}
+
+// WithDeadlineCause reports the use, then wraps context.WithDeadlineCause.
+func (c *Canary) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id abtime.ID) (context.Context, context.CancelFunc) {
+	c.chirp("WithDeadlineCause")
+	return c.real.WithDeadlineCause(parent, deadline, cause, id)
+}
+
+// WithTimeoutCause reports the use, then wraps context.WithTimeoutCause.
+func (c *Canary) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id abtime.ID) (context.Context, context.CancelFunc) {
+	c.chirp("WithTimeoutCause")
+	return c.real.WithTimeoutCause(parent, timeout, cause, id)
+}
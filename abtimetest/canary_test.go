@@ -0,0 +1,34 @@
+package abtimetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+func TestCanary(t *testing.T) {
+	ft := &fakeT{}
+	var at abtime.AbstractTime = NewCanary(ft)
+
+	if at.Now().IsZero() {
+		t.Fatal("canary did not carry on as real time")
+	}
+	if !strings.Contains(ft.failure, "Now called at") || !strings.Contains(ft.failure, "canary_test.go") {
+		t.Fatalf("unexpected failure: %q", ft.failure)
+	}
+
+	ft.failure = ""
+	at.Sleep(time.Nanosecond, 0)
+	if !strings.Contains(ft.failure, "Sleep called at") {
+		t.Fatalf("unexpected failure: %q", ft.failure)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("canary without a test did not panic")
+		}
+	}()
+	NewCanary(nil).Now()
+}
@@ -0,0 +1,8 @@
+/*
+Package abtimetest provides helpers for tests that use abtime.
+
+These cover the real-time scaffolding that tests of time-based code still
+end up needing, such as a safety timeout on a receive that ought to
+succeed, without every project rewriting it.
+*/
+package abtimetest
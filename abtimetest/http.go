@@ -0,0 +1,72 @@
+package abtimetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// A ServedRequest is a request being served by a handler under test, with
+// its own ManualTime.
+type ServedRequest struct {
+	// Clock is the ManualTime carried by the request's context, for the
+	// test to Trigger and Advance while the handler runs.
+	Clock *abtime.ManualTime
+
+	// Recorder records the handler's response. It must not be examined
+	// until Wait has returned.
+	Recorder *httptest.ResponseRecorder
+
+	done chan struct{}
+}
+
+// ServeRequest serves the request with the handler in a new goroutine,
+// with a fresh ManualTime starting at the given time injected into the
+// request's context with abtime.NewContext. Handlers retrieving their
+// clock with abtime.FromContext will then use it, so the test can drive
+// time in the middle of the request without any globals, and each request
+// gets its own clock.
+//
+// Call Wait for the handler to finish.
+func ServeRequest(handler http.Handler, req *http.Request, start time.Time) *ServedRequest {
+	sr := &ServedRequest{
+		Clock:    abtime.NewManualAtTime(start),
+		Recorder: httptest.NewRecorder(),
+		done:     make(chan struct{}),
+	}
+	req = req.WithContext(abtime.NewContext(req.Context(), sr.Clock))
+	go func() {
+		defer close(sr.done)
+		handler.ServeHTTP(sr.Recorder, req)
+	}()
+	return sr
+}
+
+// Wait waits for the handler to finish and returns the recorded response,
+// failing the test if it takes longer than failAfterReal of real time or
+// the test's deadline approaches first. The failure includes the state of
+// the request's clock, which usually shows what the handler is still
+// waiting on.
+func (sr *ServedRequest) Wait(t testing.TB, failAfterReal time.Duration) *httptest.ResponseRecorder {
+	t.Helper()
+
+	allowed, complete := budget(t, failAfterReal)
+	timeout := time.NewTimer(allowed)
+	defer timeout.Stop()
+
+	select {
+	case <-sr.done:
+		return sr.Recorder
+	case <-timeout.C:
+		if complete {
+			t.Fatalf("handler did not finish within %v; %v", failAfterReal, sr.Clock)
+		} else {
+			t.Fatalf("test deadline approaching after waiting %v of %v for handler; %v",
+				allowed, failAfterReal, sr.Clock)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package abtimetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const retryID = "retry"
+
+// retryingHandler waits out a retry delay on the request's clock.
+func retryingHandler(w http.ResponseWriter, req *http.Request) {
+	at := abtime.FromContext(req.Context())
+	start := at.Now()
+	at.Sleep(time.Second, retryID)
+	fmt.Fprint(w, at.Since(start))
+}
+
+func TestServeRequest(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sr := ServeRequest(http.HandlerFunc(retryingHandler),
+		httptest.NewRequest("GET", "/", nil), start)
+
+	WaitReal(t, time.Second, func() bool { return len(sr.Clock.PendingIDs()) > 0 })
+	sr.Clock.Advance(time.Second)
+	sr.Clock.Trigger(retryID)
+	if body := sr.Wait(t, time.Second).Body.String(); body != "1s" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	// each request gets its own clock
+	stuck := ServeRequest(http.HandlerFunc(retryingHandler),
+		httptest.NewRequest("GET", "/", nil), start)
+	if stuck.Clock == sr.Clock {
+		t.Fatal("requests share a clock")
+	}
+	WaitReal(t, time.Second, func() bool { return len(stuck.Clock.PendingIDs()) > 0 })
+	ft := &fakeT{}
+	stuck.Wait(ft, time.Millisecond)
+	if !strings.Contains(ft.failure, "id retry: 1 registered") {
+		t.Fatalf("failure does not describe the clock: %q", ft.failure)
+	}
+	stuck.Clock.Trigger(retryID)
+}
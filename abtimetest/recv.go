@@ -0,0 +1,44 @@
+package abtimetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// Recv receives from the given timer or ticker channel, failing the test
+// if nothing arrives within failAfterReal of real time. This replaces the
+// select-with-time.After boilerplate for receives that ought to succeed.
+//
+// Like SleepReal, this fails early if the test's deadline would arrive
+// first.
+//
+// If the ManualTime driving the channel is passed, its state is included
+// in the failure message, which usually makes it clear which Trigger was
+// missing.
+func Recv(t testing.TB, ch <-chan time.Time, failAfterReal time.Duration, mt ...*abtime.ManualTime) time.Time {
+	t.Helper()
+
+	allowed, complete := budget(t, failAfterReal)
+	timeout := time.NewTimer(allowed)
+	defer timeout.Stop()
+
+	select {
+	case received := <-ch:
+		return received
+	case <-timeout.C:
+		failure := fmt.Sprintf("nothing received within %v", failAfterReal)
+		if !complete {
+			failure = fmt.Sprintf("test deadline approaching after waiting %v of %v to receive",
+				allowed, failAfterReal)
+		}
+		if len(mt) > 0 {
+			t.Fatalf("%s; %v", failure, mt[0])
+		} else {
+			t.Fatalf("%s", failure)
+		}
+		return time.Time{}
+	}
+}
@@ -0,0 +1,47 @@
+package abtimetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const (
+	timerID = iota
+)
+
+// fakeT captures failures rather than ending the test.
+type fakeT struct {
+	testing.TB
+	failure string
+}
+
+func (ft *fakeT) Helper() {}
+
+func (ft *fakeT) Fatalf(format string, args ...interface{}) {
+	ft.failure = fmt.Sprintf(format, args...)
+}
+
+func (ft *fakeT) Errorf(format string, args ...interface{}) {
+	ft.failure = fmt.Sprintf(format, args...)
+}
+
+func TestRecv(t *testing.T) {
+	mt := abtime.NewManual()
+
+	timer := mt.NewTimer(time.Second, timerID)
+	go mt.Trigger(timerID)
+	if Recv(t, timer.Channel(), time.Second, mt).IsZero() {
+		t.Fatal("did not receive the timer's time")
+	}
+
+	timer = mt.NewTimer(time.Second, timerID)
+	ft := &fakeT{}
+	Recv(ft, timer.Channel(), time.Millisecond, mt)
+	if !strings.Contains(ft.failure, "id 0: 1 registered") {
+		t.Fatalf("failure message does not describe the clock: %q", ft.failure)
+	}
+}
@@ -0,0 +1,71 @@
+package abtimetest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// A ScheduledEvent is an expected firing in a schedule checked by
+// AssertSchedule.
+type ScheduledEvent struct {
+	ID abtime.ID
+
+	// Time is the virtual time the firing is expected at. The zero time
+	// matches any time, for firings whose time the test doesn't care
+	// about.
+	Time time.Time
+}
+
+func (se ScheduledEvent) String() string {
+	if se.Time.IsZero() {
+		return fmt.Sprintf("id %v at any time", se.ID)
+	}
+	return fmt.Sprintf("id %v at %v", se.ID, se.Time)
+}
+
+func (se ScheduledEvent) matches(event abtime.Event) bool {
+	return se.ID == event.ID && (se.Time.IsZero() || se.Time.Equal(event.Time))
+}
+
+// AssertSchedule fails the test unless the firings recorded in the
+// ManualTime's History are exactly the expected schedule: the same IDs,
+// at the same virtual times, in the same order. On failure, the expected
+// and actual firings are listed side by side, with the mismatched lines
+// marked, so a complex simulation can be checked with one readable
+// assertion.
+func AssertSchedule(t testing.TB, mt *abtime.ManualTime, expected []ScheduledEvent) {
+	t.Helper()
+
+	fired := []abtime.Event{}
+	for _, event := range mt.History() {
+		if event.Kind == abtime.Fired {
+			fired = append(fired, event)
+		}
+	}
+
+	lines := []string{}
+	mismatched := len(fired) != len(expected)
+	for i := 0; i < len(fired) || i < len(expected); i++ {
+		want, got := "(nothing)", "(nothing)"
+		same := i < len(fired) && i < len(expected) && expected[i].matches(fired[i])
+		if i < len(expected) {
+			want = expected[i].String()
+		}
+		if i < len(fired) {
+			got = fmt.Sprintf("id %v at %v", fired[i].ID, fired[i].Time)
+		}
+		if same {
+			lines = append(lines, fmt.Sprintf("    %d: %s", i, got))
+			continue
+		}
+		mismatched = true
+		lines = append(lines, fmt.Sprintf("  ! %d: expected %s\n  ! %d:      got %s", i, want, i, got))
+	}
+	if mismatched {
+		t.Errorf("firings do not match the expected schedule:\n%s", strings.Join(lines, "\n"))
+	}
+}
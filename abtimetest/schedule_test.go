@@ -0,0 +1,45 @@
+package abtimetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+func TestAssertSchedule(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mt := abtime.NewManualAtTime(start)
+
+	first := mt.NewTimer(time.Second, "first")
+	second := mt.NewTimer(time.Minute, "second")
+	mt.Advance(time.Second)
+	mt.Trigger("first")
+	<-first.Channel()
+	mt.Advance(time.Minute)
+	mt.Trigger("second")
+	<-second.Channel()
+
+	AssertSchedule(t, mt, []ScheduledEvent{
+		{"first", start.Add(time.Second)},
+		{"second", time.Time{}},
+	})
+
+	ft := &fakeT{}
+	AssertSchedule(ft, mt, []ScheduledEvent{
+		{"first", start.Add(time.Second)},
+		{"second", start.Add(time.Minute)},
+		{"third", time.Time{}},
+	})
+	for _, expected := range []string{
+		"    0: id first",
+		"  ! 1: expected id second at 2020-01-01 00:01:00",
+		"  ! 1:      got id second at 2020-01-01 00:01:01",
+		"  ! 2:      got (nothing)",
+	} {
+		if !strings.Contains(ft.failure, expected) {
+			t.Fatalf("failure lacks %q:\n%s", expected, ft.failure)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package abtimetest
+
+import (
+	"testing"
+	"time"
+)
+
+// deadlineMargin is how far ahead of the test deadline the real-time
+// helpers give up, leaving time for the failure to be reported.
+const deadlineMargin = time.Second
+
+// deadliner is implemented by *testing.T.
+type deadliner interface {
+	Deadline() (time.Time, bool)
+}
+
+// budget returns how much of d may be spent waiting before the test's
+// deadline approaches, and whether that is all of d.
+func budget(t testing.TB, d time.Duration) (time.Duration, bool) {
+	dt, hasDeadline := t.(deadliner)
+	if !hasDeadline {
+		return d, true
+	}
+	deadline, hasDeadline := dt.Deadline()
+	if !hasDeadline {
+		return d, true
+	}
+	remaining := time.Until(deadline) - deadlineMargin
+	if remaining < d {
+		if remaining < 0 {
+			remaining = 0
+		}
+		return remaining, false
+	}
+	return d, true
+}
+
+// SleepReal sleeps for d of real time. If the test has a deadline that
+// would arrive first, as set by go test -timeout, it instead fails the
+// test shortly before the deadline, rather than letting the whole test
+// binary be killed with no useful report.
+//
+// This is for the unavoidable places where a test must wait on real
+// goroutine scheduling.
+func SleepReal(t testing.TB, d time.Duration) {
+	t.Helper()
+
+	allowed, complete := budget(t, d)
+	time.Sleep(allowed)
+	if !complete {
+		t.Fatalf("test deadline approaching after sleeping %v of %v", allowed, d)
+	}
+}
+
+// WaitReal polls cond every millisecond of real time until it returns
+// true, failing the test if that takes longer than d or if the test's
+// deadline approaches first.
+func WaitReal(t testing.TB, d time.Duration, cond func() bool) {
+	t.Helper()
+
+	allowed, complete := budget(t, d)
+	giveUp := time.Now().Add(allowed)
+	for !cond() {
+		if time.Now().After(giveUp) {
+			if complete {
+				t.Fatalf("condition not met within %v", d)
+			} else {
+				t.Fatalf("test deadline approaching after waiting %v of %v for condition", allowed, d)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
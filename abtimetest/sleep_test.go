@@ -0,0 +1,55 @@
+package abtimetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// deadlineT has a test deadline of its choosing.
+type deadlineT struct {
+	fakeT
+	deadline time.Time
+}
+
+func (dt *deadlineT) Deadline() (time.Time, bool) {
+	return dt.deadline, true
+}
+
+func TestSleepReal(t *testing.T) {
+	start := time.Now()
+	SleepReal(t, 5*time.Millisecond)
+	if time.Since(start) < 5*time.Millisecond {
+		t.Fatal("did not sleep")
+	}
+
+	dt := &deadlineT{deadline: time.Now().Add(deadlineMargin + 10*time.Millisecond)}
+	start = time.Now()
+	SleepReal(dt, time.Hour)
+	if time.Since(start) > time.Second {
+		t.Fatal("did not cut the sleep short")
+	}
+	if !strings.Contains(dt.failure, "test deadline approaching") {
+		t.Fatalf("unexpected failure: %q", dt.failure)
+	}
+}
+
+func TestWaitReal(t *testing.T) {
+	calls := 0
+	WaitReal(t, time.Second, func() bool {
+		calls++
+		return calls == 3
+	})
+
+	ft := &fakeT{}
+	WaitReal(ft, 5*time.Millisecond, func() bool { return false })
+	if ft.failure != "condition not met within 5ms" {
+		t.Fatalf("unexpected failure: %q", ft.failure)
+	}
+
+	dt := &deadlineT{deadline: time.Now()}
+	WaitReal(dt, time.Hour, func() bool { return false })
+	if !strings.Contains(dt.failure, "test deadline approaching") {
+		t.Fatalf("unexpected failure: %q", dt.failure)
+	}
+}
@@ -0,0 +1,112 @@
+package abtimetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// Expire is the event that lets the current state's timeout expire, by
+// triggering its ID. Any other event is passed to the StateMachine as an
+// input.
+const Expire = "<expire>"
+
+// defaultStateWait is how long Drive waits for each transition by default.
+const defaultStateWait = time.Second
+
+// A StateMachine is the code under test, adapted to be driven by a Model.
+// State must be safe to call concurrently with the machine's own
+// goroutines.
+type StateMachine interface {
+	State() string
+	Input(input string)
+}
+
+// A TimeoutTransition is the transition a state takes when its timeout,
+// registered on the ManualTime under ID, fires.
+type TimeoutTransition struct {
+	ID   abtime.ID
+	Next string
+}
+
+// A Model declares the expected behavior of a timed state machine: its
+// initial state, the timeout transition of each state that has one, and
+// the next state for each input in each state.
+type Model struct {
+	Initial     string
+	Timeouts    map[string]TimeoutTransition
+	Transitions map[string]map[string]string
+
+	// Wait is how much real time to allow the machine to reach each
+	// expected state. It defaults to one second.
+	Wait time.Duration
+}
+
+// Drive feeds the events to the machine in order, triggering timeouts on
+// the ManualTime for Expire events, and fails the test if the machine
+// doesn't follow the transitions the model declares. It returns the
+// sequence of states the machine went through, starting with the initial
+// state.
+func (m Model) Drive(t testing.TB, mt *abtime.ManualTime, sm StateMachine, events ...string) []string {
+	t.Helper()
+
+	wait := m.Wait
+	if wait == 0 {
+		wait = defaultStateWait
+	}
+
+	state := m.Initial
+	visited := []string{state}
+	if !waitForState(sm, state, wait) {
+		t.Fatalf("machine started in state %q, expected %q", sm.State(), state)
+		return visited
+	}
+
+	for _, event := range events {
+		var next string
+		if event == Expire {
+			timeout, hasTimeout := m.Timeouts[state]
+			if !hasTimeout {
+				t.Fatalf("after %s: model has no timeout for state %q",
+					strings.Join(visited, " -> "), state)
+				return visited
+			}
+			next = timeout.Next
+			mt.Trigger(timeout.ID)
+		} else {
+			var hasNext bool
+			next, hasNext = m.Transitions[state][event]
+			if !hasNext {
+				t.Fatalf("after %s: model has no transition for input %q in state %q",
+					strings.Join(visited, " -> "), event, state)
+				return visited
+			}
+			sm.Input(event)
+		}
+
+		if !waitForState(sm, next, wait) {
+			t.Fatalf("after %s: event %q left the machine in state %q, expected %q",
+				strings.Join(visited, " -> "), event, sm.State(), next)
+			return visited
+		}
+		state = next
+		visited = append(visited, state)
+	}
+
+	return visited
+}
+
+// waitForState polls until the machine is in the given state, or the
+// wait runs out.
+func waitForState(sm StateMachine, state string, wait time.Duration) bool {
+	giveUp := time.Now().Add(wait)
+	for sm.State() != state {
+		if time.Now().After(giveUp) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
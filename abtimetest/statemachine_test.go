@@ -0,0 +1,103 @@
+package abtimetest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const (
+	idleTimeoutID = iota
+	connectTimeoutID
+)
+
+// connection is a small timed state machine: "idle" until "connect", then
+// "connecting" until "connected" or a timeout back to "idle", and
+// "connected" until an idle timeout back to "idle".
+type connection struct {
+	at     abtime.AbstractTime
+	state  string
+	inputs chan string
+	mu     sync.Mutex
+}
+
+func newConnection(at abtime.AbstractTime) *connection {
+	c := &connection{at: at, state: "idle", inputs: make(chan string)}
+	go c.run()
+	return c
+}
+
+func (c *connection) set(state string) {
+	c.mu.Lock()
+	c.state = state
+	c.mu.Unlock()
+}
+
+func (c *connection) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *connection) Input(input string) {
+	c.inputs <- input
+}
+
+func (c *connection) run() {
+	for {
+		switch c.State() {
+		case "idle":
+			if <-c.inputs == "connect" {
+				c.set("connecting")
+			}
+		case "connecting":
+			timeout := c.at.After(time.Second, connectTimeoutID)
+			select {
+			case <-timeout:
+				c.set("idle")
+			case input := <-c.inputs:
+				if input == "connected" {
+					c.set("connected")
+				}
+			}
+		case "connected":
+			<-c.at.After(time.Minute, idleTimeoutID)
+			c.set("idle")
+		}
+	}
+}
+
+var connectionModel = Model{
+	Initial: "idle",
+	Timeouts: map[string]TimeoutTransition{
+		"connecting": {connectTimeoutID, "idle"},
+		"connected":  {idleTimeoutID, "idle"},
+	},
+	Transitions: map[string]map[string]string{
+		"idle":       {"connect": "connecting"},
+		"connecting": {"connected": "connected"},
+	},
+}
+
+func TestStateMachine(t *testing.T) {
+	mt := abtime.NewManual()
+	visited := connectionModel.Drive(t, mt, newConnection(mt),
+		"connect", Expire, "connect", "connected", Expire)
+	if strings.Join(visited, ",") != "idle,connecting,idle,connecting,connected,idle" {
+		t.Fatalf("unexpected transitions: %v", visited)
+	}
+
+	ft := &fakeT{}
+	model := connectionModel
+	model.Wait = 10 * time.Millisecond
+	model.Transitions = map[string]map[string]string{
+		"idle": {"connect": "connected"},
+	}
+	model.Drive(ft, mt, newConnection(mt), "connect")
+	if !strings.Contains(ft.failure, `after idle: event "connect" left the machine in state "connecting", expected "connected"`) {
+		t.Fatalf("unexpected failure: %q", ft.failure)
+	}
+}
@@ -0,0 +1,164 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// TickerOptions shape the start of a ticker made by NewTickerWith; after
+// its first tick, it ticks every interval as usual.
+type TickerOptions struct {
+	// Align, if positive, delays the first tick until the next multiple
+	// of Align after Now, as time.Time.Truncate counts them, so a minute
+	// ticker with an Align of a minute ticks at the top of every minute.
+	// Multiples are counted in absolute time, so aligning to hours or days
+	// lines up with UTC rather than any local time zone.
+	Align time.Duration
+
+	// ImmediateFirstTick delivers a tick of the current Now straight
+	// away, before the first one the interval or Align would give.
+	ImmediateFirstTick bool
+}
+
+// NewTickerWith makes a ticker on the given clock with the given options,
+// which can't be expressed with NewTicker alone.
+//
+// It's built on the clock's own Timers and Tickers, all under the given
+// id, so it works with any AbstractTime. On a ManualTime, an aligned
+// ticker's first Trigger of the id delivers the tick at the aligned time,
+// and the Triggers after that tick as usual. The ticks are forwarded by a
+// goroutine, and, as with time.Ticker, dropped for slow receivers.
+func NewTickerWith(at AbstractTime, d time.Duration, id ID, opts TickerOptions) Ticker {
+	if d <= 0 {
+		panic("abtime: non-positive interval for NewTickerWith")
+	}
+	t := &optionTicker{
+		at:   at,
+		d:    d,
+		id:   id,
+		c:    make(chan time.Time, 1),
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+	if opts.ImmediateFirstTick {
+		t.c <- at.Now()
+	}
+	if opts.Align > 0 {
+		now := at.Now()
+		t.timer = at.NewTimerAt(now.Truncate(opts.Align).Add(opts.Align), id)
+		t.source = t.timer.Channel()
+	} else {
+		t.ticker = at.NewTicker(d, id)
+		t.source = t.ticker.Channel()
+	}
+	go t.run(t.stop)
+	return t
+}
+
+// NewTickerAligned makes a ticker whose first tick is aligned to the next
+// multiple of align; see NewTickerWith.
+func (rt RealTime) NewTickerAligned(d, align time.Duration, id ID) Ticker {
+	return NewTickerWith(rt, d, id, TickerOptions{Align: align})
+}
+
+// NewTickerAligned makes a ticker whose first tick is aligned to the next
+// multiple of align; see NewTickerWith.
+func (mt *ManualTime) NewTickerAligned(d, align time.Duration, id ID) Ticker {
+	return NewTickerWith(mt, d, id, TickerOptions{Align: align})
+}
+
+// optionTicker implements NewTickerWith. Until its first aligned tick it
+// waits on timer; after that, or if it isn't aligned, on ticker.
+type optionTicker struct {
+	at AbstractTime
+	d  time.Duration
+	id ID
+	c  chan time.Time
+
+	timer  Timer
+	ticker Ticker
+	source <-chan time.Time
+
+	// wake tells the forwarding goroutine that source has changed, and
+	// closing stop ends it; stop is nil while the ticker is stopped
+	wake chan struct{}
+	stop chan struct{}
+	mu   sync.Mutex
+}
+
+func (t *optionTicker) run(stop chan struct{}) {
+	for {
+		t.mu.Lock()
+		source := t.source
+		t.mu.Unlock()
+
+		select {
+		case tick := <-source:
+			t.mu.Lock()
+			if t.timer != nil && source == t.timer.Channel() {
+				// the aligned first tick; tick regularly from here
+				t.timer = nil
+				t.ticker = t.at.NewTicker(t.d, t.id)
+				t.source = t.ticker.Channel()
+			}
+			t.mu.Unlock()
+			select {
+			case t.c <- tick:
+			default:
+			}
+		case <-t.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (t *optionTicker) Channel() <-chan time.Time {
+	return t.c
+}
+
+// Reset stops the ticker and restarts it with the given interval, as
+// time.Ticker.Reset does. A ticker still waiting for its aligned first
+// tick gives up on it and just ticks every d from now. A stopped ticker
+// starts ticking again.
+func (t *optionTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("abtime: non-positive interval for Ticker.Reset")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.d = d
+	if t.stop == nil {
+		t.stop = make(chan struct{})
+		go t.run(t.stop)
+	}
+	if t.ticker != nil {
+		t.ticker.Reset(d)
+		return
+	}
+	t.timer.Stop()
+	t.timer = nil
+	t.ticker = t.at.NewTicker(d, t.id)
+	t.source = t.ticker.Channel()
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *optionTicker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+}
@@ -0,0 +1,76 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickerAligned(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 30, 0, time.UTC)
+	mt := NewManualAtTime(start)
+	mt.SetAdvanceOnFire(true)
+
+	ticker := mt.NewTickerAligned(time.Minute, time.Minute, tickID)
+	defer ticker.Stop()
+	pending := mt.Pending(tickID)
+	if len(pending) != 1 || !pending[0].At.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("unexpected pending %v", pending)
+	}
+
+	for _, expected := range []time.Time{
+		start.Add(30 * time.Second),
+		start.Add(90 * time.Second),
+		start.Add(150 * time.Second),
+	} {
+		mt.Trigger(tickID)
+		if tick := <-ticker.Channel(); !tick.Equal(expected) {
+			t.Fatalf("ticked at %v, expected %v", tick, expected)
+		}
+	}
+
+	ticker.Stop()
+	mt.VerifyNoPending(t)
+
+	// a stopped ticker ticks again once Reset
+	ticker.Reset(time.Minute)
+	mt.Trigger(tickID)
+	if tick := <-ticker.Channel(); !tick.Equal(start.Add(210 * time.Second)) {
+		t.Fatalf("ticked at %v after Reset", tick)
+	}
+	ticker.Stop()
+	mt.VerifyNoPending(t)
+}
+
+func TestTickerImmediate(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	ticker := NewTickerWith(mt, time.Second, tickID, TickerOptions{ImmediateFirstTick: true})
+	if tick := <-ticker.Channel(); !tick.Equal(start) {
+		t.Fatalf("immediate tick at %v", tick)
+	}
+	mt.Trigger(tickID)
+	if tick := <-ticker.Channel(); !tick.Equal(start.Add(time.Second)) {
+		t.Fatalf("second tick at %v", tick)
+	}
+
+	// resetting a ticker still waiting to align gives up on aligning
+	aligned := NewTickerWith(mt, time.Hour, timerID, TickerOptions{Align: 24 * time.Hour})
+	aligned.Reset(time.Minute)
+	mt.Trigger(timerID)
+	if tick := <-aligned.Channel(); !tick.Equal(start.Add(time.Minute)) {
+		t.Fatalf("reset tick at %v", tick)
+	}
+	aligned.Stop()
+	ticker.Stop()
+	mt.VerifyNoPending(t)
+
+	// and in real time
+	ticker = NewTickerWith(NewRealTime(), time.Hour, 0, TickerOptions{ImmediateFirstTick: true})
+	defer ticker.Stop()
+	select {
+	case <-ticker.Channel():
+	case <-time.After(time.Second):
+		t.Fatal("no immediate tick in real time")
+	}
+}
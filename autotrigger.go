@@ -0,0 +1,98 @@
+package abtime
+
+// An AutoTriggerPolicy says when a ManualTime triggers an ID by itself.
+// See AutoTrigger.
+type AutoTriggerPolicy int
+
+const (
+	// NoAutoTrigger leaves the ID to be triggered by the test. This is
+	// the default.
+	NoAutoTrigger AutoTriggerPolicy = iota
+
+	// OnRegistration fires each registration under the ID as soon as it
+	// is made, so Sleeps return and timers fire immediately, without
+	// moving Now.
+	OnRegistration
+
+	// WhenDue fires registrations under the ID as Now reaches the time
+	// they are scheduled for, by Advance or otherwise. A ticker receives
+	// a tick for each interval Now moves past.
+	WhenDue
+)
+
+// AutoTrigger sets the ManualTime to trigger the id by itself, according
+// to the policy. This is for the IDs a test doesn't care about precisely,
+// such as a heartbeat ticker in code that's under test for something
+// else, replacing fragile choreography to keep them moving. Any
+// registrations under the id already due when WhenDue is set fire
+// immediately.
+//
+// Auto-triggered firings are recorded in the History like any other, but
+// with no Triggered event, and don't consume Triggers held for the id.
+func (mt *ManualTime) AutoTrigger(id ID, policy AutoTriggerPolicy) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if policy == NoAutoTrigger {
+		delete(mt.autoTriggers, id)
+		return
+	}
+	if mt.autoTriggers == nil {
+		mt.autoTriggers = map[ID]AutoTriggerPolicy{}
+	}
+	mt.autoTriggers[id] = policy
+	if policy == WhenDue {
+		mt.fireDue(id)
+	}
+}
+
+// autoTriggerRegistration fires the new registration if its id fires on
+// registration, or fires when due and it already is. The lock must be
+// held.
+func (mt *ManualTime) autoTriggerRegistration(id ID, trig trigger) {
+	switch mt.autoTriggers[id] {
+	case OnRegistration:
+		if trig.live() && mt.fireOne(trig) {
+			mt.remove(trig)
+		}
+	case WhenDue:
+		mt.fireDue(id)
+	}
+}
+
+// autoTriggerDue fires whatever has come due under the ids that fire when
+// due. The lock must be held.
+func (mt *ManualTime) autoTriggerDue() {
+	for id, policy := range mt.autoTriggers {
+		if policy == WhenDue {
+			mt.fireDue(id)
+		}
+	}
+}
+
+// fireDue fires the live registrations under the id that have come due,
+// firing tickers until they have caught up with Now. The lock must be
+// held.
+func (mt *ManualTime) fireDue(id ID) {
+	ti, exists := mt.triggers[id]
+	if !exists || mt.closed {
+		return
+	}
+	ti.prune()
+	for _, trig := range append([]trigger(nil), ti.triggers...) {
+		for trig.live() {
+			at := pendingOf(trig).At
+			if at.IsZero() || at.After(mt.now) {
+				break
+			}
+			if mt.fireOne(trig) {
+				mt.remove(trig)
+				break
+			}
+			if !pendingOf(trig).At.After(at) {
+				// a ticker with no interval left to catch up with
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoTriggerOnRegistration(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+	mt.AutoTrigger(sleepID, OnRegistration)
+	mt.AutoTrigger(afterID, OnRegistration)
+
+	mt.Sleep(time.Hour, sleepID)
+	if fired := <-mt.After(time.Minute, afterID); fired != start.Add(time.Minute) {
+		t.Fatalf("unexpected After delivery %v", fired)
+	}
+	if mt.Now() != start {
+		t.Fatal("auto-trigger moved Now")
+	}
+
+	// other ids are unaffected
+	timer := mt.NewTimer(time.Second, timerID)
+	select {
+	case <-timer.Channel():
+		t.Fatal("timer fired without a Trigger")
+	case <-time.After(time.Millisecond):
+	}
+
+	mt.AutoTrigger(afterID, NoAutoTrigger)
+	ch := mt.After(time.Minute, afterID)
+	select {
+	case <-ch:
+		t.Fatal("After fired after auto-triggering was turned off")
+	case <-time.After(time.Millisecond):
+	}
+}
+
+func TestAutoTriggerWhenDue(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	ticker := mt.NewTicker(time.Second, tickID)
+	defer ticker.Stop()
+	timer := mt.NewTimer(90*time.Second, timerID)
+	mt.AutoTrigger(tickID, WhenDue)
+	mt.AutoTrigger(timerID, WhenDue)
+
+	mt.Advance(2500 * time.Millisecond)
+	for i := 1; i <= 2; i++ {
+		if tick := <-ticker.Channel(); tick != start.Add(time.Duration(i)*time.Second) {
+			t.Fatalf("unexpected tick %v", tick)
+		}
+	}
+	select {
+	case <-timer.Channel():
+		t.Fatal("timer fired before it was due")
+	default:
+	}
+
+	mt.Advance(88 * time.Second)
+	for i := 3; i <= 90; i++ {
+		<-ticker.Channel()
+	}
+	if fired := <-timer.Channel(); fired != start.Add(90*time.Second) {
+		t.Fatalf("unexpected timer delivery %v", fired)
+	}
+
+	// a registration already due fires as it is made
+	if fired := <-mt.After(0, timerID); fired != mt.Now() {
+		t.Fatalf("unexpected After delivery %v", fired)
+	}
+}
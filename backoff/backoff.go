@@ -0,0 +1,163 @@
+/*
+Package backoff retries operations with exponential backoff and jitter,
+waiting through an abtime.AbstractTime so that retry loops can be tested
+deterministically with a ManualTime.
+
+Everyone ends up writing this loop, and it's exactly the sort of code that
+is miserable to test against the real clock: either the test takes as long
+as the backoff does, or the backoff gets configured down to nothing and the
+test no longer tests it. Here, each wait is a SleepContext on the
+AbstractTime under the Policy's ID, and the jitter comes from the Policy's
+Rand, so a test can trigger each retry and know exactly how long it was
+supposed to wait.
+*/
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// A Policy describes how to retry.
+//
+// The zero Policy retries forever, starting at DefaultInitial and
+// doubling up to DefaultMax, without jitter.
+type Policy struct {
+	// Initial is the wait after the first failure. If zero, DefaultInitial
+	// is used.
+	Initial time.Duration
+
+	// Max caps the wait between attempts, before jitter. If zero,
+	// DefaultMax is used.
+	Max time.Duration
+
+	// Multiplier is applied to the wait after each failure. If less than
+	// 1, 2 is used.
+	Multiplier float64
+
+	// Jitter randomizes each wait by up to this fraction of it in either
+	// direction, so 0.5 yields waits between half and one and a half
+	// times the nominal value. It is clamped to [0, 1].
+	Jitter float64
+
+	// MaxAttempts is the total number of calls to make before giving up.
+	// Zero means no limit.
+	MaxAttempts int
+
+	// Rand returns a random number in [0, 1) for the jitter. If nil,
+	// Retry draws the jitter from its clock via abtime.Jitter, which is
+	// reproducible on a ManualTime, and Delay uses math/rand's Float64.
+	// Tests can also fix it to make the waits predictable.
+	Rand func() float64
+
+	// ID is the abtime ID the waits sleep under.
+	ID abtime.ID
+}
+
+// The defaults for a Policy's zero fields.
+const (
+	DefaultInitial = 100 * time.Millisecond
+	DefaultMax     = time.Minute
+)
+
+// Delay returns the wait after the given failed attempt, counting from 1.
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.nominal(attempt)
+	jitter := math.Max(0, math.Min(1, p.Jitter))
+	if jitter > 0 {
+		random := p.Rand
+		if random == nil {
+			random = rand.Float64
+		}
+		delay = time.Duration(float64(delay) * (1 - jitter + 2*jitter*random()))
+	}
+	return delay
+}
+
+// delay returns the wait after the given failed attempt as Delay does,
+// but without a Rand, drawing the jitter from the clock.
+func (p Policy) delay(attempt int, at abtime.AbstractTime) time.Duration {
+	if p.Rand != nil || p.Jitter <= 0 {
+		return p.Delay(attempt)
+	}
+	return abtime.Jitter(at, p.nominal(attempt), p.Jitter, p.ID)
+}
+
+// nominal returns the wait after the given failed attempt, before jitter.
+func (p Policy) nominal(attempt int) time.Duration {
+	initial := p.Initial
+	if initial <= 0 {
+		initial = DefaultInitial
+	}
+	max := p.Max
+	if max <= 0 {
+		max = DefaultMax
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	return time.Duration(delay)
+}
+
+type permanent struct {
+	err error
+}
+
+func (p permanent) Error() string {
+	return p.err.Error()
+}
+
+func (p permanent) Unwrap() error {
+	return p.err
+}
+
+// Permanent wraps an error to tell Retry not to try again. Retry returns
+// the wrapped error itself.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanent{err}
+}
+
+// Retry calls fn until it succeeds, returns a Permanent error, runs out
+// of attempts, or the context is done, waiting between attempts according
+// to the policy.
+//
+// It returns nil on success. Otherwise it returns the last error from fn,
+// unless the context ended the retrying, in which case it returns the
+// context's error.
+func Retry(ctx context.Context, at abtime.AbstractTime, policy Policy, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		var perm permanent
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		if waitErr := at.SleepContext(ctx, policy.delay(attempt, at), policy.ID); waitErr != nil {
+			return waitErr
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const retryID = "retry"
+
+var errFlaky = errors.New("flaky")
+
+func TestDelay(t *testing.T) {
+	p := Policy{Initial: time.Second, Max: 5 * time.Second}
+	for attempt, expected := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	} {
+		if delay := p.Delay(attempt + 1); delay != expected {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt+1, expected, delay)
+		}
+	}
+
+	p.Jitter = 0.5
+	p.Rand = func() float64 { return 0 }
+	if delay := p.Delay(1); delay != 500*time.Millisecond {
+		t.Fatalf("minimum jitter gave %v", delay)
+	}
+	p.Rand = func() float64 { return 0.75 }
+	if delay := p.Delay(1); delay != 1250*time.Millisecond {
+		t.Fatalf("jitter gave %v", delay)
+	}
+
+	if delay := (Policy{}).Delay(2); delay != 2*DefaultInitial {
+		t.Fatalf("zero policy gave %v", delay)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	mt := abtime.NewManual()
+	mt.SetAutoAdvance(true)
+	start := mt.Now()
+
+	calls := 0
+	err := Retry(context.Background(), mt, Policy{Initial: time.Second, ID: retryID}, func() error {
+		calls++
+		if calls < 4 {
+			return errFlaky
+		}
+		return nil
+	})
+	if err != nil || calls != 4 {
+		t.Fatalf("unexpected result: %v after %d calls", err, calls)
+	}
+	// waited 1 + 2 + 4 seconds
+	if waited := mt.Now().Sub(start); waited != 7*time.Second {
+		t.Fatalf("waited %v", waited)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), mt, Policy{MaxAttempts: 3, ID: retryID}, func() error {
+		calls++
+		return errFlaky
+	})
+	if err != errFlaky || calls != 3 {
+		t.Fatalf("attempts not limited: %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), mt, Policy{ID: retryID}, func() error {
+		calls++
+		return Permanent(errFlaky)
+	})
+	if err != errFlaky || calls != 1 {
+		t.Fatalf("permanent error retried: %v after %d calls", err, calls)
+	}
+}
+
+func TestRetryTriggered(t *testing.T) {
+	mt := abtime.NewManual()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := make(chan struct{}, 10)
+	result := make(chan error)
+	go func() {
+		result <- Retry(ctx, mt, Policy{ID: retryID}, func() error {
+			attempts <- struct{}{}
+			return errFlaky
+		})
+	}()
+
+	<-attempts
+	mt.Trigger(retryID)
+	<-attempts
+
+	// canceling during a wait returns the context's error
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRetryClockJitter(t *testing.T) {
+	run := func() time.Duration {
+		mt := abtime.NewManual()
+		mt.SetAutoAdvance(true)
+		start := mt.Now()
+		Retry(context.Background(), mt, Policy{Jitter: 0.5, MaxAttempts: 4, ID: retryID}, func() error {
+			return errFlaky
+		})
+		return mt.Now().Sub(start)
+	}
+
+	first, second := run(), run()
+	if first != second {
+		t.Fatalf("jitter not reproducible: waited %v then %v", first, second)
+	}
+	if nominal := 700 * time.Millisecond; first == nominal {
+		t.Fatal("no jitter applied")
+	}
+
+	mt := abtime.NewManual()
+	mt.SetAutoAdvance(true)
+	mt.SetJitterFunc(func(abtime.ID) float64 { return 0.5 })
+	start := mt.Now()
+	Retry(context.Background(), mt, Policy{Jitter: 0.5, MaxAttempts: 4, ID: retryID}, func() error {
+		return errFlaky
+	})
+	if waited := mt.Now().Sub(start); waited != 700*time.Millisecond {
+		t.Fatalf("clock jitter not used: waited %v", waited)
+	}
+}
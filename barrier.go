@@ -0,0 +1,45 @@
+package abtime
+
+import (
+	"time"
+)
+
+// A Barrier holds goroutines until every one of a set of clocks has
+// reached a given instant. This is useful for scripting skew scenarios,
+// where several simulated nodes each advance their own ManualTime at
+// different rates, but must rendezvous at some virtual time before the
+// test can proceed.
+//
+// ManualTimes are waited on directly, without registering anything, so
+// the Barrier simply returns once each has been Advanced far enough. Any
+// other AbstractTime is waited on by Sleeping on it with the Barrier's ID
+// until the instant arrives. Note this means a decorator wrapping a
+// ManualTime, such as a ChaosTime, will register a Sleep on the
+// underlying ManualTime that must be Triggered; pass the ManualTime
+// itself if that is not what you want.
+type Barrier struct {
+	clocks []AbstractTime
+	id     ID
+}
+
+// NewBarrier returns a Barrier across the given clocks. The ID is used for
+// any Sleeps the Barrier performs.
+func NewBarrier(id ID, clocks ...AbstractTime) *Barrier {
+	return &Barrier{clocks: clocks, id: id}
+}
+
+// Wait blocks until every clock in the Barrier has reached at least t.
+//
+// Since clocks never move backwards, the clocks are simply waited on in
+// turn.
+func (b *Barrier) Wait(t time.Time) {
+	for _, clock := range b.clocks {
+		if mt, isManual := clock.(*ManualTime); isManual {
+			mt.waitUntil(t)
+			continue
+		}
+		if d := clock.Until(t); d > 0 {
+			clock.Sleep(d, b.id)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	fast := NewManualAtTime(start)
+	slow := NewManualAtTime(start)
+	rendezvous := start.Add(time.Minute)
+
+	barrier := NewBarrier("barrier", fast, slow, NewRealTime())
+	done := make(chan struct{})
+	go func() {
+		barrier.Wait(rendezvous)
+		close(done)
+	}()
+
+	fast.Advance(2 * time.Minute)
+	slow.Advance(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("barrier passed before all clocks arrived")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	slow.Advance(30 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("barrier did not pass once all clocks arrived")
+	}
+
+	// queued Nows count as the clock moving, too
+	slow.QueueNows(start.Add(2 * time.Minute))
+	again := make(chan struct{})
+	go func() {
+		barrier.Wait(start.Add(90 * time.Second))
+		close(again)
+	}()
+	slow.Now()
+	<-again
+}
@@ -0,0 +1,47 @@
+package abtime
+
+import (
+	"runtime"
+)
+
+// SetBreakpoint arranges for hook to be called whenever a registration
+// under the given id is made or fires, which is handy when stepping
+// through an unfamiliar codebase's timer behavior in a debugger. If hook
+// is nil, runtime.Breakpoint is called instead, stopping in the debugger
+// right at the time event. (Without a debugger attached, that crashes the
+// program, so only leave a nil hook in place while debugging.)
+//
+// The hook is called synchronously, from the goroutine making the
+// registration or firing it, with the ManualTime locked, so it must not
+// call back into the ManualTime.
+func (mt *ManualTime) SetBreakpoint(id ID, hook func(Event)) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if hook == nil {
+		hook = func(Event) { runtime.Breakpoint() }
+	}
+	if mt.breakpoints == nil {
+		mt.breakpoints = map[ID]func(Event){}
+	}
+	mt.breakpoints[id] = hook
+}
+
+// ClearBreakpoint removes the breakpoint for the given id, if any.
+func (mt *ManualTime) ClearBreakpoint(id ID) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	delete(mt.breakpoints, id)
+}
+
+// breakpoint calls the breakpoint hook for the event, if there is one.
+// The lock must be held.
+func (mt *ManualTime) breakpoint(event Event) {
+	if event.Kind != Registered && event.Kind != Fired {
+		return
+	}
+	if hook, set := mt.breakpoints[event.ID]; set {
+		hook(event)
+	}
+}
@@ -0,0 +1,30 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakpoint(t *testing.T) {
+	mt := NewManual()
+
+	hits := []Event{}
+	mt.SetBreakpoint("interesting", func(event Event) {
+		hits = append(hits, event)
+	})
+
+	timer := mt.NewTimer(time.Second, "interesting")
+	mt.NewTimer(time.Second, "boring")
+	mt.Trigger("interesting", "boring")
+	<-timer.Channel()
+
+	if len(hits) != 2 || hits[0].Kind != Registered || hits[1].Kind != Fired {
+		t.Fatalf("unexpected breakpoint hits: %v", hits)
+	}
+
+	mt.ClearBreakpoint("interesting")
+	mt.NewTimer(time.Second, "interesting")
+	if len(hits) != 2 {
+		t.Fatalf("cleared breakpoint still hit: %v", hits)
+	}
+}
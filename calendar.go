@@ -0,0 +1,107 @@
+package abtime
+
+import "time"
+
+// A Step computes the instants a ManualTime steps through in StepUntil:
+// given the starting instant, already in the step location, it returns the
+// i'th step from it, for i starting at 1.
+//
+// Steps are computed from the start rather than from the previous step so
+// that calendar arithmetic doesn't drift; stepping monthly from January 31
+// gives February 28 (or 29), March 31, April 30, and so on, rather than
+// getting stuck on the 28th.
+type Step func(start time.Time, i int) time.Time
+
+// Days steps by n calendar days. Across a daylight saving transition, a
+// calendar day is 23 or 25 hours, keeping the wall clock time the same.
+func Days(n int) Step {
+	return func(start time.Time, i int) time.Time {
+		return start.AddDate(0, 0, n*i)
+	}
+}
+
+// Months steps by n calendar months, keeping the day of the month where
+// possible and otherwise using the last day of the month.
+func Months(n int) Step {
+	return func(start time.Time, i int) time.Time {
+		year, month, day := start.Date()
+		hour, min, sec := start.Clock()
+		first := time.Date(year, month+time.Month(n*i), 1, hour, min, sec,
+			start.Nanosecond(), start.Location())
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+}
+
+// Interval steps by a fixed duration.
+func Interval(d time.Duration) Step {
+	return func(start time.Time, i int) time.Time {
+		return start.Add(d * time.Duration(i))
+	}
+}
+
+// SetStepLocation sets the time.Location calendar steps are computed in
+// by StepDays and StepUntil, which matters for where days begin and for
+// daylight saving transitions. By default it is the location of Now. This
+// doesn't change the location Now itself is reported in.
+func (mt *ManualTime) SetStepLocation(loc *time.Location) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.stepLocation = loc
+}
+
+// StepDays advances Now by one calendar day n times, calling fn with the
+// new Now after each. See StepUntil.
+func (mt *ManualTime) StepDays(n int, fn func(now time.Time)) {
+	start := mt.stepStart()
+	mt.StepUntil(Days(1)(start, n), Days(1), fn)
+}
+
+// StepUntil advances Now step by step until the next step would pass end,
+// calling fn with the new Now after each step. This walks billing and
+// scheduling code through day and month boundaries, daylight saving
+// transitions, and leap days, checking it at each.
+//
+// Each step moves Now just as AdvanceTo does, so it fires nothing but the
+// IDs set to AutoTrigger WhenDue, and cancels contexts made by
+// WithDeadlineAuto; give the IDs that should keep up with the calendar
+// that policy. StepUntil then yields, as RunUntilIdle does, so the code
+// they woke can run, but it never waits for anything to be consumed. fn
+// is called after that, with the ManualTime unlocked.
+func (mt *ManualTime) StepUntil(end time.Time, step Step, fn func(now time.Time)) {
+	start := mt.stepStart()
+	loc := mt.currentNow().Location()
+	prev := start
+	for i := 1; ; i++ {
+		next := step(start, i)
+		if next.After(end) {
+			return
+		}
+		if !next.After(prev) {
+			panic("abtime: calendar step did not advance")
+		}
+		prev = next
+		if next.Location() != loc {
+			next = next.In(loc)
+		}
+		mt.AdvanceTo(next)
+		mt.settle()
+		if fn != nil {
+			fn(mt.currentNow())
+		}
+	}
+}
+
+// stepStart returns the current Now in the step location.
+func (mt *ManualTime) stepStart() time.Time {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.stepLocation == nil {
+		return mt.now
+	}
+	return mt.now.In(mt.stepLocation)
+}
@@ -0,0 +1,84 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepUntilMonths(t *testing.T) {
+	mt := NewManualAtTime(time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC))
+
+	days := []int{}
+	mt.StepUntil(time.Date(2024, 5, 31, 9, 0, 0, 0, time.UTC), Months(1), func(now time.Time) {
+		days = append(days, now.Day())
+	})
+	if len(days) != 4 || days[0] != 29 || days[1] != 31 || days[2] != 30 || days[3] != 31 {
+		t.Fatalf("unexpected month ends: %v", days)
+	}
+}
+
+func TestStepDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no timezone database available")
+	}
+
+	// 2024-03-10 is the spring forward in New York
+	mt := NewManualAtTime(time.Date(2024, 3, 9, 12, 0, 0, 0, ny).UTC())
+	mt.SetStepLocation(ny)
+
+	start := mt.Now()
+	mt.AutoTrigger(timerID, WhenDue)
+	timer := mt.NewTimer(30*time.Hour, timerID)
+	fired := make(chan time.Time, 1)
+	go func() { fired <- <-timer.Channel() }()
+
+	// never received, and not auto-triggered, so stepping must neither
+	// fire it nor wait on it
+	mt.NewTicker(time.Hour, tickID)
+
+	steps := []time.Time{}
+	mt.StepDays(3, func(now time.Time) {
+		steps = append(steps, now)
+	})
+	if len(steps) != 3 {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+	for _, step := range steps {
+		if step.In(ny).Hour() != 12 {
+			t.Fatalf("step %v is not at noon in New York", step.In(ny))
+		}
+		if step.Location() != time.UTC {
+			t.Fatal("stepping changed the location of Now")
+		}
+	}
+	if steps[0].Sub(start) != 23*time.Hour || steps[1].Sub(steps[0]) != 24*time.Hour {
+		t.Fatalf("unexpected day lengths: %v", steps)
+	}
+
+	// the timer came due during the second step
+	select {
+	case at := <-fired:
+		if !at.Equal(start.Add(30 * time.Hour)) {
+			t.Fatalf("timer delivered %v", at)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timer did not fire while stepping")
+	}
+	if mt.Delivered(tickID) != 0 {
+		t.Fatal("stepping fired a ticker that is not auto-triggered")
+	}
+}
+
+func TestStepInterval(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	count := 0
+	mt.StepUntil(start.Add(time.Hour), Interval(25*time.Minute), func(time.Time) {
+		count++
+	})
+	if count != 2 || !mt.Now().Equal(start.Add(50*time.Minute)) {
+		t.Fatalf("stepped %d times to %v", count, mt.Now())
+	}
+}
@@ -0,0 +1,162 @@
+package abtime
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// A LatencyRule describes a delay a ChaosTime injects into the timers it
+// matches.
+//
+// A rule can be keyed by IDs, by the requested duration, or both. Keying
+// on durations lets you reach timers inside libraries, which generally
+// carry no meaningful IDs.
+type LatencyRule struct {
+	// If IDs is non-empty, the rule only matches those IDs. An ID that
+	// isn't comparable, such as a slice, never matches.
+	IDs []ID
+
+	// The rule only matches requested durations of at least Min and, if
+	// Max is non-zero, at most Max.
+	Min time.Duration
+	Max time.Duration
+
+	// A matched duration is increased by Factor times itself, plus
+	// Extra. A Factor of 0.2 delays by 20%.
+	Factor float64
+	Extra  time.Duration
+}
+
+func (lr LatencyRule) matches(d time.Duration, id ID) bool {
+	if d < lr.Min || (lr.Max != 0 && d > lr.Max) {
+		return false
+	}
+	if len(lr.IDs) == 0 {
+		return true
+	}
+	if id != nil && !reflect.TypeOf(id).Comparable() {
+		// comparing it to a rule's ID of the same type would panic
+		return false
+	}
+	for _, ruleID := range lr.IDs {
+		if ruleID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ChaosTime decorates another AbstractTime, typically a RealTime, delaying
+// the timers, tickers, sleeps, and timeouts that match its LatencyRules.
+// The first matching rule applies; durations matching no rule pass
+// through untouched.
+//
+// Only the duration requested at creation is adjusted; Reset calls on the
+// resulting Timers and Tickers pass straight through.
+type ChaosTime struct {
+	AbstractTime
+
+	rules []LatencyRule
+	mu    sync.Mutex
+}
+
+// NewChaosTime returns a ChaosTime wrapping the given AbstractTime with
+// the given rules.
+func NewChaosTime(at AbstractTime, rules ...LatencyRule) *ChaosTime {
+	return &ChaosTime{AbstractTime: at, rules: rules}
+}
+
+// realClock reports whether the wrapped clock follows the real clock; see
+// CoalescedTime.
+func (ct *ChaosTime) realClock() bool {
+	return isRealClock(ct.AbstractTime)
+}
+
+// AddRule appends a rule, which is consulted after all existing rules.
+func (ct *ChaosTime) AddRule(rule LatencyRule) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.rules = append(ct.rules, rule)
+}
+
+// adjust returns the duration to actually use for the given request.
+func (ct *ChaosTime) adjust(d time.Duration, id ID) time.Duration {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	for _, rule := range ct.rules {
+		if rule.matches(d, id) {
+			return d + time.Duration(float64(d)*rule.Factor) + rule.Extra
+		}
+	}
+	return d
+}
+
+// After delays the wrapped After per the rules.
+func (ct *ChaosTime) After(d time.Duration, id ID) <-chan time.Time {
+	return ct.AbstractTime.After(ct.adjust(d, id), id)
+}
+
+// Sleep delays the wrapped Sleep per the rules.
+func (ct *ChaosTime) Sleep(d time.Duration, id ID) {
+	ct.AbstractTime.Sleep(ct.adjust(d, id), id)
+}
+
+// SleepContext delays the wrapped SleepContext per the rules.
+func (ct *ChaosTime) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	return ct.AbstractTime.SleepContext(ctx, ct.adjust(d, id), id)
+}
+
+// Tick delays the wrapped Tick per the rules.
+func (ct *ChaosTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	return ct.AbstractTime.Tick(ct.adjust(d, id), id)
+}
+
+// NewTicker delays the wrapped NewTicker per the rules.
+func (ct *ChaosTime) NewTicker(d time.Duration, id ID) Ticker {
+	return ct.AbstractTime.NewTicker(ct.adjust(d, id), id)
+}
+
+// AfterFunc delays the wrapped AfterFunc per the rules.
+func (ct *ChaosTime) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	return ct.AbstractTime.AfterFunc(ct.adjust(d, id), f, id)
+}
+
+// NewTimer delays the wrapped NewTimer per the rules.
+func (ct *ChaosTime) NewTimer(d time.Duration, id ID) Timer {
+	return ct.AbstractTime.NewTimer(ct.adjust(d, id), id)
+}
+
+// NewTimerAt delays the wrapped NewTimerAt per the rules, matching on the
+// duration until the requested time.
+func (ct *ChaosTime) NewTimerAt(t time.Time, id ID) Timer {
+	d := ct.Until(t)
+	return ct.AbstractTime.NewTimerAt(t.Add(ct.adjust(d, id)-d), id)
+}
+
+// WithTimeout delays the wrapped WithTimeout per the rules.
+func (ct *ChaosTime) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	return ct.AbstractTime.WithTimeout(parent, ct.adjust(timeout, id), id)
+}
+
+// WithDeadline delays the wrapped WithDeadline per the rules, matching on
+// the duration until the deadline.
+func (ct *ChaosTime) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	d := ct.Until(deadline)
+	return ct.AbstractTime.WithDeadline(parent, deadline.Add(ct.adjust(d, id)-d), id)
+}
+
+// WithTimeoutCause delays the wrapped WithTimeoutCause per the rules.
+func (ct *ChaosTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	return ct.AbstractTime.WithTimeoutCause(parent, ct.adjust(timeout, id), cause, id)
+}
+
+// WithDeadlineCause delays the wrapped WithDeadlineCause per the rules,
+// matching on the duration until the deadline.
+func (ct *ChaosTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	d := ct.Until(deadline)
+	return ct.AbstractTime.WithDeadlineCause(parent, deadline.Add(ct.adjust(d, id)-d), cause, id)
+}
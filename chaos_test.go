@@ -0,0 +1,40 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChaosRules(t *testing.T) {
+	ct := NewChaosTime(NewRealTime(),
+		LatencyRule{IDs: []ID{[]int{1}, "special"}, Extra: 50 * time.Millisecond},
+		LatencyRule{Min: time.Second, Max: 5 * time.Second, Factor: 0.2},
+	)
+	ct.AddRule(LatencyRule{Min: time.Hour, Extra: time.Minute})
+
+	for _, test := range []struct {
+		d        time.Duration
+		id       ID
+		expected time.Duration
+	}{
+		{time.Millisecond, "special", 51 * time.Millisecond},
+		{time.Millisecond, 0, time.Millisecond},
+		{time.Millisecond, []int{1}, time.Millisecond},
+		{time.Second, 0, 1200 * time.Millisecond},
+		{5 * time.Second, 0, 6 * time.Second},
+		{6 * time.Second, 0, 6 * time.Second},
+		{2 * time.Hour, 0, 2*time.Hour + time.Minute},
+	} {
+		if adjusted := ct.adjust(test.d, test.id); adjusted != test.expected {
+			t.Fatalf("%v for id %v adjusted to %v, expected %v",
+				test.d, test.id, adjusted, test.expected)
+		}
+	}
+
+	// and make sure the wrapping actually works
+	start := time.Now()
+	ct.Sleep(time.Millisecond, "special")
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("sleep was not delayed")
+	}
+}
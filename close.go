@@ -0,0 +1,41 @@
+package abtime
+
+// Close tears the ManualTime down, releasing every goroutine waiting on
+// it, so that a test failing part way through doesn't leave them leaked
+// for the life of the test binary, and goroutine leak checkers stay
+// quiet.
+//
+// Every pending Sleep wakes, with SleepErr and SleepContext returning
+// ErrClosed. The channels of pending Afters, timers, and tickers are
+// closed, as are those of deliveries still waiting to be received, so
+// receivers wake with the zero time and range loops over tickers end.
+// Pending contexts are canceled, and pending AfterFuncs are dropped
+// without running.
+//
+// After Close, new registrations fail fast in the same way: Sleeps return
+// at once, channels come already closed, and contexts come already
+// canceled. Triggers do nothing. Note code polling a closed channel in a
+// loop will spin, so code under test should still be stopped properly.
+//
+// Closing more than once does nothing.
+func (mt *ManualTime) Close() {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.closed {
+		return
+	}
+	mt.closed = true
+	close(mt.closing)
+
+	for _, ti := range mt.triggers {
+		for _, trig := range ti.triggers {
+			trig.close(mt)
+		}
+	}
+	for _, trig := range mt.expired {
+		trig.close(mt)
+	}
+	mt.triggers = map[ID]*triggerInfo{}
+	mt.deadlines = nil
+}
@@ -0,0 +1,83 @@
+package abtime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	mt := NewManual()
+	ran := false
+
+	var wg sync.WaitGroup
+	wait := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+	wait(func() { mt.Sleep(time.Second, sleepID) })
+	wait(func() {
+		if mt.SleepErr(time.Second, sleepID) != ErrClosed {
+			t.Error("SleepErr not woken with ErrClosed")
+		}
+	})
+	wait(func() {
+		if mt.SleepContext(context.Background(), time.Second, sleepID) != ErrClosed {
+			t.Error("SleepContext not woken with ErrClosed")
+		}
+	})
+	after := mt.After(time.Second, afterID)
+	wait(func() { <-after })
+	ticker := mt.NewTicker(time.Second, tickID)
+	wait(func() {
+		for range ticker.Channel() {
+		}
+	})
+	timer := mt.NewTimer(time.Second, timerID)
+	wait(func() { <-timer.Channel() })
+	ctx, cancel := mt.WithCancel(context.Background(), contextID)
+	defer cancel()
+	wait(func() { <-ctx.Done() })
+	mt.AfterFunc(time.Second, func() { ran = true }, afterFuncID)
+
+	// a delivery nobody receives before Close
+	fired := mt.NewTimer(time.Second, "fired")
+	mt.Trigger("fired")
+
+	for len(mt.Pending(sleepID)) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	mt.Close()
+	mt.Close()
+	wg.Wait()
+
+	select {
+	case <-fired.Channel():
+	case <-time.After(time.Second):
+		t.Fatal("undelivered timer not closed")
+	}
+
+	mt.Trigger(afterFuncID)
+	if ran {
+		t.Fatal("AfterFunc ran after Close")
+	}
+
+	// new registrations fail fast
+	if mt.SleepErr(time.Hour, sleepID) != ErrClosed {
+		t.Fatal("Sleep after Close did not fail")
+	}
+	<-mt.After(time.Hour, afterID)
+	<-mt.NewTimer(time.Hour, timerID).Channel()
+	for range mt.Tick(time.Hour, tickID) {
+	}
+	ctx, cancel = mt.WithTimeout(context.Background(), time.Hour, contextID)
+	defer cancel()
+	<-ctx.Done()
+	if len(mt.PendingIDs()) != 0 {
+		t.Fatal("registrations made after Close")
+	}
+}
@@ -0,0 +1,56 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoalescedManual(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+	ct := NewCoalescedTime(mt, 10*time.Millisecond)
+
+	if ct.Now() != start {
+		t.Fatal("first read not the clock's Now")
+	}
+	mt.Advance(9 * time.Millisecond)
+	if ct.Now() != start || ct.Since(start) != 0 {
+		t.Fatal("read within the window not coalesced")
+	}
+	if ct.Until(start.Add(time.Second)) != time.Second {
+		t.Fatal("Until not using the cached Now")
+	}
+	mt.Advance(time.Millisecond)
+	if ct.Now() != start.Add(10*time.Millisecond) {
+		t.Fatal("read a full window later not refreshed")
+	}
+}
+
+func TestCoalescedReal(t *testing.T) {
+	ct := NewCoalescedTime(NewRealTime(), time.Hour)
+	first := ct.Now()
+	time.Sleep(time.Millisecond)
+	if ct.Now() != first || ct.Since(first) != 0 {
+		t.Fatal("real reads not coalesced")
+	}
+
+	ct = NewCoalescedTime(NewRealTime(), time.Millisecond)
+	first = ct.Now()
+	time.Sleep(20 * time.Millisecond)
+	if !ct.Now().After(first) {
+		t.Fatal("real cache never expired")
+	}
+
+	// real clocks are recognized however they are held or wrapped
+	rt := NewRealTimeWithSemantics(Go123Timers)
+	for _, at := range []AbstractTime{&rt, rt, NewRealTimeInstrumented(&UsageLog{}),
+		NewMeteredTime(rt, nil), NewTruncatedTime(rt, time.Second),
+		NewChaosTime(NewMeteredTime(&rt, nil))} {
+		if !NewCoalescedTime(at, time.Hour).overRealTime {
+			t.Fatalf("%T not coalesced as a real clock", at)
+		}
+	}
+	if NewCoalescedTime(NewMeteredTime(NewManual(), nil), time.Hour).overRealTime {
+		t.Fatal("metered ManualTime coalesced as a real clock")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Package compat provides Timer and Ticker structs shaped like the time
+package's, with a public C field, backed by any abtime.AbstractTime.
+
+Some code, often third-party, takes a *time.Timer or *time.Ticker directly
+and reads its C field, so it can't be handed an abtime.Timer without
+rewriting it to use an interface. There's no way to construct a real
+*time.Timer that abtime controls, but changing such code from *time.Timer
+to *compat.Timer is a mechanical edit; the fields and methods it uses are
+the same:
+
+	// before
+	func poll(t *time.Timer) { <-t.C; ... }
+
+	// after
+	func poll(t *compat.Timer) { <-t.C; ... }
+
+Production code then creates its timers with compat.NewTimer on a
+RealTime, and tests on a ManualTime, triggering them by ID as usual.
+
+To go the other way, Timer and Ticker return the abtime.Timer and
+abtime.Ticker underneath, and abtime.StdTimer and abtime.StdTicker can
+recover the real *time.Timer or *time.Ticker beneath those, if there is one.
+*/
+package compat
+
+import (
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// A Timer is a stand-in for *time.Timer, backed by an abtime.Timer.
+type Timer struct {
+	// C delivers the time the timer fires, just as time.Timer's does.
+	// It is nil for timers created by AfterFunc.
+	C <-chan time.Time
+
+	timer abtime.Timer
+}
+
+// NewTimer creates a Timer on the given AbstractTime, as time.NewTimer.
+func NewTimer(at abtime.AbstractTime, d time.Duration, id abtime.ID) *Timer {
+	return FromTimer(at.NewTimer(d, id))
+}
+
+// AfterFunc creates a Timer on the given AbstractTime that runs f, as
+// time.AfterFunc. Its C is nil.
+func AfterFunc(at abtime.AbstractTime, d time.Duration, f func(), id abtime.ID) *Timer {
+	return FromTimer(at.AfterFunc(d, f, id))
+}
+
+// FromTimer converts an abtime.Timer into a Timer. The Timer shares its
+// channel and state with the abtime.Timer, so either can be used to stop
+// or reset it.
+func FromTimer(t abtime.Timer) *Timer {
+	return &Timer{C: t.Channel(), timer: t}
+}
+
+// Stop stops the timer, as time.Timer.Stop.
+func (t *Timer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// Reset changes the timer to expire after d, as time.Timer.Reset.
+func (t *Timer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+// Timer returns the abtime.Timer backing the Timer.
+func (t *Timer) Timer() abtime.Timer {
+	return t.timer
+}
+
+// A Ticker is a stand-in for *time.Ticker, backed by an abtime.Ticker.
+type Ticker struct {
+	// C delivers the ticks, just as time.Ticker's does.
+	C <-chan time.Time
+
+	ticker abtime.Ticker
+}
+
+// NewTicker creates a Ticker on the given AbstractTime, as time.NewTicker.
+func NewTicker(at abtime.AbstractTime, d time.Duration, id abtime.ID) *Ticker {
+	return FromTicker(at.NewTicker(d, id))
+}
+
+// FromTicker converts an abtime.Ticker into a Ticker. As with FromTimer,
+// the two share their channel and state.
+func FromTicker(t abtime.Ticker) *Ticker {
+	return &Ticker{C: t.Channel(), ticker: t}
+}
+
+// Stop turns off the ticker, as time.Ticker.Stop.
+func (t *Ticker) Stop() {
+	t.ticker.Stop()
+}
+
+// Reset stops the ticker and resets its period to d, as
+// time.Ticker.Reset.
+func (t *Ticker) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+}
+
+// Ticker returns the abtime.Ticker backing the Ticker.
+func (t *Ticker) Ticker() abtime.Ticker {
+	return t.ticker
+}
@@ -0,0 +1,64 @@
+package compat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const (
+	timerID = iota
+	funcID
+	tickerID
+)
+
+func TestManual(t *testing.T) {
+	mt := abtime.NewManual()
+	now := mt.Now()
+
+	timer := NewTimer(mt, time.Second, timerID)
+	mt.Trigger(timerID)
+	if fired := <-timer.C; fired != now.Add(time.Second) {
+		t.Fatalf("unexpected fire time %v", fired)
+	}
+	if timer.Reset(time.Minute) {
+		t.Fatal("Reset of a fired timer returned true")
+	}
+	if !timer.Stop() {
+		t.Fatal("Stop of a reset timer returned false")
+	}
+
+	ran := make(chan struct{})
+	af := AfterFunc(mt, time.Second, func() { close(ran) }, funcID)
+	if af.C != nil {
+		t.Fatal("AfterFunc has a channel")
+	}
+	mt.Trigger(funcID)
+	<-ran
+
+	ticker := NewTicker(mt, time.Second, tickerID)
+	defer ticker.Stop()
+	mt.Trigger(tickerID)
+	if tick := <-ticker.C; tick != now.Add(time.Second) {
+		t.Fatalf("unexpected tick %v", tick)
+	}
+	if _, isManual := ticker.Ticker().(abtime.RecordingTicker); !isManual {
+		t.Fatal("Ticker did not return the underlying ticker")
+	}
+}
+
+func TestReal(t *testing.T) {
+	rt := abtime.NewRealTime()
+
+	timer := NewTimer(rt, time.Millisecond, timerID)
+	<-timer.C
+	if _, ok := abtime.StdTimer(timer.Timer()); !ok {
+		t.Fatal("could not recover the real timer")
+	}
+
+	ticker := NewTicker(rt, time.Millisecond, tickerID)
+	<-ticker.C
+	ticker.Reset(time.Hour)
+	ticker.Stop()
+}
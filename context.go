@@ -0,0 +1,24 @@
+package abtime
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a copy of the parent context carrying the given
+// AbstractTime, retrievable with FromContext.
+//
+// This allows libraries to pick up an injected clock from the context
+// they are already being handed, rather than needing an extra
+// constructor parameter threaded all the way down.
+func NewContext(parent context.Context, at AbstractTime) context.Context {
+	return context.WithValue(parent, contextKey{}, at)
+}
+
+// FromContext returns the AbstractTime carried by the context, or a
+// RealTime if it carries none.
+func FromContext(ctx context.Context) AbstractTime {
+	if at, ok := ctx.Value(contextKey{}).(AbstractTime); ok {
+		return at
+	}
+	return NewRealTime()
+}
@@ -0,0 +1,18 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClockContext(t *testing.T) {
+	if _, isReal := FromContext(context.Background()).(RealTime); !isReal {
+		t.Fatal("FromContext does not default to RealTime")
+	}
+
+	mt := NewManual()
+	ctx := NewContext(context.Background(), mt)
+	if FromContext(ctx) != mt {
+		t.Fatal("FromContext did not return the injected clock")
+	}
+}
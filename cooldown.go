@@ -0,0 +1,49 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// A Cooldown permits one action per window of time, as measured by the
+// Now of its AbstractTime. It is useful for alert deduplication and other
+// rate-gated side effects.
+//
+// The window is half-open: an action allowed at time T blocks further
+// actions until exactly T plus the window, at which point the next one is
+// allowed.
+type Cooldown struct {
+	at      AbstractTime
+	window  time.Duration
+	resetAt time.Time
+	mu      sync.Mutex
+}
+
+// NewCooldown returns a new Cooldown for the given window. The first
+// action is always allowed.
+func NewCooldown(at AbstractTime, window time.Duration) *Cooldown {
+	return &Cooldown{at: at, window: window}
+}
+
+// Allow returns whether an action is permitted now. If it is, the action
+// is assumed to be taken, and the cooldown starts over.
+func (c *Cooldown) Allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.at.Now()
+	if now.Before(c.resetAt) {
+		return false
+	}
+	c.resetAt = now.Add(c.window)
+	return true
+}
+
+// ResetAt returns the time at which the next action will be allowed. If
+// no action has been allowed yet, this is the zero time.
+func (c *Cooldown) ResetAt() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resetAt
+}
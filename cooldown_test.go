@@ -0,0 +1,34 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldown(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+	cooldown := NewCooldown(mt, time.Minute)
+
+	if !cooldown.ResetAt().IsZero() || !cooldown.Allow() {
+		t.Fatal("first action not allowed")
+	}
+	if cooldown.ResetAt() != start.Add(time.Minute) {
+		t.Fatal("wrong ResetAt")
+	}
+	if cooldown.Allow() {
+		t.Fatal("second action allowed within the window")
+	}
+
+	mt.Advance(time.Minute - time.Nanosecond)
+	if cooldown.Allow() {
+		t.Fatal("action allowed a nanosecond before the window closed")
+	}
+	mt.Advance(time.Nanosecond)
+	if !cooldown.Allow() {
+		t.Fatal("action not allowed at the window's edge")
+	}
+	if cooldown.ResetAt() != start.Add(2*time.Minute) {
+		t.Fatal("cooldown did not restart from the allowed action")
+	}
+}
@@ -0,0 +1,58 @@
+package abtime
+
+import (
+	"context"
+	"time"
+)
+
+// A Deadline is an instant bound to the clock it was created from.
+//
+// Passing deadlines around as bare time.Time values loses track of which
+// clock they belong to, which tempts code back to time.Now. A Deadline
+// answers all its questions through its own clock.
+type Deadline struct {
+	at AbstractTime
+	t  time.Time
+}
+
+// DeadlineIn returns a Deadline d from the clock's current Now.
+func DeadlineIn(at AbstractTime, d time.Duration) Deadline {
+	return Deadline{at, at.Now().Add(d)}
+}
+
+// DeadlineAt returns a Deadline at the given time on the given clock.
+func DeadlineAt(at AbstractTime, t time.Time) Deadline {
+	return Deadline{at, t}
+}
+
+// Time returns the instant of the deadline.
+func (d Deadline) Time() time.Time {
+	return d.t
+}
+
+// Remaining returns how long remains until the deadline, or zero if it
+// has passed.
+func (d Deadline) Remaining() time.Duration {
+	remaining := d.at.Until(d.t)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Expired returns whether the clock has reached the deadline.
+func (d Deadline) Expired() bool {
+	return !d.at.Now().Before(d.t)
+}
+
+// Context returns a context bound by the deadline, via the clock's
+// WithDeadline.
+func (d Deadline) Context(parent context.Context, id ID) (context.Context, context.CancelFunc) {
+	return d.at.WithDeadline(parent, d.t, id)
+}
+
+// Timer returns a Timer that fires at the deadline, via the clock's
+// NewTimerAt.
+func (d Deadline) Timer(id ID) Timer {
+	return d.at.NewTimerAt(d.t, id)
+}
@@ -0,0 +1,36 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	mt := NewManual()
+	deadline := DeadlineIn(mt, time.Minute)
+
+	if deadline.Expired() || deadline.Remaining() != time.Minute {
+		t.Fatal("fresh deadline is not a minute away")
+	}
+	if DeadlineAt(mt, deadline.Time()) != deadline {
+		t.Fatal("DeadlineAt did not produce the same deadline")
+	}
+
+	ctx, cancel := deadline.Context(context.Background(), contextID)
+	defer cancel()
+	if ctxDeadline, _ := ctx.Deadline(); ctxDeadline != deadline.Time() {
+		t.Fatal("context does not carry the deadline")
+	}
+
+	timer := deadline.Timer(timerID)
+	go mt.Trigger(timerID)
+	if <-timer.Channel() != deadline.Time() {
+		t.Fatal("timer did not fire at the deadline")
+	}
+
+	mt.Advance(2 * time.Minute)
+	if !deadline.Expired() || deadline.Remaining() != 0 {
+		t.Fatal("deadline should have expired")
+	}
+}
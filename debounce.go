@@ -0,0 +1,202 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// A Debouncer delays calls to a function until they have stopped coming
+// for a while; see Debounce.
+type Debouncer struct {
+	at      AbstractTime
+	d       time.Duration
+	id      ID
+	fn      func()
+	timer   Timer
+	gen     int
+	pending bool
+	stopped bool
+	mu      sync.Mutex
+}
+
+// Debounce returns a Debouncer that runs fn once d has passed without
+// another Call. Each Call restarts the wait, using an AfterFunc on the
+// AbstractTime with the given ID, so on a ManualTime triggering the ID
+// runs fn as if the calls had gone quiet.
+//
+// fn runs in its own goroutine, as with AfterFunc, except when run by
+// Flush.
+func Debounce(at AbstractTime, d time.Duration, id ID, fn func()) *Debouncer {
+	return &Debouncer{at: at, d: d, id: id, fn: fn}
+}
+
+// Call requests a run of the function, once calls stop for the
+// debouncer's duration.
+func (db *Debouncer) Call() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.stopped {
+		return
+	}
+	if db.timer != nil {
+		db.timer.Stop()
+	}
+	db.gen++
+	db.pending = true
+	gen := db.gen
+	db.timer = db.at.AfterFunc(db.d, func() { db.fire(gen) }, db.id)
+}
+
+func (db *Debouncer) fire(gen int) {
+	db.mu.Lock()
+	if !db.pending || db.gen != gen {
+		db.mu.Unlock()
+		return
+	}
+	db.pending = false
+	db.timer = nil
+	db.mu.Unlock()
+
+	db.fn()
+}
+
+// take claims the pending run, if any, stopping its timer.
+func (db *Debouncer) take() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.timer != nil {
+		db.timer.Stop()
+		db.timer = nil
+	}
+	db.gen++
+	pending := db.pending
+	db.pending = false
+	return pending
+}
+
+// Flush runs the function immediately, in the calling goroutine, if a run
+// is pending, rather than waiting for the calls to go quiet. It returns
+// whether it ran.
+func (db *Debouncer) Flush() bool {
+	if !db.take() {
+		return false
+	}
+	db.fn()
+	return true
+}
+
+// Stop discards any pending run, and makes further Calls do nothing. It
+// returns whether a run was discarded.
+func (db *Debouncer) Stop() bool {
+	db.mu.Lock()
+	db.stopped = true
+	db.mu.Unlock()
+
+	return db.take()
+}
+
+// A Throttler limits calls to a function to one per period; see Throttle.
+type Throttler struct {
+	at       AbstractTime
+	d        time.Duration
+	id       ID
+	fn       func()
+	timer    Timer
+	gen      int
+	inWindow bool
+	pending  bool
+	stopped  bool
+	mu       sync.Mutex
+}
+
+// Throttle returns a Throttler that runs fn at most once per d. A Call
+// outside of a period runs fn immediately, in the calling goroutine, and
+// starts a period. Calls within the period are collapsed into a single
+// run when it ends, which starts another period.
+//
+// Periods are timed with an AfterFunc on the AbstractTime under the given
+// ID, so on a ManualTime triggering the ID ends the current period.
+func Throttle(at AbstractTime, d time.Duration, id ID, fn func()) *Throttler {
+	return &Throttler{at: at, d: d, id: id, fn: fn}
+}
+
+// startWindow starts a new period. The lock must be held.
+func (th *Throttler) startWindow() {
+	th.gen++
+	th.inWindow = true
+	gen := th.gen
+	th.timer = th.at.AfterFunc(th.d, func() { th.endWindow(gen) }, th.id)
+}
+
+// Call requests a run of the function, immediately if no period is
+// running, or else at the end of the current one.
+func (th *Throttler) Call() {
+	th.mu.Lock()
+	if th.stopped {
+		th.mu.Unlock()
+		return
+	}
+	if th.inWindow {
+		th.pending = true
+		th.mu.Unlock()
+		return
+	}
+	th.startWindow()
+	th.mu.Unlock()
+
+	th.fn()
+}
+
+func (th *Throttler) endWindow(gen int) {
+	th.mu.Lock()
+	if th.gen != gen {
+		th.mu.Unlock()
+		return
+	}
+	th.inWindow = false
+	th.timer = nil
+	if !th.pending || th.stopped {
+		th.mu.Unlock()
+		return
+	}
+	th.pending = false
+	th.startWindow()
+	th.mu.Unlock()
+
+	th.fn()
+}
+
+// Flush runs the function immediately, in the calling goroutine, if a run
+// is pending at the end of the current period. The period itself carries
+// on. It returns whether it ran.
+func (th *Throttler) Flush() bool {
+	th.mu.Lock()
+	pending := th.pending
+	th.pending = false
+	th.mu.Unlock()
+
+	if pending {
+		th.fn()
+	}
+	return pending
+}
+
+// Stop ends the current period, discarding any pending run, and makes
+// further Calls do nothing. It returns whether a run was discarded.
+func (th *Throttler) Stop() bool {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+
+	th.stopped = true
+	if th.timer != nil {
+		th.timer.Stop()
+		th.timer = nil
+	}
+	th.gen++
+	th.inWindow = false
+	pending := th.pending
+	th.pending = false
+	return pending
+}
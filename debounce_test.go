@@ -0,0 +1,96 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+const debounceID = "debounce"
+
+func TestDebounce(t *testing.T) {
+	mt := NewManual()
+	ran := make(chan struct{}, 10)
+	db := Debounce(mt, time.Second, debounceID, func() { ran <- struct{}{} })
+
+	db.Call()
+	db.Call()
+	db.Call()
+	mt.Trigger(debounceID)
+	<-ran
+	// only the last call's timer was live, so that was the only run
+	mt.Trigger(debounceID)
+	select {
+	case <-ran:
+		t.Fatal("debounced function ran twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+	mt.Unregister(debounceID)
+
+	if db.Flush() {
+		t.Fatal("flushed with nothing pending")
+	}
+	db.Call()
+	if !db.Flush() {
+		t.Fatal("pending run not flushed")
+	}
+	<-ran
+
+	db.Call()
+	if !db.Stop() {
+		t.Fatal("pending run not discarded")
+	}
+	db.Call()
+	if db.Flush() {
+		t.Fatal("call after Stop was pending")
+	}
+	if len(ran) != 0 {
+		t.Fatal("stopped debouncer ran")
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	mt := NewManual()
+	ran := make(chan struct{}, 10)
+	th := Throttle(mt, time.Second, debounceID, func() { ran <- struct{}{} })
+
+	// leading edge runs immediately
+	th.Call()
+	if len(ran) != 1 {
+		t.Fatal("first call did not run immediately")
+	}
+	<-ran
+
+	// calls within the period collapse into one at its end
+	th.Call()
+	th.Call()
+	if len(ran) != 0 {
+		t.Fatal("call within the period ran immediately")
+	}
+	mt.Trigger(debounceID)
+	<-ran
+
+	// that run started another period
+	th.Call()
+	if len(ran) != 0 {
+		t.Fatal("trailing run did not start a period")
+	}
+	if !th.Flush() || len(ran) != 1 {
+		t.Fatal("pending run not flushed")
+	}
+	<-ran
+
+	// a period with nothing pending just ends
+	mt.Trigger(debounceID)
+	mt.WaitConsumed(debounceID, 2)
+	th.Call()
+	<-ran
+
+	th.Call()
+	if !th.Stop() {
+		t.Fatal("pending run not discarded")
+	}
+	th.Call()
+	if len(ran) != 0 {
+		t.Fatal("stopped throttler ran")
+	}
+}
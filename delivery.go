@@ -0,0 +1,96 @@
+package abtime
+
+import "time"
+
+// A DeliveryPolicy decides how a ManualTime delivers what its
+// registrations fire: values on channels and the ends of sleeps. See
+// SetDeliveryPolicy.
+type DeliveryPolicy struct {
+	synchronous bool
+	buffer      int
+}
+
+var (
+	// Async delivers each firing from its own goroutine, so that Trigger
+	// returns at once, possibly before anything has been received. This
+	// is the default.
+	Async = DeliveryPolicy{}
+
+	// Synchronous delivers just as Async does, but then blocks Trigger
+	// and TriggerN until the deliveries have been consumed, exactly as
+	// TriggerAndWait does. As with TriggerAndWait, a Trigger nothing
+	// will consume blocks forever.
+	Synchronous = DeliveryPolicy{synchronous: true}
+)
+
+// Buffered returns a policy giving the channels of Afters, timers, and
+// tickers, and the internal channels of sleeps, buffers of the given size,
+// at least 1. A firing is then put straight into the buffer by Trigger,
+// without a goroutine, and only falls back to Async delivery while the
+// buffer is full; a ticker's later ticks then queue behind the ones
+// being sent, so they still arrive in order.
+//
+// As nothing can tell when a buffered value is received, it is counted as
+// consumed as soon as it is buffered; see Consumed. Timers and tickers
+// under Go123Timers stay unbuffered, since their Stop and Reset must be
+// able to discard an undelivered value.
+func Buffered(n int) DeliveryPolicy {
+	if n < 1 {
+		n = 1
+	}
+	return DeliveryPolicy{buffer: n}
+}
+
+// SetDeliveryPolicy sets the DeliveryPolicy. Buffer sizes apply to
+// registrations made after the call.
+func (mt *ManualTime) SetDeliveryPolicy(policy DeliveryPolicy) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.delivery = policy
+}
+
+// timeChan makes a channel for delivering times, sized by the delivery
+// policy unless the channel must be unbuffered. The lock must be held.
+func (mt *ManualTime) timeChan(unbuffered bool) chan time.Time {
+	if unbuffered {
+		return make(chan time.Time)
+	}
+	return make(chan time.Time, mt.delivery.buffer)
+}
+
+// signalChan makes the channel a sleep waits on, sized by the delivery
+// policy. The lock must not be held.
+func (mt *ManualTime) signalChan() chan struct{} {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.delivery.buffer > 0 {
+		return make(chan struct{}, 1)
+	}
+	return make(chan struct{})
+}
+
+// buffer puts the value into the channel's buffer, if it has one with
+// room, returning whether it did. The lock must be held.
+func (mt *ManualTime) buffer(id ID, ch chan time.Time, v time.Time) bool {
+	if cap(ch) == 0 {
+		return false
+	}
+	select {
+	case ch <- v:
+		mt.buffered(id)
+		return true
+	default:
+		return false
+	}
+}
+
+// buffered records a delivery into a buffer, counting it as consumed at
+// once. The lock must be held.
+func (mt *ManualTime) buffered(id ID) {
+	di := mt.deliveryInfo(id)
+	di.delivered++
+	di.consumed++
+	mt.consumed.Broadcast()
+}
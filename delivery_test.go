@@ -0,0 +1,104 @@
+package abtime
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSynchronousDelivery(t *testing.T) {
+	mt := NewManual()
+	mt.SetDeliveryPolicy(Synchronous)
+
+	ch := mt.After(time.Second, afterID)
+	received := make(chan time.Time, 1)
+	go func() { received <- <-ch }()
+
+	// Trigger doesn't return until the value is received
+	mt.Trigger(afterID)
+	if mt.Consumed(afterID) != 1 {
+		t.Fatal("Trigger returned before consumption")
+	}
+	<-received
+
+	ticker := mt.NewTicker(time.Second, tickID)
+	go func() {
+		for range ticker.Channel() {
+		}
+	}()
+	mt.TriggerN(tickID, 3)
+	if mt.Consumed(tickID) != 3 {
+		t.Fatal("TriggerN returned before consumption")
+	}
+	ticker.Stop()
+}
+
+func TestBufferedDelivery(t *testing.T) {
+	mt := NewManual()
+	mt.SetDeliveryPolicy(Buffered(2))
+
+	after := mt.After(time.Second, afterID)
+	timer := mt.NewTimer(time.Second, timerID)
+	ticker := mt.NewTicker(time.Second, tickID)
+	mt.Trigger(afterID, timerID, tickID, tickID)
+
+	// the firings went straight into their buffers
+	if len(after) != 1 || len(timer.Channel()) != 1 || len(ticker.Channel()) != 2 {
+		t.Fatal("firings not buffered")
+	}
+	if mt.Consumed(afterID) != 1 || mt.Consumed(timerID) != 1 || mt.Consumed(tickID) != 2 {
+		t.Fatal("buffered deliveries not counted as consumed")
+	}
+	<-after
+	<-timer.Channel()
+	<-ticker.Channel()
+	<-ticker.Channel()
+	ticker.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		mt.Sleep(time.Second, sleepID)
+		close(done)
+	}()
+	for len(mt.PendingIDs()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	mt.Trigger(sleepID)
+	<-done
+	if mt.Consumed(sleepID) != 1 {
+		t.Fatal("buffered sleep not counted")
+	}
+
+	// a full buffer falls back to asynchronous delivery
+	after = mt.After(time.Second, afterID)
+	ticker = mt.NewTicker(time.Second, tickID)
+	mt.TriggerN(tickID, 3)
+	<-ticker.Channel()
+	<-ticker.Channel()
+	<-ticker.Channel()
+	ticker.Stop()
+	mt.Trigger(afterID)
+	<-after
+
+	// Go123Timers stay unbuffered
+	mt.SetTimerSemantics(Go123Timers)
+	if cap(mt.NewTimer(time.Second, timerID).Channel()) != 0 {
+		t.Fatal("Go123Timers timer buffered")
+	}
+}
+
+func TestBufferedTickerGoroutines(t *testing.T) {
+	mt := NewManual()
+	mt.SetDeliveryPolicy(Buffered(1000))
+	ticker := mt.NewTicker(time.Second, tickID)
+
+	before := runtime.NumGoroutine()
+	mt.TriggerN(tickID, 1000)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("buffered ticks started goroutines: %d before, %d after", before, after)
+	}
+	if len(ticker.Channel()) != 1000 {
+		t.Fatal("ticks not buffered")
+	}
+	ticker.Stop()
+}
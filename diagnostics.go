@@ -0,0 +1,127 @@
+package abtime
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDurationMismatch is the error ManualTime's diagnostics report when
+// an ID is registered with a duration wildly different from the one it
+// was first registered with. See SetDiagnostics.
+var ErrDurationMismatch = errors.New("id registered with differing durations")
+
+// diagnostics holds the state for SetDiagnostics.
+type diagnostics struct {
+	maxRatio  float64
+	onFinding func(error)
+
+	// the first registration seen for each id
+	first map[ID]firstRegistration
+
+	// findings already reported, so each is reported only once
+	reported map[string]bool
+	findings []error
+}
+
+type firstRegistration struct {
+	d    time.Duration
+	site string
+}
+
+// SetDiagnostics turns on checking for the likely copy and paste bugs of
+// an ID being reused where it shouldn't be: registering an ID from a
+// different call site than it was first registered from, or with a
+// duration more than maxRatio times longer or shorter than the one it was
+// first registered with. For instance, with a maxRatio of 10, an ID first
+// used for a one second timer is flagged when used for a one minute
+// Sleep. A maxRatio of 0 turns the checks off again.
+//
+// Unlike NewManualStrict, which only objects to IDs shared between call
+// sites while both registrations are live, this compares every
+// registration with the first one ever made under the ID, and doesn't
+// interfere with the code under test. Each finding is an error wrapping
+// ErrDuplicateRegistration or ErrDurationMismatch, reported once; they
+// are all available from Diagnostics, and also passed to onFinding if it
+// isn't nil. As with NewManualStrict, onFinding is called with the
+// ManualTime locked, so it must not call back into it; t.Error is
+// typical.
+func (mt *ManualTime) SetDiagnostics(maxRatio float64, onFinding func(error)) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if maxRatio <= 0 {
+		mt.diagnostics = nil
+		return
+	}
+	if maxRatio < 1 {
+		maxRatio = 1
+	}
+	mt.diagnostics = &diagnostics{
+		maxRatio:  maxRatio,
+		onFinding: onFinding,
+		first:     map[ID]firstRegistration{},
+		reported:  map[string]bool{},
+	}
+}
+
+// Diagnostics returns the findings reported since SetDiagnostics was
+// called, in the order they were found.
+func (mt *ManualTime) Diagnostics() []error {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.diagnostics == nil {
+		return nil
+	}
+	return append([]error(nil), mt.diagnostics.findings...)
+}
+
+// diagnose checks a new registration against the first one made under
+// its id. The lock must be held.
+func (mt *ManualTime) diagnose(id ID, trig trigger) {
+	dg := mt.diagnostics
+	site := trig.reg().callSite()
+	d := pendingOf(trig).Duration
+	first, seen := dg.first[id]
+	if !seen {
+		dg.first[id] = firstRegistration{d, site}
+		return
+	}
+	if first.d <= 0 && d > 0 {
+		// the first had no duration to compare with, as with WithCancel
+		first.d = d
+		dg.first[id] = first
+	}
+
+	if site != first.site {
+		mt.diagnosed(fmt.Sprintf("site %v %s", id, site), fmt.Errorf(
+			"abtime: id %s registered at %s, but first registered at %s: %w",
+			mt.idString(id), site, first.site, ErrDuplicateRegistration))
+	}
+	if d > 0 && first.d > 0 {
+		ratio := float64(d) / float64(first.d)
+		if ratio < 1 {
+			ratio = 1 / ratio
+		}
+		if ratio > dg.maxRatio {
+			mt.diagnosed(fmt.Sprintf("duration %v %v", id, d), fmt.Errorf(
+				"abtime: id %s registered for %v at %s, but first registered for %v at %s: %w",
+				mt.idString(id), d, site, first.d, first.site, ErrDurationMismatch))
+		}
+	}
+}
+
+// diagnosed reports a finding, unless the same one was already reported.
+// The lock must be held.
+func (mt *ManualTime) diagnosed(key string, err error) {
+	dg := mt.diagnostics
+	if dg.reported[key] {
+		return
+	}
+	dg.reported[key] = true
+	dg.findings = append(dg.findings, err)
+	if dg.onFinding != nil {
+		dg.onFinding(err)
+	}
+}
@@ -0,0 +1,55 @@
+package abtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagnostics(t *testing.T) {
+	mt := NewManual()
+	if mt.Diagnostics() != nil {
+		t.Fatal("diagnostics without SetDiagnostics")
+	}
+	reported := []error{}
+	mt.SetDiagnostics(10, func(err error) { reported = append(reported, err) })
+
+	// the same call site, with similar durations, is fine
+	for i := 1; i <= 3; i++ {
+		mt.NewTimer(time.Duration(i)*time.Second, timerID)
+	}
+	if len(mt.Diagnostics()) != 0 {
+		t.Fatalf("unexpected findings: %v", mt.Diagnostics())
+	}
+
+	// a different call site, with a wildly different duration, is not,
+	// but each is reported only once
+	for i := 0; i < 2; i++ {
+		mt.After(time.Hour, timerID)
+	}
+	findings := mt.Diagnostics()
+	if len(findings) != 2 || len(reported) != 2 ||
+		!errors.Is(findings[0], ErrDuplicateRegistration) ||
+		!errors.Is(findings[1], ErrDurationMismatch) ||
+		!strings.Contains(findings[1].Error(), "registered for 1h0m0s at ") ||
+		!strings.Contains(findings[1].Error(), "diagnostics_test.go:") {
+		t.Fatalf("unexpected findings: %v", findings)
+	}
+
+	// WithCancel contexts have no duration to compare
+	for i := 0; i < 2; i++ {
+		_, cancel := mt.WithCancel(context.Background(), contextID)
+		defer cancel()
+	}
+	if len(mt.Diagnostics()) != 2 {
+		t.Fatalf("unexpected findings: %v", mt.Diagnostics())
+	}
+
+	mt.SetDiagnostics(0, nil)
+	mt.After(time.Second, sleepID)
+	if mt.Diagnostics() != nil {
+		t.Fatal("diagnostics not turned off")
+	}
+}
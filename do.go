@@ -0,0 +1,41 @@
+package abtime
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout is the error Do returns when its timeout fires before the
+// function returns.
+var ErrTimeout = errors.New("abtime: timed out")
+
+// Do runs fn with a context that times out after d on the given clock,
+// returning fn's error if it finishes in time, ErrTimeout if the timeout
+// fires first, or the parent context's error if that ends first.
+//
+// The timeout is a WithTimeoutCause under the given id, with ErrTimeout
+// as the cause, so on a ManualTime it fires when the id is Triggered, and
+// fn can tell why its context ended with context.Cause.
+//
+// Do returns as soon as the timeout fires; it doesn't wait for fn, which
+// is left to notice its context is done and clean up after itself.
+func Do(ctx context.Context, at AbstractTime, d time.Duration, id ID, fn func(context.Context) error) error {
+	ctx, cancel := at.WithTimeoutCause(ctx, d, ErrTimeout, id)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- fn(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		if errors.Is(context.Cause(ctx), ErrTimeout) {
+			return ErrTimeout
+		}
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,63 @@
+package abtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	mt := NewManual()
+	fnErr := errors.New("failed")
+
+	// finishing in time returns fn's result
+	err := Do(context.Background(), mt, time.Second, contextID, func(context.Context) error {
+		return fnErr
+	})
+	if err != fnErr {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// triggering the id times it out, and fn can see why; fn is held
+	// until the end so that it's the timeout Do sees
+	release := make(chan struct{})
+	defer close(release)
+	cause := make(chan error, 1)
+	result := make(chan error)
+	go func() {
+		result <- Do(context.Background(), mt, time.Second, contextID, func(ctx context.Context) error {
+			<-ctx.Done()
+			cause <- context.Cause(ctx)
+			<-release
+			return nil
+		})
+	}()
+	mt.Trigger(contextID)
+	if err := <-result; err != ErrTimeout {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := <-cause; err != ErrTimeout {
+		t.Fatalf("unexpected cause %v", err)
+	}
+
+	// the parent ending first isn't a timeout
+	parent, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Do(parent, mt, time.Second, childContextID, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// and in real time
+	err = Do(context.Background(), NewRealTime(), time.Millisecond, contextID, func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if err != ErrTimeout {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
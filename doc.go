@@ -20,16 +20,25 @@ This module declares an interface for time functions AbstractTime,
 provides an implementation that simply backs to the "real" time functions
 "RealTime", and provides an implementation that allows you to fully control
 the time "ManualTime", including setting "now", and requiring you to
-manually trigger all time-based events, such as alerts and alarms.
+manually trigger all time-based events, such as alerts and alarms. In
+between the two sits "ScaledTime", which is real time running at a
+multiple of real speed.
 
 Since there is no way to distinguish between different calls to the
 standard time functions, each of the methods in the AbstractTime interface
-adds an "id". The RealTime implementation simply ignores them. The
+adds an "id", which may be any comparable value; see ID. The RealTime
+implementation simply ignores them. The
 ManualTime implementations uses these to trigger specific time events.
 Be sure to see the example for usage of the ManualTime implementation.
 
-Avoid re-using IDs on the Tick functions; it becomes confusing which
-.Trigger is affecting which Tick.
+Registrations made under the same ID queue up in the order they were
+made, and each Trigger goes to the oldest one that is still live, so code
+that creates a fresh timer under the same ID on every pass through a loop
+can be driven one Trigger per pass. Pools of goroutines sharing one ID
+can be fired one at a time with TriggerOne, or all at once with
+TriggerAll. Since a Tick stays at the head of its
+queue until it is stopped, avoid sharing IDs between Ticks and other
+events; it becomes confusing which .Trigger is affecting which Tick.
 
 Be sure to see the Example below.
 
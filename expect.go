@@ -0,0 +1,147 @@
+package abtime
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// An Expectation is one step of a Script: an event of the given kind for
+// the given id.
+type Expectation struct {
+	Kind EventKind
+	ID   ID
+}
+
+// Register expects a registration under the id.
+func Register(id ID) Expectation {
+	return Expectation{Registered, id}
+}
+
+// Trigger expects the id to be Triggered.
+func Trigger(id ID) Expectation {
+	return Expectation{Triggered, id}
+}
+
+// Fire expects a registration under the id to fire.
+func Fire(id ID) Expectation {
+	return Expectation{Fired, id}
+}
+
+// Stop expects a registration under the id to be stopped.
+func Stop(id ID) Expectation {
+	return Expectation{Stopped, id}
+}
+
+// A Script is the sequence of events a test expects to happen on a
+// ManualTime, as returned by Expect.
+type Script struct {
+	mt       *ManualTime
+	start    int
+	expected []Expectation
+}
+
+// Expect starts a Script of the events expected to happen on the
+// ManualTime from now on, for checking with Verify once they should have:
+//
+//	script := mt.Expect(
+//	    abtime.Register(retryID),
+//	    abtime.Trigger(retryID),
+//	    abtime.Register(retryID),
+//	)
+//	// ... drive the code under test ...
+//	script.Verify(t)
+//
+// This makes the timing behavior of the code under test an assertable
+// contract, rather than something tests only probe indirectly.
+func (mt *ManualTime) Expect(expected ...Expectation) *Script {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return &Script{mt, mt.events, expected}
+}
+
+// Verify fails the test unless the events since Expect match the script
+// exactly, reporting a diff of expected against actual events if not.
+//
+// Only the kinds of event the script mentions are compared: a script of
+// nothing but Registers isn't bothered by Triggers and firings, but one
+// that mentions any Trigger must account for all of them. Advances are
+// never compared.
+func (s *Script) Verify(t testing.TB) {
+	t.Helper()
+
+	kinds := map[EventKind]bool{}
+	for _, e := range s.expected {
+		kinds[e.Kind] = true
+	}
+
+	s.mt.Lock()
+	defer s.mt.Unlock()
+
+	expected := make([]string, len(s.expected))
+	for i, e := range s.expected {
+		expected[i] = fmt.Sprintf("%v id %s", e.Kind, s.mt.idString(e.ID))
+	}
+	// the history holds the most recent events, so the script's start
+	// is that many events back from the end
+	since := len(s.mt.history) - (s.mt.events - s.start)
+	if since < 0 {
+		t.Errorf("ManualTime events since the script started were discarded by ClearHistory or SetHistoryLimit")
+		return
+	}
+	actual := []string{}
+	for _, event := range s.mt.history[since:] {
+		if kinds[event.Kind] {
+			actual = append(actual, fmt.Sprintf("%v id %s", event.Kind, s.mt.idString(event.ID)))
+		}
+	}
+
+	if diff, differ := diffLines(expected, actual); differ {
+		t.Errorf("ManualTime events didn't match the script (- expected, + actual):\n%s", diff)
+	}
+}
+
+// diffLines diffs two lists of lines along their longest common
+// subsequence, returning the diff and whether they differed at all.
+func diffLines(a, b []string) (string, bool) {
+	// common[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	lines := []string{}
+	differ := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "    "+a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "  - "+a[i])
+			differ = true
+			i++
+		default:
+			lines = append(lines, "  + "+b[j])
+			differ = true
+			j++
+		}
+	}
+	return strings.Join(lines, "\n"), differ
+}
@@ -0,0 +1,37 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpect(t *testing.T) {
+	mt := NewManual()
+	mt.DeclareID(afterID, "retry")
+
+	script := mt.Expect(
+		Register(afterID),
+		Trigger(afterID),
+		Register(afterID),
+	)
+	mt.After(time.Second, afterID)
+	mt.Trigger(afterID)
+	mt.After(time.Second, afterID)
+	mt.Advance(time.Second)
+	script.Verify(t)
+
+	// skipping an expected registration and making an unexpected one
+	// are both shown
+	script = mt.Expect(Register(timerID), Fire(afterID))
+	mt.NewTicker(time.Second, tickID)
+	mt.Trigger(afterID)
+	rt := &recordingT{}
+	script.Verify(rt)
+	expected := "ManualTime events didn't match the script (- expected, + actual):\n" +
+		"  - Registered id 5\n" +
+		"  + Registered id 2\n" +
+		"    Fired id 0 (retry)"
+	if len(rt.errors) != 1 || rt.errors[0] != expected {
+		t.Fatalf("unexpected report: %q", rt.errors)
+	}
+}
@@ -0,0 +1,93 @@
+package abtime
+
+import "time"
+
+// A Fault makes a ManualTime misbehave for an ID, as a flaky clock might,
+// so tests can exercise the code that defends against timer anomalies.
+// See SetFault.
+//
+// The zero Fault is no fault at all.
+type Fault struct {
+	shift time.Duration
+	drop  bool
+}
+
+// FireEarly makes registrations behave as if armed for d less than they
+// were, down to zero: they are scheduled, and deliver their time, that
+// much early. This affects the times ManualTime reports and delivers, and
+// when WhenDue auto-triggering and RunUntilIdle consider them due; as
+// always, nothing fires without being triggered.
+func FireEarly(d time.Duration) Fault {
+	return Fault{shift: -d}
+}
+
+// FireLate makes registrations behave as if armed for d more than they
+// were, as FireEarly does in the other direction.
+func FireLate(d time.Duration) Fault {
+	return Fault{shift: d}
+}
+
+// Drop makes registrations swallow their firings: a Trigger is consumed,
+// and recorded in the History, but nothing is delivered. Sleeps never
+// wake, timers never send, AfterFuncs never run, and contexts are never
+// canceled by a Trigger. Tickers drop the tick but stay registered, their
+// schedule moving on as if it had been delivered. Since nothing is
+// delivered, TriggerAndWait on a dropping ID never returns.
+func Drop() Fault {
+	return Fault{drop: true}
+}
+
+// SetFault injects the fault into the registrations made under the id
+// from now on, including the durations given to Reset. Setting the zero
+// Fault removes it.
+func (mt *ManualTime) SetFault(id ID, fault Fault) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if fault == (Fault{}) {
+		delete(mt.faults, id)
+		return
+	}
+	if mt.faults == nil {
+		mt.faults = map[ID]Fault{}
+	}
+	mt.faults[id] = fault
+}
+
+// faulted returns d as shifted by any fault on the id.
+func (mt *ManualTime) faulted(id ID, d time.Duration) time.Duration {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.faultDuration(id, d)
+}
+
+// faultDuration returns d as shifted by any fault on the id. The lock
+// must be held.
+func (mt *ManualTime) faultDuration(id ID, d time.Duration) time.Duration {
+	fault, exists := mt.faults[id]
+	if !exists || fault.shift == 0 {
+		return d
+	}
+	d += fault.shift
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// dropped reports whether the registration's firing should be swallowed,
+// and if so, whether the registration should be removed. The lock must be
+// held.
+func (mt *ManualTime) dropped(trig trigger) (drop bool, remove bool) {
+	if !mt.faults[trig.reg().id].drop {
+		return false, false
+	}
+	if tt, isTicker := trig.(*tickTrigger); isTicker {
+		tt.Lock()
+		tt.now = tt.now.Add(tt.d)
+		tt.Unlock()
+		return true, false
+	}
+	return true, true
+}
@@ -0,0 +1,69 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+	mt.SetFault(timerID, FireEarly(3*time.Second))
+	mt.SetFault(afterID, FireLate(time.Minute))
+
+	timer := mt.NewTimer(10*time.Second, timerID)
+	mt.Trigger(timerID)
+	if fired := <-timer.Channel(); fired != start.Add(7*time.Second) {
+		t.Fatalf("early timer delivered %v", fired)
+	}
+	timer.Reset(time.Second)
+	if at := timer.(Scheduled).ScheduledAt(); at != start {
+		t.Fatalf("early Reset scheduled for %v", at)
+	}
+	timer.Stop()
+
+	mt.Trigger(afterID)
+	if fired := <-mt.After(time.Second, afterID); fired != start.Add(61*time.Second) {
+		t.Fatalf("late After delivered %v", fired)
+	}
+
+	ctx, cancel := mt.WithTimeout(context.Background(), time.Second, afterID)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); deadline != start.Add(61*time.Second) {
+		t.Fatalf("late context deadline %v", deadline)
+	}
+
+	mt.SetFault(timerID, Fault{})
+	if at := mt.NewTimer(time.Second, timerID).(Scheduled).ScheduledAt(); at != start.Add(time.Second) {
+		t.Fatal("fault not removed")
+	}
+}
+
+func TestDropFault(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+	mt.SetFault(timerID, Drop())
+	mt.SetFault(tickID, Drop())
+
+	timer := mt.NewTimer(time.Second, timerID)
+	mt.Trigger(timerID)
+	select {
+	case <-timer.Channel():
+		t.Fatal("dropped timer fired")
+	case <-time.After(time.Millisecond):
+	}
+	if len(mt.Pending(timerID)) != 0 || mt.PendingTriggers(timerID) != 0 {
+		t.Fatal("dropped firing did not consume the Trigger")
+	}
+	mt.AssertFiredInOrder(t, timerID)
+
+	ticker := mt.NewTicker(time.Second, tickID)
+	defer ticker.Stop()
+	mt.Trigger(tickID)
+	mt.SetFault(tickID, Fault{})
+	mt.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != start.Add(2*time.Second) {
+		t.Fatalf("ticker did not move on past the dropped tick: %v", tick)
+	}
+}
@@ -0,0 +1,141 @@
+package abtime
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// leakGrace is how long the cleanup registered by NewManualForTest waits
+// for outstanding deliveries to be consumed before calling them leaks.
+// The acknowledgement of a delivery can trail the receive slightly.
+const leakGrace = 100 * time.Millisecond
+
+// watchdogOutput is where Watchdog writes its report as it goes off.
+var watchdogOutput io.Writer = os.Stderr
+
+// NewManualForTest returns a new ManualTime for use by the given test,
+// registering a cleanup with the test that Closes it, releasing any
+// goroutines still blocked in Sleep, or sending on the channels of
+// Afters, timers, and tickers, and unregistering everything.
+//
+// If failOnLeaks is true, the cleanup also fails the test if any delivery
+// was never consumed, which means a goroutine was left blocked trying to
+// send a value to code that never received it.
+func NewManualForTest(t testing.TB, failOnLeaks bool) *ManualTime {
+	mt := NewManual()
+	t.Cleanup(func() {
+		mt.cleanup(t, failOnLeaks)
+	})
+	return mt
+}
+
+func (mt *ManualTime) cleanup(t testing.TB, failOnLeaks bool) {
+	t.Helper()
+
+	// the test is over, so nothing it waited on could still be hung
+	mt.Lock()
+	watchdogs := mt.watchdogs
+	mt.watchdogs = nil
+	mt.Unlock()
+	for _, stop := range watchdogs {
+		stop()
+	}
+
+	if failOnLeaks {
+		mt.Lock()
+		leaks := mt.unconsumed(leakGrace)
+		mt.Unlock()
+		if len(leaks) > 0 {
+			t.Errorf("ManualTime deliveries were never consumed:\n%s",
+				strings.Join(leaks, "\n"))
+		}
+	}
+
+	mt.Close()
+}
+
+// unconsumed describes each id with unconsumed deliveries, waiting up to
+// the given grace period for them to be consumed. The lock must be held.
+func (mt *ManualTime) unconsumed(grace time.Duration) []string {
+	giveUp := time.Now().Add(grace)
+	for {
+		leaks := []string{}
+		for id, di := range mt.deliveries {
+			if di.consumed < di.delivered {
+				leaks = append(leaks, fmt.Sprintf("  id %s: %d of %d deliveries unconsumed",
+					mt.idString(id), di.delivered-di.consumed, di.delivered))
+			}
+		}
+		if len(leaks) == 0 || time.Now().After(giveUp) {
+			sort.Strings(leaks)
+			return leaks
+		}
+
+		mt.Unlock()
+		time.Sleep(time.Millisecond)
+		mt.Lock()
+	}
+}
+
+// Watchdog reports on the ManualTime if the test hasn't finished within
+// the given real time, which is usually because it's waiting on a Trigger
+// that never comes, or code that never registers for one. The report
+// includes the clock's registrations and its whole History, and fails the
+// test.
+//
+// The report is also written straight to standard error, because a hung
+// test's output is otherwise never shown, leaving nothing but a stuck go
+// test to go on.
+//
+// The watchdog is stopped when the test finishes, and also by the cleanup
+// of NewManualForTest, so that it can't go off while that waits on
+// unconsumed deliveries.
+func (mt *ManualTime) Watchdog(t testing.TB, realTimeout time.Duration) {
+	t.Helper()
+
+	var mu sync.Mutex
+	finished := false
+	watchdog := time.AfterFunc(realTimeout, func() {
+		// holding mu throughout keeps the test from finishing part way
+		// through a report, which it would then never see
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return
+		}
+
+		report := fmt.Sprintf("abtime: %s still running after %v\n%s",
+			t.Name(), realTimeout, mt.dump())
+		fmt.Fprintln(watchdogOutput, report)
+		t.Errorf("%s", report)
+	})
+	stop := func() {
+		watchdog.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		finished = true
+	}
+	t.Cleanup(stop)
+
+	mt.Lock()
+	defer mt.Unlock()
+	mt.watchdogs = append(mt.watchdogs, stop)
+}
+
+// dump describes everything about the ManualTime that might explain why
+// a test is stuck on it.
+func (mt *ManualTime) dump() string {
+	lines := []string{mt.String(), "Registrations:"}
+	for _, r := range mt.Registrations() {
+		lines = append(lines, "  "+r.String())
+	}
+	lines = append(lines, "History:", mt.formatEvents(mt.History()))
+	return strings.Join(lines, "\n")
+}
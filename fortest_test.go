@@ -0,0 +1,133 @@
+package abtime
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManualForTest(t *testing.T) {
+	var mt *ManualTime
+	woke := make(chan struct{})
+	t.Run("subtest", func(t *testing.T) {
+		mt = NewManualForTest(t, true)
+		go func() {
+			mt.Sleep(time.Hour, sleepID)
+			close(woke)
+		}()
+		ch := mt.After(time.Second, afterID)
+		go mt.Trigger(afterID)
+		<-ch
+		for len(mt.PendingIDs()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	// the cleanup should have released the sleeper and cleared everything
+	<-woke
+	if len(mt.PendingIDs()) != 0 {
+		t.Fatal("cleanup did not unregister everything")
+	}
+	if mt.SleepErr(time.Hour, sleepID) != ErrClosed {
+		t.Fatal("cleanup did not close the ManualTime")
+	}
+
+	// a goroutine left sending on an unreceived ticker is released
+	var ticker Ticker
+	t.Run("ticker", func(t *testing.T) {
+		mt = NewManualForTest(t, false)
+		ticker = mt.NewTicker(time.Second, tickID)
+		mt.Trigger(tickID)
+	})
+	for range ticker.Channel() {
+	}
+
+	rt := &recordingT{}
+	mt = NewManual()
+	mt.After(time.Second, afterID)
+	mt.Trigger(afterID)
+	mt.cleanup(rt, true)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "id 0: 1 of 1 deliveries unconsumed") {
+		t.Fatalf("unexpected leak report: %v", rt.errors)
+	}
+}
+
+// watchdogT is a testing.TB that records the errors reported from the
+// Watchdog's goroutine, and runs its cleanups when told to.
+type watchdogT struct {
+	testing.TB
+	reported chan string
+	cleanups []func()
+}
+
+func (wt *watchdogT) Helper()      {}
+func (wt *watchdogT) Name() string { return "TestStuck" }
+
+func (wt *watchdogT) Errorf(format string, args ...interface{}) {
+	wt.reported <- fmt.Sprintf(format, args...)
+}
+
+func (wt *watchdogT) Cleanup(f func()) {
+	wt.cleanups = append(wt.cleanups, f)
+}
+
+func (wt *watchdogT) finish() {
+	for _, f := range wt.cleanups {
+		f()
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	var written bytes.Buffer
+	watchdogOutput = &written
+	defer func() { watchdogOutput = os.Stderr }()
+
+	mt := NewManual()
+	mt.DeclareID(timerID, "billing.graceperiod")
+	mt.NewTimer(time.Second, timerID)
+	mt.Trigger(afterID)
+
+	wt := &watchdogT{reported: make(chan string, 1)}
+	mt.Watchdog(wt, 10*time.Millisecond)
+	report := <-wt.reported
+	wt.finish()
+	for _, expected := range []string{
+		"TestStuck still running after 10ms",
+		"Registrations:",
+		"billing.graceperiod",
+		"fortest_test.go:",
+		"History:",
+		"Triggered id 0",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Fatalf("report lacks %q:\n%s", expected, report)
+		}
+	}
+	if !strings.Contains(written.String(), report) {
+		t.Fatal("report not written out")
+	}
+
+	// a test finishing in time hears nothing, and nothing is written
+	written.Reset()
+	wt = &watchdogT{reported: make(chan string, 1)}
+	mt.Watchdog(wt, 10*time.Millisecond)
+	wt.finish()
+	time.Sleep(20 * time.Millisecond)
+	if len(wt.reported) != 0 || written.Len() != 0 {
+		t.Fatal("watchdog went off after the test finished")
+	}
+
+	// NewManualForTest's cleanup stops it too, before waiting on leaks
+	rt := &recordingT{}
+	wt = &watchdogT{reported: make(chan string, 1)}
+	mt.Watchdog(wt, leakGrace/2)
+	mt.Trigger(timerID)
+	mt.cleanup(rt, true)
+	time.Sleep(leakGrace)
+	if len(wt.reported) != 0 || written.Len() != 0 {
+		t.Fatal("watchdog went off during the cleanup")
+	}
+}
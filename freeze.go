@@ -0,0 +1,75 @@
+package abtime
+
+import "time"
+
+// DeterministicEpoch is the Now of a ManualTime made by
+// NewManualDeterministic: midnight UTC at the start of 2000.
+var DeterministicEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// NewManualDeterministic returns a new ManualTime whose Now starts at
+// DeterministicEpoch, rather than at the real time as NewManual's does.
+// The epoch is in UTC, carries no monotonic reading, and is a whole
+// second, so timestamps serialized by the code under test come out the
+// same on every run, for snapshot and golden-file tests. Combine it with
+// SetPrecision to keep them whole, and Freeze to keep them from moving
+// unless the test moves them.
+func NewManualDeterministic() *ManualTime {
+	return NewManualAtTime(DeterministicEpoch)
+}
+
+// SetPrecision truncates the times Now returns to a multiple of the given
+// duration, as time.Time.Truncate does, which also strips any monotonic
+// clock reading. The ManualTime still keeps its time to the nanosecond
+// internally, so Advancing by less than the precision is not lost; it
+// just doesn't show until it adds up. A precision of 0, the default,
+// returns Now as it is.
+//
+// Use time.Nanosecond to strip the monotonic reading from the times of
+// a ManualTime made by NewManual without otherwise changing them.
+func (mt *ManualTime) SetPrecision(precision time.Duration) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.precision = precision
+	mt.publishNow()
+}
+
+// reading returns now as Now reports it. The lock must be held.
+func (mt *ManualTime) reading() time.Time {
+	if mt.precision > 0 {
+		return mt.now.Truncate(mt.precision)
+	}
+	return mt.now
+}
+
+// Freeze stops Now from changing implicitly: SetAutoAdvance,
+// SetDeliverNow, and SetAdvanceOnFire no longer move it as things fire,
+// and queued Nows are left queued rather than being consumed by Now. Now
+// then only ever moves when the test explicitly moves it, with Advance,
+// AdvanceTo, SetWallClock, or Restore.
+func (mt *ManualTime) Freeze() {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.frozen = true
+	mt.publishNow()
+}
+
+// Unfreeze undoes Freeze. Any queued Nows are consumed from the next call
+// to Now on, and firings move Now again as configured.
+func (mt *ManualTime) Unfreeze() {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.frozen = false
+	mt.publishNow()
+}
+
+// moveImplicitly moves now to t on behalf of something other than the
+// test asking for it, unless the ManualTime is frozen. The lock must be
+// held.
+func (mt *ManualTime) moveImplicitly(t time.Time) {
+	if !mt.frozen {
+		mt.setNow(t)
+	}
+}
@@ -0,0 +1,57 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeterministic(t *testing.T) {
+	mt := NewManualDeterministic()
+	if got := mt.Now().Format(time.RFC3339Nano); got != "2000-01-01T00:00:00Z" {
+		t.Fatalf("unexpected epoch %s", got)
+	}
+
+	mt.SetPrecision(time.Second)
+	mt.Advance(600 * time.Millisecond)
+	if !mt.Now().Equal(DeterministicEpoch) {
+		t.Fatalf("now not truncated: %v", mt.Now())
+	}
+	mt.Advance(600 * time.Millisecond)
+	if !mt.Now().Equal(DeterministicEpoch.Add(time.Second)) {
+		t.Fatalf("sub-precision advances lost: %v", mt.Now())
+	}
+
+	// the nanosecond precision just strips the monotonic reading
+	seeded := NewManual()
+	seeded.SetPrecision(time.Nanosecond)
+	if now := seeded.Now(); now != now.Round(0) {
+		t.Fatal("monotonic reading not stripped")
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	mt := NewManualDeterministic()
+	mt.SetAutoAdvance(true)
+	mt.Freeze()
+
+	<-mt.After(time.Minute, afterID)
+	mt.QueueNows(DeterministicEpoch.Add(time.Hour))
+	if !mt.Now().Equal(DeterministicEpoch) {
+		t.Fatalf("frozen now moved to %v", mt.Now())
+	}
+
+	// explicit moves still work
+	mt.Advance(time.Second)
+	if !mt.Now().Equal(DeterministicEpoch.Add(time.Second)) {
+		t.Fatalf("frozen now didn't advance: %v", mt.Now())
+	}
+
+	mt.Unfreeze()
+	if !mt.Now().Equal(DeterministicEpoch.Add(time.Hour)) {
+		t.Fatalf("queued now not consumed: %v", mt.Now())
+	}
+	<-mt.After(time.Minute, afterID)
+	if !mt.Now().Equal(DeterministicEpoch.Add(time.Hour + time.Minute)) {
+		t.Fatalf("auto advance didn't resume: %v", mt.Now())
+	}
+}
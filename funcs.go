@@ -0,0 +1,54 @@
+package abtime
+
+import (
+	"time"
+)
+
+// StdFuncs holds closures with the signatures of the time package's
+// functions, for plugging an AbstractTime into third-party libraries
+// whose injection points are shaped like the standard library, such as a
+// `NowFunc func() time.Time` field.
+//
+// The time package's NewTimer and NewTicker return concrete types that
+// can't be produced by a ManualTime, so those return abtime's Timer and
+// Ticker instead; see StdTimer and StdTicker for going the other way
+// where it is possible.
+type StdFuncs struct {
+	Now       func() time.Time
+	Since     func(time.Time) time.Duration
+	Until     func(time.Time) time.Duration
+	After     func(time.Duration) <-chan time.Time
+	Sleep     func(time.Duration)
+	Tick      func(time.Duration) <-chan time.Time
+	AfterFunc func(time.Duration, func()) Timer
+	NewTimer  func(time.Duration) Timer
+	NewTicker func(time.Duration) Ticker
+}
+
+// Funcs returns StdFuncs backed by the given AbstractTime, with
+// everything they create registered under the given id.
+func Funcs(at AbstractTime, id ID) StdFuncs {
+	return StdFuncs{
+		Now:   at.Now,
+		Since: at.Since,
+		Until: at.Until,
+		After: func(d time.Duration) <-chan time.Time {
+			return at.After(d, id)
+		},
+		Sleep: func(d time.Duration) {
+			at.Sleep(d, id)
+		},
+		Tick: func(d time.Duration) <-chan time.Time {
+			return at.Tick(d, id)
+		},
+		AfterFunc: func(d time.Duration, f func()) Timer {
+			return at.AfterFunc(d, f, id)
+		},
+		NewTimer: func(d time.Duration) Timer {
+			return at.NewTimer(d, id)
+		},
+		NewTicker: func(d time.Duration) Ticker {
+			return at.NewTicker(d, id)
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFuncs(t *testing.T) {
+	mt := NewManual()
+	funcs := Funcs(mt, "lib")
+
+	// a library taking a stdlib-shaped injection point
+	var nowFunc func() time.Time = funcs.Now
+	if !nowFunc().Equal(mt.Now()) {
+		t.Fatal("Now is not backed by the clock")
+	}
+
+	after := funcs.After(time.Second)
+	timer := funcs.NewTimer(time.Second)
+	mt.Trigger("lib", "lib")
+	<-after
+	<-timer.Channel()
+
+	mt.Advance(time.Minute)
+	if funcs.Since(nowFunc().Add(-time.Minute)) != time.Minute {
+		t.Fatal("Since is not backed by the clock")
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/thejerf/abtime
 
-go 1.14
+go 1.21
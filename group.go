@@ -0,0 +1,95 @@
+package abtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A ClockGroup owns several ManualTimes that disagree slightly, as the
+// clocks of the nodes of a distributed system do, each with its own skew
+// from a reference time and its own drift rate. Advancing the group
+// advances every member, applying its drift, so leader election, leases,
+// and timeouts can be tested against clocks that don't agree.
+//
+// The members are ordinary ManualTimes, and can still be Triggered and
+// Advanced individually; the group only ever moves them by the drifted
+// amount of its own advances, so adjustments made to a member directly
+// stick.
+type ClockGroup struct {
+	now     time.Time
+	elapsed time.Duration
+	members []*groupMember
+	byName  map[string]*groupMember
+	mu      sync.Mutex
+}
+
+type groupMember struct {
+	mt    *ManualTime
+	drift float64
+}
+
+// drifted returns how far the member has moved for the given elapsed
+// reference time.
+func (gm *groupMember) drifted(elapsed time.Duration) time.Duration {
+	return elapsed + time.Duration(float64(elapsed)*gm.drift)
+}
+
+// NewClockGroup returns an empty ClockGroup whose reference time starts
+// at the given time.
+func NewClockGroup(start time.Time) *ClockGroup {
+	return &ClockGroup{now: start, byName: map[string]*groupMember{}}
+}
+
+// Add creates a new member clock under the given name, skewed from the
+// group's current reference time by skew, and drifting by the given
+// fraction of every advance: a drift of 0.001 runs a tenth of a percent
+// fast, and -0.001 as much slow. Adding a name twice panics.
+func (g *ClockGroup) Add(name string, skew time.Duration, drift float64) *ManualTime {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, exists := g.byName[name]; exists {
+		panic(fmt.Sprintf("abtime: clock group already has a member named %q", name))
+	}
+	member := &groupMember{mt: NewManualAtTime(g.now.Add(skew)), drift: drift}
+	g.members = append(g.members, member)
+	g.byName[name] = member
+	return member.mt
+}
+
+// Member returns the member clock with the given name, or nil if there is
+// none.
+func (g *ClockGroup) Member(name string) *ManualTime {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if member, exists := g.byName[name]; exists {
+		return member.mt
+	}
+	return nil
+}
+
+// Now returns the group's reference time, which no member necessarily
+// agrees with.
+func (g *ClockGroup) Now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.now
+}
+
+// Advance advances the reference time by d, and each member by d adjusted
+// for its drift. Drift is computed from the total advanced so far, so
+// rounding doesn't accumulate over many small advances.
+func (g *ClockGroup) Advance(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	elapsed := g.elapsed + d
+	for _, member := range g.members {
+		member.mt.Advance(member.drifted(elapsed) - member.drifted(g.elapsed))
+	}
+	g.elapsed = elapsed
+	g.now = g.now.Add(d)
+}
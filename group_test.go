@@ -0,0 +1,54 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockGroup(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := NewClockGroup(start)
+	leader := group.Add("leader", 0, 0)
+	follower := group.Add("follower", time.Second, 0.01)
+	slow := group.Add("slow", -time.Second, -0.5)
+
+	if group.Member("follower") != follower || group.Member("nobody") != nil {
+		t.Fatal("members not found by name")
+	}
+	if follower.Now() != start.Add(time.Second) || slow.Now() != start.Add(-time.Second) {
+		t.Fatal("skew not applied")
+	}
+
+	group.Advance(100 * time.Second)
+	if group.Now() != start.Add(100*time.Second) || leader.Now() != start.Add(100*time.Second) {
+		t.Fatal("reference time not advanced")
+	}
+	if follower.Now() != start.Add(102*time.Second) {
+		t.Fatalf("follower drifted to %v", follower.Now())
+	}
+	if slow.Now() != start.Add(49*time.Second) {
+		t.Fatalf("slow clock drifted to %v", slow.Now())
+	}
+
+	// drift doesn't lose rounding over many small steps
+	for i := 0; i < 1000; i++ {
+		group.Advance(time.Nanosecond)
+	}
+	if slow.Now() != start.Add(49*time.Second+500*time.Nanosecond) {
+		t.Fatalf("slow clock drifted to %v", slow.Now())
+	}
+
+	// members still move individually
+	leader.Advance(time.Second)
+	group.Advance(time.Second)
+	if leader.Now() != start.Add(102*time.Second+1000*time.Nanosecond) {
+		t.Fatal("individual advance lost")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate member did not panic")
+		}
+	}()
+	group.Add("leader", 0, 0)
+}
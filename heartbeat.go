@@ -0,0 +1,95 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// A Heartbeat watches for a producer going quiet: the producer calls Beat
+// regularly, and if it ever goes longer than the Heartbeat's duration
+// without doing so, the time is sent on the Missed channel.
+//
+// The timeout is an AfterFunc under the Heartbeat's id, made afresh on
+// every Beat, so on a ManualTime triggering the id simulates the
+// producer missing its beat.
+type Heartbeat struct {
+	at AbstractTime
+	d  time.Duration
+	id ID
+
+	missed   chan time.Time
+	timer    Timer
+	gen      uint64
+	lastBeat time.Time
+	stopped  bool
+	mu       sync.Mutex
+}
+
+// NewHeartbeat returns a Heartbeat expecting a Beat at least every d,
+// counting the first interval from now.
+func NewHeartbeat(at AbstractTime, d time.Duration, id ID) *Heartbeat {
+	hb := &Heartbeat{at: at, d: d, id: id, missed: make(chan time.Time, 1)}
+	hb.Beat()
+	return hb
+}
+
+// Beat records a sign of life from the producer, starting the interval
+// over. It does nothing once the Heartbeat is stopped.
+func (hb *Heartbeat) Beat() {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	if hb.stopped {
+		return
+	}
+	if hb.timer != nil {
+		hb.timer.Stop()
+	}
+	hb.gen++
+	gen := hb.gen
+	hb.lastBeat = hb.at.Now()
+	hb.timer = hb.at.AfterFunc(hb.d, func() { hb.miss(gen) }, hb.id)
+}
+
+// miss reports a missed beat, unless there has been a Beat since the
+// timer for the given generation was made.
+func (hb *Heartbeat) miss(gen uint64) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	if hb.stopped || gen != hb.gen {
+		return
+	}
+	select {
+	case hb.missed <- hb.at.Now():
+	default:
+	}
+}
+
+// Missed returns the channel a missed beat is reported on. Each silence is
+// reported once; the next report can only come after another Beat. As
+// with a time.Timer's channel, it holds one report, and any more while
+// that one goes unreceived are dropped.
+func (hb *Heartbeat) Missed() <-chan time.Time {
+	return hb.missed
+}
+
+// LastBeat returns the time of the last Beat, or of the Heartbeat's
+// creation if there hasn't been one.
+func (hb *Heartbeat) LastBeat() time.Time {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	return hb.lastBeat
+}
+
+// Stop stops watching for missed beats. It may be called more than once.
+func (hb *Heartbeat) Stop() {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	if !hb.stopped {
+		hb.stopped = true
+		hb.timer.Stop()
+	}
+}
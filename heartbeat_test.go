@@ -0,0 +1,47 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	mt := NewManual()
+	hb := NewHeartbeat(mt, time.Second, afterFuncID)
+
+	// beating replaces the timeout, so it's still just the one
+	mt.Advance(time.Second / 2)
+	hb.Beat()
+	if !hb.LastBeat().Equal(mt.Now()) {
+		t.Fatal("beat not recorded")
+	}
+	if len(mt.Registrations()) != 1 {
+		t.Fatalf("unexpected registrations: %v", mt.Registrations())
+	}
+	select {
+	case <-hb.Missed():
+		t.Fatal("missed reported while beating")
+	default:
+	}
+
+	// going quiet is reported, once
+	mt.TriggerAndWait(afterFuncID)
+	if missed := <-hb.Missed(); !missed.Equal(mt.Now()) {
+		t.Fatalf("unexpected miss time %v", missed)
+	}
+	mt.VerifyNoPending(t)
+
+	hb.Beat()
+	hb.Stop()
+	hb.Stop()
+	mt.VerifyNoPending(t)
+
+	// and in real time
+	hb = NewHeartbeat(NewRealTime(), 5*time.Millisecond, 0)
+	defer hb.Stop()
+	select {
+	case <-hb.Missed():
+	case <-time.After(time.Second):
+		t.Fatal("real heartbeat miss never reported")
+	}
+}
@@ -0,0 +1,213 @@
+package abtime
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// EventKind is the kind of an Event in a ManualTime's history.
+type EventKind int
+
+const (
+	// Registered means a timer, ticker, sleep, or context was registered.
+	Registered EventKind = iota
+
+	// Triggered means Trigger was called for the ID.
+	Triggered
+
+	// Fired means a registration fired: a value was sent, a sleep
+	// woken, a function run, or a context canceled.
+	Fired
+
+	// Stopped means a timer or ticker was stopped while still live.
+	Stopped
+
+	// Advanced means Now moved, by Advance or otherwise. Its ID is nil.
+	Advanced
+)
+
+func (ek EventKind) String() string {
+	switch ek {
+	case Registered:
+		return "Registered"
+	case Triggered:
+		return "Triggered"
+	case Fired:
+		return "Fired"
+	case Stopped:
+		return "Stopped"
+	case Advanced:
+		return "Advanced"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(ek))
+	}
+}
+
+// An Event is an entry in a ManualTime's history.
+type Event struct {
+	Kind EventKind
+	ID   ID
+
+	// Time is the ManualTime's Now when the event happened.
+	Time time.Time
+
+	// Registration names the kind of registration involved, such as
+	// "Timer" or "Sleep". It is empty for Triggered events.
+	Registration string
+
+	// CreatedBy is the file:line of the call that made the registration,
+	// and Goroutine the goroutine that made it, for Registered events
+	// under SetTrackOwnership. Otherwise they are empty.
+	CreatedBy string
+	Goroutine uint64
+}
+
+func (e Event) String() string {
+	return e.format(fmt.Sprint(e.ID))
+}
+
+// format formats the event, with the given rendering of its id.
+func (e Event) format(id string) string {
+	if e.Kind == Advanced {
+		return fmt.Sprintf("Advanced to %v", e.Time)
+	}
+	if e.Registration == "" {
+		return fmt.Sprintf("%v id %s at %v", e.Kind, id, e.Time)
+	}
+	if e.CreatedBy != "" {
+		return fmt.Sprintf("%v %s id %s at %v, by goroutine %d at %s",
+			e.Kind, e.Registration, id, e.Time, e.Goroutine, e.CreatedBy)
+	}
+	return fmt.Sprintf("%v %s id %s at %v", e.Kind, e.Registration, id, e.Time)
+}
+
+// record appends an event to the history. The lock must be held.
+func (mt *ManualTime) record(kind EventKind, id ID, registration string) {
+	mt.recordEvent(Event{Kind: kind, ID: id, Time: mt.now, Registration: registration})
+}
+
+// recordEvent appends a fully formed event to the history. The lock must
+// be held.
+func (mt *ManualTime) recordEvent(event Event) {
+	mt.history = append(mt.history, event)
+	mt.events++
+	mt.trimHistory()
+	mt.breakpoint(event)
+	for _, sub := range mt.subscriptions {
+		sub.publish(event)
+	}
+}
+
+// recordStop records that the given registration was stopped.
+func (mt *ManualTime) recordStop(trig trigger) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.record(Stopped, trig.reg().id, kind(trig))
+}
+
+// trimHistory drops the oldest events beyond the history limit. Slicing
+// them off lets the next reallocation by append leave them behind. The
+// lock must be held.
+func (mt *ManualTime) trimHistory() {
+	if mt.historyLimit > 0 && len(mt.history) > mt.historyLimit {
+		mt.history = mt.history[len(mt.history)-mt.historyLimit:]
+	}
+}
+
+// History returns every registration, Trigger, firing, stop, and advance
+// that has happened on the ManualTime, in order, since the last
+// ClearHistory. Under SetHistoryLimit, only the most recent are kept.
+func (mt *ManualTime) History() []Event {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return append([]Event(nil), mt.history...)
+}
+
+// SetHistoryLimit sets how many of the most recent events the History
+// keeps, dropping older ones as new ones are recorded. By default, and
+// with a limit of 0, it keeps everything, which is what a test asserting
+// on it wants, but long runs under auto-advance or AutoTrigger can
+// accumulate a great many events.
+//
+// AssertTriggered, AssertFiredInOrder, and Script only see the events
+// that are kept.
+func (mt *ManualTime) SetHistoryLimit(limit int) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.historyLimit = limit
+	mt.trimHistory()
+}
+
+// ClearHistory discards the History recorded so far, so a long test can
+// assert on each phase afresh.
+func (mt *ManualTime) ClearHistory() {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.history = nil
+}
+
+// formatEvents formats events for a failure message, naming any declared
+// ids.
+func (mt *ManualTime) formatEvents(events []Event) string {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if len(events) == 0 {
+		return "  (none)"
+	}
+	lines := make([]string, len(events))
+	for i, event := range events {
+		lines[i] = "  " + event.format(mt.idString(event.ID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssertTriggered fails the test if the id has never been triggered,
+// printing the history.
+func (mt *ManualTime) AssertTriggered(t testing.TB, id ID) {
+	t.Helper()
+
+	history := mt.History()
+	for _, event := range history {
+		if event.Kind == Triggered && event.ID == id {
+			return
+		}
+	}
+	mt.Lock()
+	name := mt.idString(id)
+	mt.Unlock()
+	t.Errorf("id %s was never triggered; history:\n%s", name, mt.formatEvents(history))
+}
+
+// AssertFiredInOrder fails the test unless registrations for the given
+// ids fired in the given order. Other firings may be interleaved; only
+// the relative order of the given ones is checked.
+func (mt *ManualTime) AssertFiredInOrder(t testing.TB, ids ...ID) {
+	t.Helper()
+
+	history := mt.History()
+	fired := []Event{}
+	next := 0
+	for _, event := range history {
+		if event.Kind != Fired {
+			continue
+		}
+		fired = append(fired, event)
+		if next < len(ids) && event.ID == ids[next] {
+			next++
+		}
+	}
+	if next < len(ids) {
+		mt.Lock()
+		missing := mt.idString(ids[next])
+		mt.Unlock()
+		t.Errorf("ids did not fire in the order %v; missing %s from the firings:\n%s",
+			ids, missing, mt.formatEvents(fired))
+	}
+}
@@ -0,0 +1,85 @@
+package abtime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	mt := NewManual()
+	now := mt.Now()
+
+	mt.After(time.Second, afterID)
+	mt.Trigger(timerID)
+	mt.NewTimer(time.Second, timerID)
+	mt.Trigger(afterID)
+
+	expected := []Event{
+		{Kind: Registered, ID: afterID, Time: now, Registration: "After"},
+		{Kind: Triggered, ID: timerID, Time: now},
+		{Kind: Registered, ID: timerID, Time: now, Registration: "Timer"},
+		{Kind: Fired, ID: timerID, Time: now, Registration: "Timer"},
+		{Kind: Triggered, ID: afterID, Time: now},
+		{Kind: Fired, ID: afterID, Time: now, Registration: "After"},
+	}
+	history := mt.History()
+	if len(history) != len(expected) {
+		t.Fatalf("unexpected history:\n%s", mt.formatEvents(history))
+	}
+	for i := range expected {
+		if history[i] != expected[i] {
+			t.Fatalf("unexpected history:\n%s", mt.formatEvents(history))
+		}
+	}
+
+	mt.AssertTriggered(t, afterID)
+	mt.AssertFiredInOrder(t, timerID, afterID)
+
+	rt := &recordingT{}
+	mt.AssertTriggered(rt, sleepID)
+	mt.AssertFiredInOrder(rt, afterID, timerID)
+	if len(rt.errors) != 2 ||
+		!strings.Contains(rt.errors[0], "id 1 was never triggered") ||
+		!strings.Contains(rt.errors[1], "missing 5 from the firings:\n  Fired Timer id 5") {
+		t.Fatalf("unexpected failures: %v", rt.errors)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	mt := NewManual()
+	mt.SetHistoryLimit(2)
+
+	script := mt.Expect(Trigger(afterID), Trigger(timerID), Trigger(sleepID))
+	mt.Trigger(afterID)
+	mt.Trigger(timerID)
+	mt.Trigger(sleepID)
+	history := mt.History()
+	if len(history) != 2 || history[0].ID != timerID || history[1].ID != sleepID {
+		t.Fatalf("history not limited to the most recent events:\n%s", mt.formatEvents(history))
+	}
+	rt := &recordingT{}
+	script.Verify(rt)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "discarded") {
+		t.Fatalf("script verified against a trimmed history: %v", rt.errors)
+	}
+
+	// a script started after the trimming is unaffected
+	script = mt.Expect(Trigger(afterID))
+	mt.Trigger(afterID)
+	script.Verify(t)
+
+	mt.ClearHistory()
+	if len(mt.History()) != 0 {
+		t.Fatal("history not cleared")
+	}
+	mt.SetHistoryLimit(0)
+	script = mt.Expect(Trigger(afterID), Trigger(timerID), Trigger(sleepID))
+	mt.Trigger(afterID)
+	mt.Trigger(timerID)
+	mt.Trigger(sleepID)
+	script.Verify(t)
+	if len(mt.History()) != 3 {
+		t.Fatal("unlimited history lost events")
+	}
+}
@@ -0,0 +1,140 @@
+package abtime
+
+import (
+	"runtime"
+	"sort"
+)
+
+// settleRounds is how many rounds of yielding without any activity on the
+// ManualTime RunUntilIdle takes as quiescence.
+const settleRounds = 10
+
+// RunUntilIdle fires every registration that has come due, meaning its
+// scheduled time as reported by Pending is at or before Now, and waits
+// for each firing to be consumed. It repeats this until nothing more is
+// due, so that registrations made by the woken code which are already due
+// themselves fire too, and returns how many firings it made. This is the
+// "settle" in advance, settle, assert:
+//
+//	mt.Advance(time.Minute)
+//	mt.RunUntilIdle()
+//	// assert on everything that should have happened in that minute
+//
+// Registrations come due in order of their scheduled time, breaking ties
+// by priority (see SetPriority) and then by the order they were made. A
+// ticker fires once per interval it has fallen behind by. WithCancel
+// contexts are never due, and stale registrations (see SetStaleAfter) are
+// expired rather than fired.
+//
+// Go offers no way to observe that goroutines are blocked, so between
+// rounds RunUntilIdle yields the processor until the ManualTime has gone
+// a while without seeing any activity. Code that does a lot of work
+// between one use of the clock and the next may need a more explicit
+// handshake. As with TriggerAndWait, a firing nothing ever consumes
+// blocks RunUntilIdle forever.
+func (mt *ManualTime) RunUntilIdle() int {
+	fired := 0
+	for {
+		mt.settle()
+
+		mt.Lock()
+		due := mt.due()
+		if len(due) == 0 {
+			mt.Unlock()
+			return fired
+		}
+		// the baseline is taken before firing, as a buffered delivery
+		// is counted as consumed the moment it is made
+		targets := map[ID]int{}
+		for _, trig := range due {
+			id := trig.reg().id
+			di := mt.deliveryInfo(id)
+			if _, seen := targets[id]; !seen {
+				targets[id] = di.consumed
+			}
+			before := di.delivered
+			if mt.fireOne(trig) {
+				mt.remove(trig)
+			}
+			targets[id] += di.delivered - before
+			fired++
+		}
+		for id, target := range targets {
+			for mt.deliveryInfo(id).consumed < target {
+				mt.consumed.Wait()
+			}
+		}
+		mt.Unlock()
+	}
+}
+
+// due returns the live registrations that have come due, in the order to
+// fire them. The lock must be held.
+func (mt *ManualTime) due() []trigger {
+	mt.expireStale()
+
+	due := []trigger{}
+	ats := map[trigger]Pending{}
+	for _, ti := range mt.triggers {
+		ti.prune()
+		for _, trig := range ti.triggers {
+			p := pendingOf(trig)
+			if tt, isTicker := trig.(*tickTrigger); isTicker && tt.Duration() <= 0 {
+				continue
+			}
+			if p.At.IsZero() || p.At.After(mt.now) {
+				continue
+			}
+			due = append(due, trig)
+			ats[trig] = p
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		ati, atj := ats[due[i]].At, ats[due[j]].At
+		if !ati.Equal(atj) {
+			return ati.Before(atj)
+		}
+		pi, pj := mt.priorities[due[i].reg().id], mt.priorities[due[j].reg().id]
+		if pi != pj {
+			return pi > pj
+		}
+		return due[i].reg().seq < due[j].reg().seq
+	})
+	return due
+}
+
+// remove removes the registration from its queue. The lock must be held.
+func (mt *ManualTime) remove(trig trigger) {
+	ti, exists := mt.triggers[trig.reg().id]
+	if !exists {
+		return
+	}
+	for i, queued := range ti.triggers {
+		if queued == trig {
+			copy(ti.triggers[i:], ti.triggers[i+1:])
+			ti.triggers[len(ti.triggers)-1] = nil
+			ti.triggers = ti.triggers[:len(ti.triggers)-1]
+			return
+		}
+	}
+}
+
+// settle yields until the ManualTime has seen no new events for
+// settleRounds rounds. The lock must not be held.
+func (mt *ManualTime) settle() {
+	last := -1
+	for quiet := 0; quiet < settleRounds; {
+		for i := 0; i < 10; i++ {
+			runtime.Gosched()
+		}
+		mt.Lock()
+		events := mt.events
+		mt.Unlock()
+		if events == last {
+			quiet++
+		} else {
+			quiet = 0
+			last = events
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunUntilIdle(t *testing.T) {
+	mt := NewManual()
+
+	done := make(chan struct{})
+	go func() {
+		<-mt.After(time.Second, afterID)
+		// these are due as soon as they're made, so the same
+		// RunUntilIdle fires them
+		mt.Sleep(0, sleepID)
+		ctx, cancel := mt.WithTimeout(context.Background(), 0, contextID)
+		defer cancel()
+		<-ctx.Done()
+		close(done)
+	}()
+	for len(mt.PendingIDs()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if fired := mt.RunUntilIdle(); fired != 0 {
+		t.Fatalf("fired %d registrations before they were due", fired)
+	}
+	mt.Advance(time.Second)
+	if fired := mt.RunUntilIdle(); fired != 3 {
+		t.Fatalf("fired %d registrations rather than 3", fired)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not finish")
+	}
+}
+
+func TestRunUntilIdleBuffered(t *testing.T) {
+	mt := NewManual()
+	mt.SetDeliveryPolicy(Buffered(1))
+
+	after := mt.After(time.Second, afterID)
+	mt.Advance(time.Second)
+	returned := make(chan int)
+	go func() { returned <- mt.RunUntilIdle() }()
+	select {
+	case fired := <-returned:
+		if fired != 1 {
+			t.Fatalf("fired %d registrations rather than 1", fired)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunUntilIdle hung on a buffered delivery")
+	}
+	<-after
+}
+
+func TestRunUntilIdleOrder(t *testing.T) {
+	mt := NewManual()
+	mt.SetPriority(1, "high")
+
+	ticker := mt.NewTicker(time.Second, tickID)
+	go func() {
+		for range ticker.Channel() {
+		}
+	}()
+	mt.AfterFunc(2*time.Second, func() {}, "low")
+	mt.AfterFunc(2*time.Second, func() {}, "high")
+	mt.AfterFunc(time.Second, func() {}, "first")
+	mt.WithCancel(context.Background(), contextID)
+
+	mt.Advance(3 * time.Second)
+	if fired := mt.RunUntilIdle(); fired != 6 {
+		t.Fatalf("fired %d registrations rather than 6", fired)
+	}
+	ticker.Stop()
+	mt.AssertFiredInOrder(t, "first", "high", "low")
+	if mt.Delivered(tickID) != 3 || mt.Consumed(tickID) != 3 {
+		t.Fatal("ticker did not catch up")
+	}
+	if pending := mt.PendingIDs(); len(pending) != 1 || pending[0] != contextID {
+		t.Fatalf("unexpected pending ids: %v", pending)
+	}
+}
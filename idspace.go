@@ -0,0 +1,119 @@
+package abtime
+
+import "sync"
+
+// idSpaceBase is where IDSpace allocation starts, well clear of any
+// hand-written iota block that might share a test with it.
+const idSpaceBase = 1 << 24
+
+// spaces is the process-wide allocator every IDSpace draws from, which is
+// what keeps two spaces from ever handing out the same id.
+var spaces = struct {
+	sync.Mutex
+	next  int
+	names map[int]string
+}{next: idSpaceBase, names: map[int]string{}}
+
+// An IDSpace allocates IDs that can't collide with those of any other
+// IDSpace, for libraries that want their own IDs without coordinating an
+// iota block with everything else that might end up in the same test.
+// Allocate them at init time:
+//
+//	var (
+//	    ids         = abtime.NewIDSpace()
+//	    retryID     = ids.Named("billing.retry")
+//	    gracePeriod = ids.Named("billing.graceperiod")
+//	)
+//
+// A ManualTime prints the names of Named IDs wherever it would print the
+// id, and TriggerSpace fires everything registered under a space.
+//
+// An IDSpace is safe for concurrent use.
+type IDSpace struct {
+	ids   []int
+	names map[string]int
+	mu    sync.Mutex
+}
+
+// NewIDSpace returns a new, empty IDSpace.
+func NewIDSpace() *IDSpace {
+	return &IDSpace{names: map[string]int{}}
+}
+
+// Next allocates a fresh, unnamed id.
+func (is *IDSpace) Next() int {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	return is.allocate("")
+}
+
+// Named returns the id for the given name in this space, allocating it
+// the first time the name is asked for.
+func (is *IDSpace) Named(name string) int {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	if id, have := is.names[name]; have {
+		return id
+	}
+	id := is.allocate(name)
+	is.names[name] = id
+	return id
+}
+
+// IDs returns every id allocated from the space, in allocation order.
+func (is *IDSpace) IDs() []int {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+
+	return append([]int(nil), is.ids...)
+}
+
+// allocate draws the next id from the process-wide allocator. The lock
+// must be held.
+func (is *IDSpace) allocate(name string) int {
+	spaces.Lock()
+	defer spaces.Unlock()
+
+	id := spaces.next
+	spaces.next++
+	if name != "" {
+		spaces.names[id] = name
+	}
+	is.ids = append(is.ids, id)
+	return id
+}
+
+// spaceName returns the name an id was given by IDSpace.Named, if it was.
+func spaceName(id ID) (string, bool) {
+	n, isInt := id.(int)
+	if !isInt || n < idSpaceBase {
+		return "", false
+	}
+	spaces.Lock()
+	defer spaces.Unlock()
+
+	name, named := spaces.names[n]
+	return name, named
+}
+
+// TriggerSpace fires every live registration under any id of the space,
+// once each, just as TriggerAll does for a single id, returning how many
+// there were. The ids are fired in allocation order; ids with nothing
+// registered are skipped entirely, and so don't appear in the History as
+// triggered.
+func (mt *ManualTime) TriggerSpace(space *IDSpace) int {
+	pending := map[ID]bool{}
+	for _, id := range mt.PendingIDs() {
+		pending[id] = true
+	}
+
+	fired := 0
+	for _, id := range space.IDs() {
+		if pending[id] {
+			fired += mt.triggerLive(id, -1)
+		}
+	}
+	return fired
+}
@@ -0,0 +1,65 @@
+package abtime
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIDSpace(t *testing.T) {
+	billing, shipping := NewIDSpace(), NewIDSpace()
+
+	// allocate concurrently from both spaces; nothing may collide
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			billing.Named(fmt.Sprintf("billing.%d", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			shipping.Next()
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, id := range append(billing.IDs(), shipping.IDs()...) {
+		if seen[id] || id < idSpaceBase {
+			t.Fatalf("bad id %d", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 20 {
+		t.Fatalf("expected 20 ids, got %d", len(seen))
+	}
+
+	retry := billing.Named("billing.retry")
+	if billing.Named("billing.retry") != retry {
+		t.Fatal("Named not stable")
+	}
+
+	mt := NewManual()
+	mt.After(time.Second, retry)
+	mt.After(time.Second, retry)
+	mt.After(time.Second, shipping.Next())
+	rt := &recordingT{}
+	mt.VerifyNoPending(rt)
+	if len(rt.errors) != 1 ||
+		!strings.Contains(rt.errors[0], fmt.Sprintf("id %d (billing.retry)", retry)) {
+		t.Fatalf("unexpected report: %v", rt.errors)
+	}
+
+	if fired := mt.TriggerSpace(billing); fired != 2 {
+		t.Fatalf("expected 2 fired, got %d", fired)
+	}
+	if len(mt.PendingIDs()) != 1 {
+		t.Fatalf("unexpected pending ids: %v", mt.PendingIDs())
+	}
+	if fired := mt.TriggerSpace(shipping); fired != 1 {
+		t.Fatalf("expected 1 fired, got %d", fired)
+	}
+}
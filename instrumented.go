@@ -0,0 +1,284 @@
+package abtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Recorder records the uses of an InstrumentedTime. Its methods are
+// called synchronously from whichever goroutine made or fired the
+// registration, so they must be safe for concurrent use, and should be
+// quick.
+type Recorder interface {
+	// Registered is called when a timer, ticker, sleep, or context is
+	// made, or a timer Reset, with the kind of registration, as named in
+	// Event.Registration, and the duration it was armed for. The
+	// duration is a ticker's interval, and zero for WithCancel.
+	Registered(id ID, registration string, d time.Duration)
+
+	// Fired is called when the registration fires, with the time it
+	// did: a value sent, a sleep ended, a function run, or a context's
+	// deadline exceeded. A ticker fires on every tick.
+	Fired(id ID, registration string, at time.Time)
+}
+
+// InstrumentedTime is a RealTime that reports every use of it to a
+// Recorder, by ID. This is the same abstraction used in tests put to work
+// in production, to see what timers the code actually arms, for how long,
+// and how often they fire, or just to check that IDs are being used
+// sensibly.
+//
+// Timers and tickers follow LegacyTimers semantics. Tickers are forwarded
+// by a goroutine so their ticks can be recorded; like time.Ticker, ticks
+// are dropped for slow receivers.
+type InstrumentedTime struct {
+	RealTime
+	recorder Recorder
+}
+
+// NewRealTimeInstrumented returns a real clock reporting to the given
+// Recorder.
+func NewRealTimeInstrumented(recorder Recorder) InstrumentedTime {
+	return InstrumentedTime{NewRealTime(), recorder}
+}
+
+// After wraps time.After, recording its use.
+func (it InstrumentedTime) After(d time.Duration, id ID) <-chan time.Time {
+	return it.newTimer(d, id, "After").c
+}
+
+// Sleep wraps time.Sleep, recording its use.
+func (it InstrumentedTime) Sleep(d time.Duration, id ID) {
+	it.recorder.Registered(id, "Sleep", d)
+	time.Sleep(d)
+	it.recorder.Fired(id, "Sleep", time.Now())
+}
+
+// SleepContext sleeps for the given duration unless the context is done
+// first, just as RealTime does, recording its use. Only a sleep that runs
+// its course counts as fired.
+func (it InstrumentedTime) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	it.recorder.Registered(id, "Sleep", d)
+	err := sleepContext(ctx, d)
+	if err == nil {
+		it.recorder.Fired(id, "Sleep", time.Now())
+	}
+	return err
+}
+
+// Tick wraps time.Tick, recording its use.
+func (it InstrumentedTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	return it.NewTicker(d, id).Channel()
+}
+
+// NewTicker wraps time.NewTicker, recording its use.
+func (it InstrumentedTime) NewTicker(d time.Duration, id ID) Ticker {
+	it.recorder.Registered(id, "Ticker", d)
+	ticker := &instrumentedTicker{
+		Ticker:   time.NewTicker(d),
+		recorder: it.recorder,
+		id:       id,
+		c:        make(chan time.Time, 1),
+		stop:     make(chan struct{}),
+	}
+	go ticker.forward(ticker.stop)
+	return ticker
+}
+
+// AfterFunc wraps time.AfterFunc, recording its use.
+func (it InstrumentedTime) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	it.recorder.Registered(id, "AfterFunc", d)
+	return TimerWrap{time.AfterFunc(d, func() {
+		it.recorder.Fired(id, "AfterFunc", time.Now())
+		f()
+	})}
+}
+
+// NewTimer wraps time.NewTimer, recording its use.
+func (it InstrumentedTime) NewTimer(d time.Duration, id ID) Timer {
+	return it.newTimer(d, id, "Timer")
+}
+
+// NewTimerAt creates a timer that fires at the given time, recording its
+// use.
+func (it InstrumentedTime) NewTimerAt(t time.Time, id ID) Timer {
+	return it.newTimer(time.Until(t), id, "Timer")
+}
+
+func (it InstrumentedTime) newTimer(d time.Duration, id ID, registration string) *instrumentedTimer {
+	it.recorder.Registered(id, registration, d)
+	timer := &instrumentedTimer{
+		recorder:     it.recorder,
+		id:           id,
+		registration: registration,
+		c:            make(chan time.Time, 1),
+	}
+	timer.t = time.AfterFunc(d, timer.fire)
+	return timer
+}
+
+// WithCancel wraps context.WithCancel, recording its use.
+func (it InstrumentedTime) WithCancel(parent context.Context, id ID) (context.Context, context.CancelFunc) {
+	it.recorder.Registered(id, "Context", 0)
+	return context.WithCancel(parent)
+}
+
+// WithDeadline wraps context.WithDeadline, recording its use.
+func (it InstrumentedTime) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	return it.withDeadline(parent, deadline, time.Until(deadline), nil, id)
+}
+
+// WithTimeout wraps context.WithTimeout, recording its use.
+func (it InstrumentedTime) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	return it.withDeadline(parent, time.Now().Add(timeout), timeout, nil, id)
+}
+
+// WithDeadlineCause wraps context.WithDeadlineCause, recording its use.
+func (it InstrumentedTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	return it.withDeadline(parent, deadline, time.Until(deadline), cause, id)
+}
+
+// WithTimeoutCause wraps context.WithTimeoutCause, recording its use.
+func (it InstrumentedTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	return it.withDeadline(parent, time.Now().Add(timeout), timeout, cause, id)
+}
+
+func (it InstrumentedTime) withDeadline(parent context.Context, deadline time.Time, d time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	it.recorder.Registered(id, "Context", d)
+	ctx, cancel := context.WithDeadlineCause(parent, deadline, cause)
+	go func() {
+		<-ctx.Done()
+		if ctx.Err() == context.DeadlineExceeded {
+			it.recorder.Fired(id, "Context", time.Now())
+		}
+	}()
+	return ctx, cancel
+}
+
+type instrumentedTimer struct {
+	t            *time.Timer
+	recorder     Recorder
+	id           ID
+	registration string
+	c            chan time.Time
+}
+
+func (it *instrumentedTimer) fire() {
+	now := time.Now()
+	it.recorder.Fired(it.id, it.registration, now)
+	select {
+	case it.c <- now:
+	default:
+	}
+}
+
+func (it *instrumentedTimer) Channel() <-chan time.Time {
+	return it.c
+}
+
+func (it *instrumentedTimer) Stop() bool {
+	return it.t.Stop()
+}
+
+func (it *instrumentedTimer) Reset(d time.Duration) bool {
+	it.recorder.Registered(it.id, it.registration, d)
+	return it.t.Reset(d)
+}
+
+type instrumentedTicker struct {
+	*time.Ticker
+	recorder Recorder
+	id       ID
+	c        chan time.Time
+
+	// closing stop ends forward; it is nil while the ticker is stopped
+	stop chan struct{}
+	mu   sync.Mutex
+}
+
+func (it *instrumentedTicker) forward(stop chan struct{}) {
+	for {
+		select {
+		case tick := <-it.Ticker.C:
+			it.recorder.Fired(it.id, "Ticker", tick)
+			select {
+			case it.c <- tick:
+			default:
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (it *instrumentedTicker) Channel() <-chan time.Time {
+	return it.c
+}
+
+func (it *instrumentedTicker) Reset(d time.Duration) {
+	it.recorder.Registered(it.id, "Ticker", d)
+
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.Ticker.Reset(d)
+	if it.stop == nil {
+		it.stop = make(chan struct{})
+		go it.forward(it.stop)
+	}
+}
+
+func (it *instrumentedTicker) Stop() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	it.Ticker.Stop()
+	if it.stop != nil {
+		close(it.stop)
+		it.stop = nil
+	}
+}
+
+// A Usage is one use of an InstrumentedTime, as kept by a UsageLog.
+type Usage struct {
+	// Kind is Registered or Fired.
+	Kind         EventKind
+	ID           ID
+	Registration string
+
+	// Duration is the duration armed, for Registered usages.
+	Duration time.Duration
+
+	// Time is when the usage happened.
+	Time time.Time
+}
+
+// A UsageLog is a Recorder that simply keeps every usage in memory.
+type UsageLog struct {
+	usages []Usage
+	mu     sync.Mutex
+}
+
+// Registered implements Recorder.
+func (ul *UsageLog) Registered(id ID, registration string, d time.Duration) {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+
+	ul.usages = append(ul.usages, Usage{Registered, id, registration, d, time.Now()})
+}
+
+// Fired implements Recorder.
+func (ul *UsageLog) Fired(id ID, registration string, at time.Time) {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+
+	ul.usages = append(ul.usages, Usage{Fired, id, registration, 0, at})
+}
+
+// Usages returns the usages logged so far, in order.
+func (ul *UsageLog) Usages() []Usage {
+	ul.mu.Lock()
+	defer ul.mu.Unlock()
+
+	return append([]Usage(nil), ul.usages...)
+}
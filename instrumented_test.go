@@ -0,0 +1,71 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInstrumented(t *testing.T) {
+	log := &UsageLog{}
+	var at AbstractTime = NewRealTimeInstrumented(log)
+
+	<-at.After(time.Millisecond, afterID)
+	at.Sleep(time.Millisecond, sleepID)
+	timer := at.NewTimer(time.Hour, timerID)
+	timer.Reset(time.Millisecond)
+	<-timer.Channel()
+	ticker := at.NewTicker(time.Millisecond, tickID)
+	<-ticker.Channel()
+	ticker.Stop()
+	select {
+	case <-ticker.Channel():
+	default:
+	}
+	ticker.Reset(time.Millisecond)
+	<-ticker.Channel()
+	ticker.Stop()
+	ran := make(chan struct{})
+	at.AfterFunc(time.Millisecond, func() { close(ran) }, afterFuncID)
+	<-ran
+	ctx, cancel := at.WithTimeout(context.Background(), time.Millisecond, contextID)
+	defer cancel()
+	<-ctx.Done()
+
+	// the context's firing is recorded by its own goroutine
+	deadline := time.Now().Add(time.Second)
+	for {
+		usages := log.Usages()
+		last := usages[len(usages)-1]
+		if last.Kind == Fired && last.ID == contextID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("context deadline not recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	registered := map[ID][]time.Duration{}
+	fired := map[ID]int{}
+	for _, usage := range log.Usages() {
+		switch usage.Kind {
+		case Registered:
+			registered[usage.ID] = append(registered[usage.ID], usage.Duration)
+		case Fired:
+			fired[usage.ID]++
+		}
+	}
+	if len(registered[timerID]) != 2 || registered[timerID][0] != time.Hour ||
+		registered[timerID][1] != time.Millisecond {
+		t.Fatalf("timer durations not recorded: %v", registered[timerID])
+	}
+	for _, id := range []ID{afterID, sleepID, timerID, afterFuncID, contextID} {
+		if len(registered[id]) == 0 || fired[id] != 1 {
+			t.Fatalf("id %v registered %v, fired %d times", id, registered[id], fired[id])
+		}
+	}
+	if fired[tickID] < 1 {
+		t.Fatal("ticks not recorded")
+	}
+}
@@ -5,6 +5,16 @@ import (
 	"time"
 )
 
+// An ID identifies a particular time event, so that a ManualTime can
+// trigger it. It may be any comparable value: the traditional iota block of
+// ints works, but descriptive strings such as "billing.retry-timeout", or
+// values of your own named types, make for much more readable test
+// failures and avoid collisions between packages.
+//
+// Using a non-comparable value, such as a slice, as an ID will panic, just
+// as it would as a map key.
+type ID interface{}
+
 // Ticker defines an interface for the functions that return *time.Ticker
 // in the original Time module.
 type Ticker interface {
@@ -21,16 +31,84 @@ type Timer interface {
 	Channel() <-chan time.Time
 }
 
+// TimerSemantics selects between the behaviors of timer and ticker
+// channels before and after Go 1.23.
+//
+// Before Go 1.23, a timer or ticker that had fired but whose value had not
+// been received still held that stale value after Stop or Reset, and Stop
+// would report that the timer had already expired. As of Go 1.23, Stop and
+// Reset discard any value not yet received, so no stale value can be
+// received afterwards, and a timer whose value was never received counts
+// as still active.
+//
+// Which semantics the real runtime uses depends on the go version of the
+// main module and the asynctimerchan GODEBUG setting, so choose the one
+// matching your production binary.
+type TimerSemantics int
+
+const (
+	// LegacyTimers reproduces the behavior before Go 1.23. It is the
+	// default. On RealTime it simply passes through to the runtime.
+	LegacyTimers TimerSemantics = iota
+
+	// Go123Timers reproduces the behavior as of Go 1.23. On RealTime, any
+	// stale value is drained on Stop and Reset, so this holds even when
+	// the runtime is using the old semantics.
+	Go123Timers
+)
+
+// A Clock is the smallest useful subset of an AbstractTime, for code that
+// only ever needs to know what time it is. Every AbstractTime is a Clock,
+// as are the minimal clocks of the tiny subpackage.
+type Clock interface {
+	Now() time.Time
+}
+
+// A Sleeper can block for a while.
+type Sleeper interface {
+	Sleep(time.Duration, ID)
+	SleepContext(context.Context, time.Duration, ID) error
+}
+
+// An AfterSource provides one-shot channels, as time.After does.
+type AfterSource interface {
+	After(time.Duration, ID) <-chan time.Time
+}
+
+// A TimerFactory makes Timers.
+type TimerFactory interface {
+	NewTimer(time.Duration, ID) Timer
+	NewTimerAt(time.Time, ID) Timer
+	AfterFunc(time.Duration, func(), ID) Timer
+}
+
+// A TickerFactory makes Tickers.
+type TickerFactory interface {
+	Tick(time.Duration, ID) <-chan time.Time
+	NewTicker(time.Duration, ID) Ticker
+}
+
+// A ContextFactory makes contexts that are canceled by the clock.
+type ContextFactory interface {
+	WithCancel(context.Context, ID) (context.Context, context.CancelFunc)
+	WithDeadline(context.Context, time.Time, ID) (context.Context, context.CancelFunc)
+	WithTimeout(context.Context, time.Duration, ID) (context.Context, context.CancelFunc)
+	WithDeadlineCause(context.Context, time.Time, error, ID) (context.Context, context.CancelFunc)
+	WithTimeoutCause(context.Context, time.Duration, error, ID) (context.Context, context.CancelFunc)
+}
+
 // The AbstractTime interface abstracts the time module into an interface.
+//
+// It's the union of the smaller interfaces above, so code that only
+// needs part of it, say a Sleeper, can declare just that, and fakes of
+// its own only have to implement that much. Anything taking one of them
+// will of course still accept any AbstractTime.
 type AbstractTime interface {
-	Now() time.Time
-	After(time.Duration, int) <-chan time.Time
-	Sleep(time.Duration, int)
-	Tick(time.Duration, int) <-chan time.Time
-	NewTicker(time.Duration, int) Ticker
-	AfterFunc(time.Duration, func(), int) Timer
-	NewTimer(time.Duration, int) Timer
-
-	WithDeadline(context.Context, time.Time, int) (context.Context, context.CancelFunc)
-	WithTimeout(context.Context, time.Duration, int) (context.Context, context.CancelFunc)
+	ReadOnlyTime
+	NowIn(*time.Location) time.Time
+	Sleeper
+	AfterSource
+	TimerFactory
+	TickerFactory
+	ContextFactory
 }
@@ -0,0 +1,125 @@
+package abtime
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// A Jitterer can randomize durations itself. ManualTime does so with a
+// fixed, seedable sequence per ID, so code that adds jitter to its
+// timeouts stays reproducible under test. See Jitter.
+type Jitterer interface {
+	Jitter(d time.Duration, fraction float64, id ID) time.Duration
+}
+
+// Jitter randomizes d by up to the given fraction of it in either
+// direction, so a fraction of 0.5 yields durations between half and one
+// and a half times d. The fraction is clamped to [0, 1].
+//
+// If the AbstractTime is a Jitterer, as ManualTime and the decorators in
+// this package are, the randomness comes from it; otherwise, as for
+// RealTime, it comes from math/rand. Timing code that draws its jitter
+// through here rather than from math/rand directly can be tested
+// deterministically.
+func Jitter(at AbstractTime, d time.Duration, fraction float64, id ID) time.Duration {
+	if jitterer, ok := at.(Jitterer); ok {
+		return jitterer.Jitter(d, fraction, id)
+	}
+	return jitter(d, fraction, rand.Float64())
+}
+
+// jitter applies the fraction of jitter to d, given a random number in
+// [0, 1).
+func jitter(d time.Duration, fraction float64, random float64) time.Duration {
+	fraction = math.Max(0, math.Min(1, fraction))
+	return time.Duration(float64(d) * (1 - fraction + 2*fraction*random))
+}
+
+// Jitter randomizes d as described by the package's Jitter function,
+// drawing from math/rand.
+func (rt RealTime) Jitter(d time.Duration, fraction float64, _ ID) time.Duration {
+	return jitter(d, fraction, rand.Float64())
+}
+
+// Jitter randomizes d as described by the package's Jitter function,
+// drawing from math/rand.
+func (st *ScaledTime) Jitter(d time.Duration, fraction float64, _ ID) time.Duration {
+	return jitter(d, fraction, rand.Float64())
+}
+
+// Jitter randomizes d as described by the package's Jitter function.
+//
+// The random numbers come from a separate sequence for each ID, seeded
+// from the ManualTime's jitter seed and the ID, so they are the same on
+// every run, and don't depend on the order different goroutines happen to
+// ask for them in. SetJitterSeed changes the seed, and SetJitterFunc
+// replaces the sequences entirely.
+func (mt *ManualTime) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.jitterFunc != nil {
+		return jitter(d, fraction, mt.jitterFunc(id))
+	}
+	source, exists := mt.jitterSources[id]
+	if !exists {
+		hash := fnv.New64a()
+		fmt.Fprint(hash, id)
+		source = rand.New(rand.NewSource(mt.jitterSeed ^ int64(hash.Sum64())))
+		if mt.jitterSources == nil {
+			mt.jitterSources = map[ID]*rand.Rand{}
+		}
+		mt.jitterSources[id] = source
+	}
+	return jitter(d, fraction, source.Float64())
+}
+
+// SetJitterSeed seeds the ManualTime's jitter sequences, restarting them.
+// The default seed is 0. Running a test under several seeds exercises
+// different jitter while keeping each run reproducible.
+func (mt *ManualTime) SetJitterSeed(seed int64) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.jitterSeed = seed
+	mt.jitterSources = nil
+}
+
+// SetJitterFunc replaces the ManualTime's jitter sequences with a function
+// returning a number in [0, 1) for the given ID. A function returning 0.5
+// turns jitter off entirely; 0 and just under 1 give the extremes. Passing
+// nil goes back to the seeded sequences.
+func (mt *ManualTime) SetJitterFunc(random func(id ID) float64) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.jitterFunc = random
+}
+
+// Jitter passes through to the ManualTime's Jitter, with a scoped id.
+func (ns *Namespace) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	return ns.mt.Jitter(d, fraction, ns.ID(id))
+}
+
+// Jitter passes through to the wrapped AbstractTime's Jitter.
+func (ct *ChaosTime) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	return Jitter(ct.AbstractTime, d, fraction, id)
+}
+
+// Jitter passes through to the wrapped AbstractTime's Jitter.
+func (m *MeteredTime) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	return Jitter(m.AbstractTime, d, fraction, id)
+}
+
+// Jitter passes through to the wrapped AbstractTime's Jitter.
+func (tt TruncatedTime) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	return Jitter(tt.AbstractTime, d, fraction, id)
+}
+
+// Jitter passes through to the wrapped AbstractTime's Jitter.
+func (ct *CoalescedTime) Jitter(d time.Duration, fraction float64, id ID) time.Duration {
+	return Jitter(ct.AbstractTime, d, fraction, id)
+}
@@ -0,0 +1,52 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+	sequence := func(mt *ManualTime, id ID) []time.Duration {
+		ds := []time.Duration{}
+		for i := 0; i < 5; i++ {
+			ds = append(ds, Jitter(mt, time.Second, 0.5, id))
+		}
+		return ds
+	}
+
+	first, second := NewManual(), NewManual()
+	// interleaving another id doesn't disturb the sequence
+	Jitter(second, time.Second, 0.5, "other")
+	a, b := sequence(first, "retry"), sequence(second, "retry")
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("sequences differ: %v and %v", a, b)
+		}
+		if a[i] < 500*time.Millisecond || a[i] > 1500*time.Millisecond {
+			t.Fatalf("jitter out of range: %v", a[i])
+		}
+	}
+
+	second.SetJitterSeed(1)
+	if c := sequence(second, "retry"); c[0] == a[0] && c[1] == a[1] {
+		t.Fatal("seed made no difference")
+	}
+
+	first.SetJitterFunc(func(ID) float64 { return 0.5 })
+	if Jitter(first, time.Second, 0.5, "retry") != time.Second {
+		t.Fatal("jitter func not used")
+	}
+	first.SetJitterFunc(func(ID) float64 { return 0 })
+	if Jitter(first, time.Second, 2, "retry") != 0 {
+		t.Fatal("fraction not clamped")
+	}
+
+	// decorators pass through to the ManualTime
+	if Jitter(NewChaosTime(first), time.Second, 0.5, "retry") != 500*time.Millisecond {
+		t.Fatal("ChaosTime did not pass jitter through")
+	}
+
+	if d := Jitter(NewRealTime(), time.Second, 0.1, "retry"); d < 900*time.Millisecond || d > 1100*time.Millisecond {
+		t.Fatalf("real jitter out of range: %v", d)
+	}
+}
@@ -0,0 +1,182 @@
+package abtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// A JournalEntry is one use of a RecordingTime.
+type JournalEntry struct {
+	// Kind is "Now" for a reading of the clock, or "Registered" or
+	// "Fired" as for an Event.
+	Kind string `json:"kind"`
+
+	// ID is the ID involved, as formatted by fmt.Sprint, so that it
+	// survives encoding. It is empty for Now.
+	ID string `json:"id,omitempty"`
+
+	// Registration names the kind of registration, such as "Timer".
+	Registration string `json:"registration,omitempty"`
+
+	// Duration is the duration armed, for Registered entries.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Time is the time read, for Now, or when the entry happened.
+	Time time.Time `json:"time"`
+}
+
+// A Journal is a Recorder keeping every use of a clock as JournalEntries,
+// in order, so that a session on the real clock can be encoded, saved,
+// and replayed on a ManualTime. See RecordingTime.
+type Journal struct {
+	entries []JournalEntry
+	mu      sync.Mutex
+}
+
+func (j *Journal) add(entry JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = append(j.entries, entry)
+}
+
+// Registered implements Recorder.
+func (j *Journal) Registered(id ID, registration string, d time.Duration) {
+	j.add(JournalEntry{"Registered", fmt.Sprint(id), registration, d, time.Now()})
+}
+
+// Fired implements Recorder.
+func (j *Journal) Fired(id ID, registration string, at time.Time) {
+	j.add(JournalEntry{"Fired", fmt.Sprint(id), registration, 0, at})
+}
+
+// Entries returns the entries journaled so far.
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]JournalEntry(nil), j.entries...)
+}
+
+// Encode writes the journal to w as JSON.
+func (j *Journal) Encode(w io.Writer) error {
+	return json.NewEncoder(w).Encode(j.Entries())
+}
+
+// DecodeJournal reads a journal written by Encode.
+func DecodeJournal(r io.Reader) (*Journal, error) {
+	j := &Journal{}
+	if err := json.NewDecoder(r).Decode(&j.entries); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// ErrReplayDiverged is the error Replay returns when the code under test
+// stops following the journal.
+var ErrReplayDiverged = errors.New("replay diverged from the journal")
+
+// Replay reconstructs the journaled session on a ManualTime, while the
+// code under test runs against it in other goroutines. The Now readings
+// are queued with QueueNows, so the code reads the same sequence of times
+// it did, and the firings are replayed in their original order: for each,
+// Replay waits for the code to register the ID, advances Now to the time
+// it fired if that is later, and Triggers it. IDs are matched by their
+// fmt.Sprint formatting, since that's all the journal keeps.
+//
+// If the code doesn't register an ID within realTimeout of real time, it
+// has gone its own way, and Replay returns an error wrapping
+// ErrReplayDiverged.
+//
+// This turns a flaky production timing into a deterministic regression
+// test, at least as long as the code makes the same calls in the same
+// order.
+func (j *Journal) Replay(mt *ManualTime, realTimeout time.Duration) error {
+	entries := j.Entries()
+	nows := []time.Time{}
+	for _, entry := range entries {
+		if entry.Kind == "Now" {
+			nows = append(nows, entry.Time)
+		}
+	}
+	mt.QueueNows(nows...)
+
+	for i, entry := range entries {
+		if entry.Kind != "Fired" {
+			continue
+		}
+		id, found := mt.awaitID(entry.ID, realTimeout)
+		if !found {
+			return fmt.Errorf("abtime: entry %d, %s %s id %s, never registered within %v: %w",
+				i, entry.Kind, entry.Registration, entry.ID, realTimeout, ErrReplayDiverged)
+		}
+		if entry.Time.After(mt.currentNow()) {
+			mt.AdvanceTo(entry.Time)
+		}
+		mt.Trigger(id)
+	}
+	return nil
+}
+
+// awaitID waits up to the given real time for a live registration whose
+// id formats as the given one, returning the id.
+func (mt *ManualTime) awaitID(formatted string, realTimeout time.Duration) (ID, bool) {
+	giveUp := time.Now().Add(realTimeout)
+	for {
+		for _, id := range mt.PendingIDs() {
+			if fmt.Sprint(id) == formatted {
+				return id, true
+			}
+		}
+		if time.Now().After(giveUp) {
+			return nil, false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// RecordingTime is an InstrumentedTime journaling every use of the real
+// clock, including every reading of Now, for replay on a ManualTime with
+// Journal.Replay.
+type RecordingTime struct {
+	InstrumentedTime
+	journal *Journal
+}
+
+// NewRecordingTime returns a real clock journaling its use.
+func NewRecordingTime() *RecordingTime {
+	journal := &Journal{}
+	return &RecordingTime{NewRealTimeInstrumented(journal), journal}
+}
+
+// Now wraps time.Now, journaling the reading.
+func (rt *RecordingTime) Now() time.Time {
+	now := rt.InstrumentedTime.Now()
+	rt.journal.add(JournalEntry{Kind: "Now", Time: now})
+	return now
+}
+
+// NowIn wraps time.Now, journaling the reading.
+func (rt *RecordingTime) NowIn(loc *time.Location) time.Time {
+	return rt.Now().In(loc)
+}
+
+// Since wraps time.Since, journaling the reading of Now it makes, so that
+// a replay queues the same one.
+func (rt *RecordingTime) Since(t time.Time) time.Duration {
+	return rt.Now().Sub(t)
+}
+
+// Until wraps time.Until, journaling the reading of Now it makes.
+func (rt *RecordingTime) Until(t time.Time) time.Duration {
+	return t.Sub(rt.Now())
+}
+
+// Journal returns the journal of the clock's use.
+func (rt *RecordingTime) Journal() *Journal {
+	return rt.journal
+}
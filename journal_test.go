@@ -0,0 +1,94 @@
+package abtime
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// pollTwice is the code under test: it reads the clock around two waits.
+func pollTwice(at AbstractTime) []time.Time {
+	seen := []time.Time{at.Now()}
+	<-at.After(2*time.Millisecond, "first")
+	seen = append(seen, at.Now())
+	timer := at.NewTimer(time.Millisecond, "second")
+	<-timer.Channel()
+	return append(seen, at.Now())
+}
+
+func TestJournalReplay(t *testing.T) {
+	rt := NewRecordingTime()
+	recorded := pollTwice(rt)
+
+	entries := rt.Journal().Entries()
+	if len(entries) != 7 || entries[0].Kind != "Now" || entries[1].Kind != "Registered" ||
+		entries[1].ID != "first" || entries[1].Duration != 2*time.Millisecond ||
+		entries[2].Kind != "Fired" {
+		t.Fatalf("unexpected journal: %v", entries)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.Journal().Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := DecodeJournal(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mt := NewManual()
+	replayed := make(chan []time.Time)
+	go func() { replayed <- pollTwice(mt) }()
+	if err := journal.Replay(mt, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	seen := <-replayed
+	for i := range recorded {
+		if !seen[i].Equal(recorded[i]) {
+			t.Fatalf("replay saw %v, recorded %v", seen, recorded)
+		}
+	}
+
+	// code that goes its own way is reported
+	err = journal.Replay(NewManual(), 10*time.Millisecond)
+	if !errors.Is(err, ErrReplayDiverged) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// timeWait is code under test measuring a wait with Since and Until.
+func timeWait(at AbstractTime) []time.Duration {
+	start := at.Now()
+	<-at.After(2*time.Millisecond, "wait")
+	return []time.Duration{at.Since(start), at.Until(start.Add(time.Second))}
+}
+
+func TestJournalReplaySinceUntil(t *testing.T) {
+	rt := NewRecordingTime()
+	recorded := timeWait(rt)
+	nows := 0
+	for _, entry := range rt.Journal().Entries() {
+		if entry.Kind == "Now" {
+			nows++
+		}
+	}
+	if nows != 3 {
+		t.Fatalf("Since and Until not journaled: %v", rt.Journal().Entries())
+	}
+
+	mt := NewManual()
+	replayed := make(chan []time.Duration)
+	go func() { replayed <- timeWait(mt) }()
+	if err := rt.Journal().Replay(mt, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// the recording measured on the monotonic clock, and the replay on
+	// the journaled wall clock, which may be slewed by a hair
+	seen := <-replayed
+	for i := range recorded {
+		if diff := seen[i] - recorded[i]; diff < -time.Microsecond || diff > time.Microsecond {
+			t.Fatalf("replay saw %v, recorded %v", seen, recorded)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// A Lease models ownership of something that must be periodically renewed,
+// such as a leader election or a distributed lock, and is lost if it is
+// not renewed in time.
+//
+// All the timing runs through the AbstractTime, using two IDs: one for the
+// renewal timer, and one for the expiry timer. On a ManualTime, triggering
+// the renewal ID causes a renewal attempt, and triggering the expiry ID
+// causes the lease to be lost, unless it has been successfully renewed
+// since the current expiry timer was created.
+type Lease struct {
+	at            AbstractTime
+	duration      time.Duration
+	renewInterval time.Duration
+	renew         func() bool
+	onLost        func()
+	renewID       ID
+	expireID      ID
+
+	expires time.Time
+	held    bool
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+// NewLease returns a Lease, considered acquired as of the clock's Now, for
+// the given duration.
+//
+// renewBefore is the fraction of the duration before expiry at which
+// renewal is attempted; 0.25 on a one minute lease attempts renewal 45
+// seconds in. It must be between 0 and 1, exclusive. renew is called to
+// attempt renewal, returning whether it succeeded; a failed renewal is
+// retried after half the time left on the lease, or the usual interval if
+// that is sooner, so retries keep coming until the lease expires. onLost
+// is called, in the Lease's own goroutine, if the lease expires; it may
+// call Release, which returns at once.
+func NewLease(at AbstractTime, duration time.Duration, renewBefore float64, renew func() bool, onLost func(), renewID, expireID ID) *Lease {
+	if renewBefore <= 0 || renewBefore >= 1 {
+		panic("lease renewBefore must be between 0 and 1")
+	}
+	l := &Lease{
+		at:            at,
+		duration:      duration,
+		renewInterval: duration - time.Duration(float64(duration)*renewBefore),
+		renew:         renew,
+		onLost:        onLost,
+		renewID:       renewID,
+		expireID:      expireID,
+		expires:       at.Now().Add(duration),
+		held:          true,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+func (l *Lease) run() {
+	// the lease is over before onLost hears of it, so that onLost may
+	// call Release
+	if l.hold() && l.onLost != nil {
+		l.onLost()
+	}
+}
+
+// hold keeps the lease renewed until it is lost, returning true, or it
+// is released, returning false.
+func (l *Lease) hold() bool {
+	defer close(l.done)
+
+	renewal := l.at.NewTimer(l.renewInterval, l.renewID)
+	expiry := l.at.NewTimer(l.duration, l.expireID)
+	defer func() {
+		renewal.Stop()
+		expiry.Stop()
+	}()
+
+	for {
+		select {
+		case <-renewal.Channel():
+			if l.renew() {
+				expiry.Stop()
+				l.mu.Lock()
+				l.expires = l.at.Now().Add(l.duration)
+				l.mu.Unlock()
+				expiry = l.at.NewTimer(l.duration, l.expireID)
+				renewal = l.at.NewTimer(l.renewInterval, l.renewID)
+			} else if retry := l.retryAfter(); retry > 0 {
+				renewal = l.at.NewTimer(retry, l.renewID)
+			}
+
+		case <-expiry.Channel():
+			l.mu.Lock()
+			l.held = false
+			l.mu.Unlock()
+			return true
+
+		case <-l.stop:
+			return false
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying a failed renewal,
+// which is never as long as the time left on the lease. It is zero once
+// there is no time left to retry in.
+func (l *Lease) retryAfter() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	retry := l.expires.Sub(l.at.Now()) / 2
+	if retry > l.renewInterval {
+		return l.renewInterval
+	}
+	return retry
+}
+
+// Held returns whether the lease is still held.
+func (l *Lease) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.held
+}
+
+// Expires returns when the lease will expire if not renewed.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.expires
+}
+
+// Release gives up the lease, stopping its timers without calling
+// onLost. It blocks until the Lease has stopped its timers, and may be
+// called more than once, including from onLost.
+func (l *Lease) Release() {
+	l.mu.Lock()
+	if l.held {
+		l.held = false
+		close(l.stop)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+}
@@ -0,0 +1,78 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	renewID = iota
+	expireID
+)
+
+func TestLease(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	renewals := make(chan bool)
+	lost := make(chan struct{})
+	lease := NewLease(mt, time.Minute, 0.25, func() bool {
+		return <-renewals
+	}, func() {
+		close(lost)
+	}, renewID, expireID)
+
+	if !lease.Held() || lease.Expires() != start.Add(time.Minute) {
+		t.Fatal("lease not correctly acquired")
+	}
+
+	mt.Advance(45 * time.Second)
+	mt.Trigger(renewID)
+	renewals <- true
+	for lease.Expires() == start.Add(time.Minute) {
+		time.Sleep(time.Millisecond)
+	}
+	if lease.Expires() != start.Add(105*time.Second) {
+		t.Fatal("renewal did not extend the lease")
+	}
+
+	// with the lease only half its duration from expiry, the failed
+	// renewal is retried well before it expires
+	mt.Advance(30 * time.Second)
+	mt.Trigger(renewID)
+	renewals <- false
+	for len(mt.Pending(renewID)) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if retry := mt.Pending(renewID)[0].At; retry != start.Add(90*time.Second) {
+		t.Fatalf("failed renewal retried at %v", retry.Sub(start))
+	}
+	mt.Trigger(renewID)
+	renewals <- false
+	mt.Trigger(expireID)
+	<-lost
+	if lease.Held() {
+		t.Fatal("lease still held after expiring")
+	}
+	lease.Release()
+
+	lease = NewLease(mt, time.Minute, 0.5, func() bool { return true },
+		func() { t.Fatal("released lease should not be lost") }, renewID, expireID)
+	lease.Release()
+	lease.Release()
+	mt.VerifyNoPending(t)
+
+	// releasing the lease as it is lost doesn't deadlock
+	released := make(chan struct{})
+	lease = NewLease(mt, time.Minute, 0.5, func() bool { return true },
+		func() {
+			lease.Release()
+			close(released)
+		}, renewID, expireID)
+	mt.Trigger(expireID)
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Release from onLost deadlocked")
+	}
+}
@@ -8,25 +8,182 @@ package abtime
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"testing"
 	"time"
 )
 
 // The ManualTime object implements a time object you directly control.
 //
 // This allows you to manipulate "now", and control when events occur.
+//
+// A ManualTime is safe for concurrent use. Now reads a published copy of
+// the current time without taking the ManualTime's lock, unless Nows are
+// queued, so many goroutines can read the clock at once. Everything else,
+// registering, triggering, and stopping included, is still serialized on
+// that one lock.
 type ManualTime struct {
 	now      time.Time
 	nows     []time.Time
-	triggers map[int]*triggerInfo
+	triggers map[ID]*triggerInfo
+
+	// registration limits; see SetMaxRegistrations
+	maxRegistrations      int
+	maxRegistrationsForID map[ID]int
+
+	// held Trigger limits; see SetMaxCredit
+	maxCredit      int
+	maxCreditForID map[ID]int
+
+	// delivery bookkeeping; see Delivered and Consumed
+	deliveries map[ID]*deliveryInfo
+	consumed   *sync.Cond
+
+	// the monotonic clock, as elapsed time since creation, and the
+	// history of its relationship to now; see SetWallClock
+	mono   time.Duration
+	epochs []wallEpoch
+
+	// broadcast whenever now moves; see Barrier
+	advanced *sync.Cond
+
+	// contexts canceled when now passes their deadline; see
+	// WithDeadlineAuto
+	deadlines []*contextTrigger
+
+	// tiebreaks for simultaneous deadlines; see SetPriority
+	priorities map[ID]int
+
+	// the last registration sequence number handed out
+	seq uint64
+
+	// descriptive names for ids; see DeclareID
+	names map[ID]string
+
+	autoAdvance     bool
+	deliverNow      bool
+	fireNonPositive bool
+	advanceOnFire   bool
+	timerSemantics  TimerSemantics
+
+	// stale registration expiry; see SetStaleAfter
+	staleAfter time.Duration
+	expired    []trigger
+
+	// the most recent events, how many there have ever been, and how
+	// many to keep; see History and SetHistoryLimit
+	history      []Event
+	events       int
+	historyLimit int
+
+	// hooks by id; see SetBreakpoint
+	breakpoints map[ID]func(Event)
+
+	// event subscriptions; see Subscribe and SetObserver
+	subscriptions []*subscription
+	observer      <-chan Event
+
+	// maximum undelivered ticks per ticker; see SetTickerBacklog
+	tickerBacklog int
+
+	// see SetRewindPolicy
+	rewindPolicy RewindPolicy
+	onRewind     func(error)
+
+	// see SetTrackOwnership
+	trackOwnership bool
+
+	// see Freeze and SetPrecision
+	frozen    bool
+	precision time.Duration
+
+	// see SetStepLocation and SetLocation
+	stepLocation *time.Location
+	location     *time.Location
+
+	// see AutoTrigger
+	autoTriggers map[ID]AutoTriggerPolicy
+
+	// see SetFault
+	faults map[ID]Fault
+
+	// see Jitter
+	jitterSeed    int64
+	jitterSources map[ID]*rand.Rand
+	jitterFunc    func(ID) float64
+
+	// strict mode; see NewManualStrict
+	strict   bool
+	onStrict func(error)
+
+	// see SetDiagnostics
+	diagnostics *diagnostics
+
+	// see Close
+	closed  bool
+	closing chan struct{}
+
+	// stops each Watchdog; see NewManualForTest
+	watchdogs []func()
+
+	// see SetDeliveryPolicy
+	delivery DeliveryPolicy
+
+	// the current Now, for reading without the lock; see publishNow
+	published atomic.Pointer[publishedNow]
 
 	sync.Mutex
 }
 
+// A publishedNow is a snapshot of now, and whether any Nows were queued
+// when it was taken, in which case reading Now must take the lock after
+// all to consume one.
+type publishedNow struct {
+	now    time.Time
+	queued bool
+}
+
+// publishNow makes the current now available to Now without the lock. It
+// must be called whenever now or the queue of Nows changes. The lock must
+// be held.
+func (mt *ManualTime) publishNow() {
+	mt.published.Store(&publishedNow{mt.reading(), len(mt.nows) > 0 && !mt.frozen})
+}
+
+// ErrTooManyRegistrations is the error ManualTime panics with when an ID
+// exceeds its limit on outstanding registrations. See SetMaxRegistrations.
+var ErrTooManyRegistrations = errors.New("too many outstanding registrations")
+
+// ErrClosed is the error SleepErr and SleepContext return when they are
+// woken by Close.
+var ErrClosed = errors.New("abtime: ManualTime closed")
+
+// ErrTooMuchCredit is the error ManualTime panics with when an ID exceeds
+// its limit on held Triggers. See SetMaxCredit.
+var ErrTooMuchCredit = errors.New("too many held triggers")
+
+// ErrUnregisteredTrigger is the error a strict ManualTime reports when an
+// ID is Triggered with no live registration. See NewManualStrict.
+var ErrUnregisteredTrigger = errors.New("trigger for an id with no registration")
+
+// ErrDuplicateRegistration is the error a strict ManualTime reports when an
+// ID is registered from a second call site while a registration from
+// another is still live. See NewManualStrict.
+var ErrDuplicateRegistration = errors.New("id registered from multiple call sites")
+
+// triggerInfo holds the registrations for a single ID. Registrations form
+// a FIFO queue; each Trigger is consumed by the oldest live registration.
 type triggerInfo struct {
-	// the number of times this has been Triggered without anything in
-	// the triggers array. This accounts for when .Trigger is called
-	// before the thing has been registered.
+	// the number of times this has been Triggered without a live
+	// registration to consume it. This accounts for when .Trigger is
+	// called before the thing has been registered.
 	count    uint
 	triggers []trigger
 }
@@ -35,46 +192,590 @@ type trigger interface {
 	// Note this is always called while the lock for *ManualTime is
 	// held.
 	trigger(mt *ManualTime) bool // if true, delete the token; if false, keep it.
+
+	// live returns false once the registration has been stopped, at
+	// which point it is discarded rather than triggered.
+	live() bool
+
+	// close releases anything waiting on the registration, as the
+	// ManualTime is closed. Also called with the lock held.
+	close(mt *ManualTime)
+
+	reg() *registration
 }
 
-func (mt *ManualTime) register(id int, trig trigger) {
+// registration carries the bookkeeping common to every trigger. It is
+// embedded in each of the trigger types.
+type registration struct {
+	id ID
+
+	// the call stack that created the registration
+	stack []uintptr
+
+	// the virtual time the registration was made
+	created time.Time
+
+	// the order the registration was queued in, across all ids
+	seq uint64
+
+	// the goroutine that made the registration, if tracked; see
+	// SetTrackOwnership
+	goroutine uint64
+}
+
+// isInternal returns whether the named function is one of ManualTime's
+// own, or of a Namespace onto one, which are left out of creation stacks.
+func isInternal(function string) bool {
+	return strings.HasPrefix(function, "github.com/thejerf/abtime.(*ManualTime).") ||
+		strings.HasPrefix(function, "github.com/thejerf/abtime.(*Namespace).")
+}
+
+// creation formats the stack that created the registration, omitting the
+// frames internal to ManualTime.
+func (r *registration) creation() string {
+	frames := runtime.CallersFrames(r.stack)
+	lines := []string{}
+	internal := true
+	for {
+		frame, more := frames.Next()
+		if internal && isInternal(frame.Function) {
+			if !more {
+				break
+			}
+			continue
+		}
+		internal = false
+		lines = append(lines, fmt.Sprintf("\t%s\n\t\t%s:%d", frame.Function, frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// callSite returns the location of the call into ManualTime that created
+// the registration, or "" if it is unknown.
+func (r *registration) callSite() string {
+	frames := runtime.CallersFrames(r.stack)
+	for {
+		frame, more := frames.Next()
+		if !isInternal(frame.Function) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// kind returns a human-readable name for the sort of registration.
+func kind(trig trigger) string {
+	switch trig.(type) {
+	case *afterTrigger:
+		return "After"
+	case *sleepTrigger:
+		return "Sleep"
+	case *tickTrigger:
+		return "Ticker"
+	case *afterFuncTrigger:
+		return "AfterFunc"
+	case *timerTrigger:
+		return "Timer"
+	case *contextTrigger:
+		return "Context"
+	default:
+		return fmt.Sprintf("%T", trig)
+	}
+}
+
+func (r *registration) reg() *registration {
+	return r
+}
+
+// record fills in the registration's id and creation stack.
+func (r *registration) record(id ID) {
+	r.id = id
+	if r.stack == nil {
+		pcs := make([]uintptr, 32)
+		r.stack = pcs[:runtime.Callers(3, pcs)]
+	}
+}
+
+// deliveryInfo counts the deliveries made for an ID, and how many of them
+// the consuming side has actually received.
+type deliveryInfo struct {
+	delivered int
+	consumed  int
+}
+
+func (mt *ManualTime) register(id ID, trig trigger) {
 	mt.Lock()
 	defer mt.Unlock()
 
-	currentTriggerInfo, present := mt.triggers[id]
-	if !present {
-		mt.triggers[id] = &triggerInfo{0, []trigger{trig}}
+	mt.registerLocked(id, trig)
+}
+
+// registerLocked registers the trigger under the id. The lock must be
+// held.
+func (mt *ManualTime) registerLocked(id ID, trig trigger) {
+	trig.reg().record(id)
+	if mt.closed {
+		trig.close(mt)
+		return
+	}
+	trig.reg().created = mt.now
+	mt.seq++
+	trig.reg().seq = mt.seq
+	event := Event{Kind: Registered, ID: id, Time: mt.now, Registration: kind(trig)}
+	if mt.trackOwnership {
+		trig.reg().goroutine = goroutineID()
+		event.CreatedBy = trig.reg().callSite()
+		event.Goroutine = trig.reg().goroutine
+	}
+	mt.recordEvent(event)
+	ti := mt.triggerInfo(id)
+	if mt.strict {
+		mt.checkCallSite(id, ti, trig)
+	}
+	if mt.diagnostics != nil {
+		mt.diagnose(id, trig)
+	}
+	if limit := mt.registrationLimit(id); limit > 0 {
+		ti.prune()
+		if len(ti.triggers) >= limit {
+			panic(fmt.Errorf("abtime: id %s already has %d live registrations: %w",
+				mt.idString(id), len(ti.triggers), ErrTooManyRegistrations))
+		}
+	}
+	ti.triggers = append(ti.triggers, trig)
+	ti.fire(mt)
+	mt.autoTriggerRegistration(id, trig)
+}
+
+// registerTimed registers a one-shot trigger for the given duration. In
+// auto-advance mode, it instead fires immediately and advances Now.
+func (mt *ManualTime) registerTimed(id ID, trig trigger, d time.Duration) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.registerTimedLocked(id, trig, d)
+}
+
+// registerTimedLocked is registerTimed for callers already holding the
+// lock.
+func (mt *ManualTime) registerTimedLocked(id ID, trig trigger, d time.Duration) {
+	immediate := mt.fireNonPositive && d <= 0
+	if !(mt.autoAdvance || immediate) || mt.closed {
+		mt.registerLocked(id, trig)
+		return
+	}
+
+	trig.reg().record(id)
+	if d > 0 && mt.deliverNow {
+		// the fire happens at the advanced Now, so it must be
+		// advanced before the trigger reads it
+		mt.moveImplicitly(mt.now.Add(d))
+		mt.fireOne(trig)
+		return
+	}
+	target := mt.now.Add(d)
+	mt.fireOne(trig)
+	if d > 0 && mt.now.Before(target) {
+		// under SetAdvanceOnFire, firing may already have advanced
+		mt.moveImplicitly(target)
+	}
+}
+
+// SetTimerSemantics sets the semantics of timers and tickers created after
+// this call. See TimerSemantics.
+func (mt *ManualTime) SetTimerSemantics(semantics TimerSemantics) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.timerSemantics = semantics
+}
+
+// SetDeliverNow sets whether After, timers, and tickers deliver the
+// ManualTime's current Now at the time they are triggered, rather than
+// the time they would nominally have fired at: the Now at creation plus
+// their duration for timers, and the previous tick plus the interval for
+// tickers. This keeps code that compares received times against Now
+// consistent.
+//
+// This affects deliveries made after the call, and does not consume any
+// queued Nows.
+func (mt *ManualTime) SetDeliverNow(deliverNow bool) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.deliverNow = deliverNow
+}
+
+// SetFireNonPositive sets whether After, Sleep, NewTimer, and AfterFunc
+// with a zero or negative duration fire immediately, without waiting for
+// a Trigger, as they do in the time package, and likewise Resetting a
+// Timer to one. This keeps "fire now" fast paths in the code under test
+// behaving as they will in production.
+//
+// It isn't the default because existing tests Trigger such
+// registrations; with it set, those Triggers would instead be held for
+// the next registration.
+//
+// Tickers always panic on a non-positive interval, as time.NewTicker
+// does, and Tick returns nil for one, as time.Tick does.
+func (mt *ManualTime) SetFireNonPositive(fire bool) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.fireNonPositive = fire
+}
+
+// fireIfImmediate fires a registration just re-armed for d straight away,
+// if SetFireNonPositive calls for it. The lock must be held.
+func (mt *ManualTime) fireIfImmediate(trig trigger, d time.Duration) {
+	if mt.fireNonPositive && d <= 0 && trig.live() {
+		if mt.fireOne(trig) {
+			mt.remove(trig)
+		}
+	}
+}
+
+// SetAdvanceOnFire sets whether firing a registration first advances Now
+// to the time it would nominally have fired at, so that code calling Now
+// as it handles a timer's expiry sees a Now consistent with the timer:
+// a 30 second timer triggered right after it was created is received 30
+// seconds later.
+//
+// Now only moves forward this way; firing something whose nominal time
+// has already passed leaves Now alone. Contexts made with WithCancel
+// have no nominal time, and don't move Now either.
+func (mt *ManualTime) SetAdvanceOnFire(advance bool) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.advanceOnFire = advance
+}
+
+// fireTime returns the time to deliver for an event nominally scheduled
+// for the given time. The lock must be held.
+func (mt *ManualTime) fireTime(scheduled time.Time) time.Time {
+	if mt.deliverNow {
+		return mt.now
+	}
+	return scheduled
+}
+
+// SetAutoAdvance turns auto-advance mode on or off. In auto-advance mode,
+// Sleep, After, AfterFunc, NewTimer, and NewTimerAt do not wait for a
+// Trigger; they fire immediately and advance Now by their duration, as if
+// exactly that much time had passed.
+//
+// This lets time-dependent code simply run to completion, which is
+// useful for integration tests and fuzzing that don't care to orchestrate
+// every Trigger. Tickers and contexts are unaffected.
+func (mt *ManualTime) SetAutoAdvance(autoAdvance bool) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.autoAdvance = autoAdvance
+}
+
+// registrationLimit returns the limit on live registrations for the given
+// id, or 0 for no limit. The lock must be held.
+func (mt *ManualTime) registrationLimit(id ID) int {
+	if limit, set := mt.maxRegistrationsForID[id]; set {
+		return limit
+	}
+	return mt.maxRegistrations
+}
+
+// SetMaxRegistrations limits how many live registrations may be queued
+// under a single ID at once. If ids are given, the limit applies only to
+// those ids; otherwise it becomes the default for all ids without their
+// own limit. A limit of 0 means unlimited, which is the default.
+//
+// Exceeding the limit panics with an error wrapping
+// ErrTooManyRegistrations. This is meant to catch code that leaks a new
+// timer on every pass through a loop without ever stopping the old ones,
+// which otherwise just silently piles up registrations.
+func (mt *ManualTime) SetMaxRegistrations(limit int, ids ...ID) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if len(ids) == 0 {
+		mt.maxRegistrations = limit
 		return
 	}
+	if mt.maxRegistrationsForID == nil {
+		mt.maxRegistrationsForID = map[ID]int{}
+	}
+	for _, id := range ids {
+		mt.maxRegistrationsForID[id] = limit
+	}
+}
 
-	currentTriggerInfo.triggers = append(currentTriggerInfo.triggers, trig)
+// creditLimit returns the held Trigger limit for the id, or 0 for no
+// limit. The lock must be held.
+func (mt *ManualTime) creditLimit(id ID) int {
+	if limit, set := mt.maxCreditForID[id]; set {
+		return limit
+	}
+	return mt.maxCredit
+}
 
-	triggerAll(mt, currentTriggerInfo)
+// SetMaxCredit limits how many Triggers may be held for an ID with no
+// live registration to consume them. If ids are given, the limit applies
+// only to those ids; otherwise it becomes the default for all ids without
+// their own limit. A limit of 0 means unlimited, which is the default.
+//
+// Exceeding the limit panics with an error wrapping ErrTooMuchCredit,
+// without holding the excess Trigger. Held Triggers otherwise pile up
+// invisibly, so this catches tests that accidentally trigger more than
+// the code will ever consume. See also PendingTriggers.
+func (mt *ManualTime) SetMaxCredit(limit int, ids ...ID) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if len(ids) == 0 {
+		mt.maxCredit = limit
+		return
+	}
+	if mt.maxCreditForID == nil {
+		mt.maxCreditForID = map[ID]int{}
+	}
+	for _, id := range ids {
+		mt.maxCreditForID[id] = limit
+	}
+}
+
+// PendingTriggers returns how many Triggers are being held for the id,
+// waiting for a registration to consume them.
+func (mt *ManualTime) PendingTriggers(id ID) int {
+	mt.Lock()
+	defer mt.Unlock()
+
+	ti, exists := mt.triggers[id]
+	if !exists {
+		return 0
+	}
+	return int(ti.count)
+}
+
+// triggerInfo returns the triggerInfo for the given id, creating it if
+// necessary. The lock must be held.
+func (mt *ManualTime) triggerInfo(id ID) *triggerInfo {
+	ti, present := mt.triggers[id]
+	if !present {
+		ti = &triggerInfo{}
+		mt.triggers[id] = ti
+	}
+	return ti
 }
 
 // NewManual returns a new ManualTime object, with the Now populated
 // from the time.Now().
 func NewManual() *ManualTime {
-	return &ManualTime{now: time.Now(), nows: []time.Time{}, triggers: make(map[int]*triggerInfo)}
+	return NewManualAtTime(time.Now())
 }
 
 // NewManualAtTime returns a new ManualTime object, with the Now set to the
 // time.Time you pass in.
 func NewManualAtTime(now time.Time) *ManualTime {
-	return &ManualTime{now: now, nows: []time.Time{}, triggers: make(map[int]*triggerInfo)}
+	mt := &ManualTime{
+		now:        now,
+		nows:       []time.Time{},
+		triggers:   make(map[ID]*triggerInfo),
+		deliveries: make(map[ID]*deliveryInfo),
+	}
+	mt.consumed = sync.NewCond(&mt.Mutex)
+	mt.advanced = sync.NewCond(&mt.Mutex)
+	mt.closing = make(chan struct{})
+	mt.epochs = []wallEpoch{{now, 0}}
+	mt.publishNow()
+	return mt
 }
 
-// triggerAll triggers all registered triggers count times, discarding triggers
-// as requested.
-func triggerAll(mt *ManualTime, ti *triggerInfo) {
-	for ti.count > 0 && len(ti.triggers) > 0 {
-		keep := []trigger{}
-		for _, toTrigger := range ti.triggers {
-			if !toTrigger.trigger(mt) {
-				keep = append(keep, toTrigger)
-			}
+// NewManualStrict returns a new ManualTime, set to the current time just
+// as NewManual is, that checks for the sorts of mistakes that otherwise
+// fail silently, usually a typo in an ID:
+//
+//   - Triggering an ID with no live registration reports an error
+//     wrapping ErrUnregisteredTrigger. A non-strict ManualTime just holds
+//     on to the Trigger for a registration that may never come. Note
+//     this means code under test must have registered before the test
+//     Triggers it.
+//   - Registering an ID from one call site while a registration made from
+//     a different call site is still live under it reports an error
+//     wrapping ErrDuplicateRegistration, since it is probably two
+//     different things accidentally sharing an ID. Queueing multiple
+//     registrations from the same call site, as in a loop, is fine.
+//
+// Errors are passed to onError, which is called with the ManualTime
+// locked, so it must not call back into it. A typical choice is t.Error
+// or t.Fatal for your test. If onError is nil, the ManualTime panics with
+// the error instead. Either way, the operation then proceeds as it would
+// have in non-strict mode.
+func NewManualStrict(onError func(error)) *ManualTime {
+	mt := NewManual()
+	mt.strict = true
+	mt.onStrict = onError
+	return mt
+}
+
+// strictError reports a strict-mode violation. The lock must be held.
+func (mt *ManualTime) strictError(err error) {
+	if mt.onStrict == nil {
+		panic(err)
+	}
+	mt.onStrict(err)
+}
+
+// checkCallSite reports if trig was created from a different call site
+// than the live registrations already queued for id. The lock must be
+// held.
+func (mt *ManualTime) checkCallSite(id ID, ti *triggerInfo, trig trigger) {
+	ti.prune()
+	if len(ti.triggers) == 0 {
+		return
+	}
+	existing := ti.triggers[0].reg().callSite()
+	if site := trig.reg().callSite(); site != existing {
+		mt.strictError(fmt.Errorf("abtime: id %s registered at %s while still live from %s: %w",
+			mt.idString(id), site, existing, ErrDuplicateRegistration))
+	}
+}
+
+// deliver runs the given send in its own goroutine, so that triggering
+// never blocks on the consumer, and records when the send completes. The
+// lock must be held.
+func (mt *ManualTime) deliver(id ID, send func()) {
+	mt.deliveryInfo(id).delivered++
+	go func() {
+		send()
+		mt.acknowledge(id)
+	}()
+}
+
+// send sends the value on the channel, unless the ManualTime is closed
+// first, returning whether it sent.
+func (mt *ManualTime) send(ch chan<- time.Time, v time.Time) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-mt.closing:
+		return false
+	}
+}
+
+// retract removes n deliveries for the id that were discarded before
+// being consumed. The lock must not be held.
+func (mt *ManualTime) retract(id ID, n int) {
+	mt.Lock()
+	mt.deliveryInfo(id).delivered -= n
+	mt.Unlock()
+	mt.consumed.Broadcast()
+}
+
+// acknowledge records that a delivery for the id has been consumed. The
+// lock must not be held.
+func (mt *ManualTime) acknowledge(id ID) {
+	mt.Lock()
+	mt.deliveryInfo(id).consumed++
+	mt.Unlock()
+	mt.consumed.Broadcast()
+}
+
+// deliveryInfo returns the deliveryInfo for the given id, creating it if
+// necessary. The lock must be held.
+func (mt *ManualTime) deliveryInfo(id ID) *deliveryInfo {
+	di, present := mt.deliveries[id]
+	if !present {
+		di = &deliveryInfo{}
+		mt.deliveries[id] = di
+	}
+	return di
+}
+
+// Delivered returns how many times a registration under the given id has
+// fired: a value sent on a channel, a Sleep woken, an AfterFunc run, or a
+// context canceled.
+func (mt *ManualTime) Delivered(id ID) int {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.deliveryInfo(id).delivered
+}
+
+// Consumed returns how many of the deliveries for the given id have been
+// acknowledged by the consuming side.
+//
+// Channel deliveries are made unbuffered, so a delivery is acknowledged
+// exactly when the code under test receives the value; no cooperation is
+// required from that code. A Sleep is acknowledged when the sleeper wakes,
+// an AfterFunc when the function returns, and a context cancellation
+// immediately. This allows a test to assert not just that an event
+// fired, but that it was actually consumed.
+func (mt *ManualTime) Consumed(id ID) int {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.deliveryInfo(id).consumed
+}
+
+// WaitConsumed blocks until at least n deliveries for the given id have
+// been consumed, as described in Consumed.
+func (mt *ManualTime) WaitConsumed(id ID, n int) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	for mt.deliveryInfo(id).consumed < n {
+		mt.consumed.Wait()
+	}
+}
+
+// fireOne fires the given registration, recording it in the history, and
+// returns whether it should be removed. The lock must be held.
+func (mt *ManualTime) fireOne(trig trigger) bool {
+	if mt.advanceOnFire {
+		if at := pendingOf(trig).At; at.After(mt.now) {
+			mt.moveImplicitly(at)
+		}
+	}
+	mt.record(Fired, trig.reg().id, kind(trig))
+	if drop, remove := mt.dropped(trig); drop {
+		return remove
+	}
+	return trig.trigger(mt)
+}
+
+// prune discards any registrations that are no longer live.
+func (ti *triggerInfo) prune() {
+	keep := ti.triggers[:0]
+	for _, trig := range ti.triggers {
+		if trig.live() {
+			keep = append(keep, trig)
+		}
+	}
+	for i := len(keep); i < len(ti.triggers); i++ {
+		ti.triggers[i] = nil
+	}
+	ti.triggers = keep
+}
+
+// fire consumes the outstanding trigger count, handing each trigger to
+// the oldest live registration in turn. One-shot registrations are
+// removed as they fire; tickers stay at the head of the queue until they
+// are stopped.
+func (ti *triggerInfo) fire(mt *ManualTime) {
+	for ti.count > 0 {
+		ti.prune()
+		if len(ti.triggers) == 0 {
+			return
+		}
+		if mt.fireOne(ti.triggers[0]) {
+			ti.triggers[0] = nil
+			ti.triggers = ti.triggers[1:]
 		}
-		ti.triggers = keep
 		ti.count--
 	}
 }
@@ -82,36 +783,190 @@ func triggerAll(mt *ManualTime, ti *triggerInfo) {
 // Trigger takes the given ids for time events, and causes them to "occur":
 // triggering messages on channels, ending sleeps, etc.
 //
+// Multiple registrations under the same id form a queue. Each Trigger is
+// consumed by the oldest registration that is still live; stopped timers
+// and tickers are skipped and discarded, and one-shot events such as
+// After and Sleep are removed once they fire. A ticker remains at the head
+// of its queue, receiving every Trigger, until it is stopped. If there is
+// no live registration, the Trigger is held until one arrives.
+//
+// How the firings are delivered depends on the DeliveryPolicy. By default
+// Trigger returns without waiting for them to be received.
+//
 // Note this is the ONLY way to "trigger" such events. While this package
 // allows you to manipulate "Now" in a couple of different ways, advancing
 // "now" past a Trigger's set time will NOT trigger it. First, this keeps
 // it simple to understand when things are triggered, and second, reality
 // isn't so deterministic anyhow....
-func (mt *ManualTime) Trigger(ids ...int) {
+func (mt *ManualTime) Trigger(ids ...ID) {
 	mt.Lock()
 	defer mt.Unlock()
 
+	if mt.delivery.synchronous {
+		targets, _ := mt.triggerForTargets(ids)
+		mt.waitTargets(targets)
+		return
+	}
 	for _, id := range ids {
-		triggers, hasTriggers := mt.triggers[id]
-		if !hasTriggers {
-			mt.triggers[id] = &triggerInfo{1, []trigger{}}
-			continue
+		mt.triggerLocked(id)
+	}
+}
+
+// triggerLocked triggers a single id. The lock must be held.
+func (mt *ManualTime) triggerLocked(id ID) {
+	if mt.closed {
+		return
+	}
+	mt.expireStale()
+	mt.record(Triggered, id, "")
+	ti := mt.triggerInfo(id)
+	if mt.strict {
+		ti.prune()
+		if len(ti.triggers) == 0 {
+			mt.strictError(fmt.Errorf("abtime: id %s: %w", mt.idString(id), ErrUnregisteredTrigger))
 		}
+	}
+	ti.count++
+	ti.fire(mt)
+	if limit := mt.creditLimit(id); limit > 0 && ti.count > uint(limit) {
+		ti.count--
+		panic(fmt.Errorf("abtime: id %s already has %d held triggers: %w",
+			mt.idString(id), limit, ErrTooMuchCredit))
+	}
+}
+
+// TriggerN triggers the id n times, just as calling Trigger n times
+// would. This reads better than a loop for "exactly three ticks".
+func (mt *ManualTime) TriggerN(id ID, n int) {
+	ids := make([]ID, n)
+	for i := range ids {
+		ids[i] = id
+	}
+	mt.Trigger(ids...)
+}
+
+// TriggerAndWait triggers the given ids just as Trigger does, but then
+// blocks until each resulting delivery has been consumed, as described
+// in Consumed. Once this returns, the receiving code is guaranteed to
+// have received its value, woken from its Sleep, or finished running its
+// AfterFunc, so tests don't need to sleep to let it catch up.
+//
+// If an id has no live registration yet, this will wait for one to be
+// made and consume the Trigger, so be sure something will.
+func (mt *ManualTime) TriggerAndWait(ids ...ID) {
+	mt.Lock()
+	defer mt.Unlock()
 
-		triggers.count++
+	targets, _ := mt.triggerForTargets(ids)
+	mt.waitTargets(targets)
+}
 
-		triggerAll(mt, triggers)
+// FireInOrder triggers the given ids one at a time, waiting for each
+// delivery to be consumed, as TriggerAndWait does, before triggering the
+// next. The code under test therefore observes the firings in exactly the
+// order given, which TriggerAndWait with several ids doesn't promise,
+// since it triggers them all before waiting on any.
+//
+// The same caveat applies: an id with no live registration yet is waited
+// on until one is made.
+func (mt *ManualTime) FireInOrder(ids ...ID) {
+	for _, id := range ids {
+		mt.TriggerAndWait(id)
 	}
 }
 
-// Unregister will unregister a particular ID from the system. Normally the
-// first one sticks, which means if you've got code that creates multiple
-// timers in a loop or in multiple function calls, only the first one will
-// work.
+// waitTargets waits until the consumption targets returned by
+// triggerForTargets have been reached. The lock must be held.
+func (mt *ManualTime) waitTargets(targets map[ID]int) {
+	for id, target := range targets {
+		for mt.deliveryInfo(id).consumed < target {
+			mt.consumed.Wait()
+		}
+	}
+}
+
+// triggerForTargets triggers the ids, returning how many deliveries must
+// be consumed for each id before all the triggered ones have been, along
+// with the distinct ids in order. The lock must be held.
+func (mt *ManualTime) triggerForTargets(ids []ID) (map[ID]int, []ID) {
+	targets := map[ID]int{}
+	order := []ID{}
+	for _, id := range ids {
+		if _, seen := targets[id]; !seen {
+			targets[id] = mt.deliveryInfo(id).delivered
+			order = append(order, id)
+		}
+		// Only wait on what the trigger actually delivered: a tick
+		// dropped by a full backlog or a dropping fault delivers
+		// nothing, while a held trigger delivers once registered.
+		di, ti := mt.deliveryInfo(id), mt.triggerInfo(id)
+		delivered, held := di.delivered, ti.count
+		mt.triggerLocked(id)
+		if ti.count > held {
+			targets[id]++
+		} else {
+			targets[id] += di.delivered - delivered
+		}
+	}
+	return targets, order
+}
+
+// ErrNotConsumed is the error TriggerWithin returns when deliveries were
+// not consumed in time.
+var ErrNotConsumed = errors.New("deliveries not consumed")
+
+// TriggerWithin triggers the given ids just as TriggerAndWait does, but
+// gives up waiting for the deliveries to be consumed after the given
+// amount of real time. If any were not consumed, it returns an error
+// wrapping ErrNotConsumed that lists the ids whose consumers failed to
+// receive.
 //
-// NOTE: This method indicates a design flaw in abtime. It is not yet clear
-// to me how to fix it in any reasonable way.
-func (mt *ManualTime) Unregister(ids ...int) {
+// This diagnoses code that isn't actually selecting on the channel it is
+// supposed to be, which otherwise shows up as a test hanging in
+// TriggerAndWait, or as goroutines leaked blocking on their sends. Note
+// that giving up doesn't retract the deliveries; they are still sent if
+// the consumer gets around to receiving them.
+func (mt *ManualTime) TriggerWithin(realTimeout time.Duration, ids ...ID) error {
+	mt.Lock()
+	defer mt.Unlock()
+
+	targets, order := mt.triggerForTargets(ids)
+	expired := false
+	timeout := time.AfterFunc(realTimeout, func() {
+		mt.Lock()
+		expired = true
+		mt.Unlock()
+		mt.consumed.Broadcast()
+	})
+	defer timeout.Stop()
+
+	for {
+		failed := []string{}
+		for _, id := range order {
+			if di := mt.deliveryInfo(id); di.consumed < targets[id] {
+				failed = append(failed, fmt.Sprintf("%v (%d unconsumed)",
+					id, targets[id]-di.consumed))
+			}
+		}
+		if len(failed) == 0 {
+			return nil
+		}
+		if expired {
+			return fmt.Errorf("abtime: after %v, ids %s: %w",
+				realTimeout, strings.Join(failed, ", "), ErrNotConsumed)
+		}
+		mt.consumed.Wait()
+	}
+}
+
+// Unregister will unregister a particular ID from the system, discarding
+// any registrations still queued under it as well as any outstanding
+// Triggers that have not yet been consumed.
+//
+// Since stopped and fired registrations are now removed automatically,
+// this is rarely necessary; it remains useful for abandoning
+// registrations that were never stopped.
+func (mt *ManualTime) Unregister(ids ...ID) {
 	mt.Lock()
 	for _, id := range ids {
 		delete(mt.triggers, id)
@@ -124,35 +979,475 @@ func (mt *ManualTime) Unregister(ids ...int) {
 // such.
 func (mt *ManualTime) UnregisterAll() {
 	mt.Lock()
-	mt.triggers = map[int]*triggerInfo{}
+	mt.triggers = map[ID]*triggerInfo{}
+	mt.expired = nil
+	mt.deadlines = nil
 	mt.Unlock()
 }
 
+// SetStaleAfter sets how much virtual time a registration may remain
+// pending before it is considered stale. Stale registrations are expired:
+// removed from their queue, so Triggers will no longer reach them, and
+// reported as leaks by VerifyNoPending. This distinguishes waiters that
+// were forgotten from those that are intentionally still pending in long
+// simulations.
+//
+// A registration's age is measured from the Now when it was made. Expiry
+// is checked whenever the clock is advanced or triggered, and when
+// pending registrations are inspected. A duration of 0, the default,
+// disables expiry.
+func (mt *ManualTime) SetStaleAfter(d time.Duration) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.staleAfter = d
+	mt.expireStale()
+}
+
+// expireStale moves any stale registrations to the expired list. The lock
+// must be held.
+func (mt *ManualTime) expireStale() {
+	if mt.staleAfter <= 0 {
+		return
+	}
+	for _, ti := range mt.triggers {
+		ti.prune()
+		keep := ti.triggers[:0]
+		for _, trig := range ti.triggers {
+			if mt.now.Sub(trig.reg().created) > mt.staleAfter {
+				mt.expired = append(mt.expired, trig)
+			} else {
+				keep = append(keep, trig)
+			}
+		}
+		for i := len(keep); i < len(ti.triggers); i++ {
+			ti.triggers[i] = nil
+		}
+		ti.triggers = keep
+	}
+}
+
+// PendingIDs returns all the IDs that currently have live registrations:
+// timers and tickers that have not been stopped, sleeps and Afters that
+// have not fired, and contexts that have not been canceled. The IDs are
+// sorted by their printed representation.
+func (mt *ManualTime) PendingIDs() []ID {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.pendingIDs()
+}
+
+// pendingIDs implements PendingIDs. The lock must be held.
+func (mt *ManualTime) pendingIDs() []ID {
+	mt.expireStale()
+
+	ids := []ID{}
+	for id, ti := range mt.triggers {
+		ti.prune()
+		if len(ti.triggers) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return fmt.Sprint(ids[i]) < fmt.Sprint(ids[j])
+	})
+	return ids
+}
+
+// VerifyNoPending fails the test if anything is still registered on the
+// ManualTime, listing each leftover registration along with the stack
+// that created it. Call it at the end of a test to assert that the code
+// under test didn't leave dangling timers or blocked sleeps behind.
+//
+// Registrations expired as stale, per SetStaleAfter, are also reported.
+func (mt *ManualTime) VerifyNoPending(t testing.TB) {
+	t.Helper()
+
+	mt.Lock()
+	defer mt.Unlock()
+
+	report := []string{}
+	for _, id := range mt.pendingIDs() {
+		for _, trig := range mt.triggers[id].triggers {
+			report = append(report, fmt.Sprintf("%s with id %s, %s:\n%s",
+				kind(trig), mt.idString(id), trig.reg().origin(), trig.reg().creation()))
+		}
+	}
+	for _, trig := range mt.expired {
+		report = append(report, fmt.Sprintf("%s with id %s, expired as stale, %s:\n%s",
+			kind(trig), mt.idString(trig.reg().id), trig.reg().origin(), trig.reg().creation()))
+	}
+	if len(report) > 0 {
+		t.Errorf("ManualTime has %d pending registrations:\n%s",
+			len(report), strings.Join(report, "\n"))
+	}
+}
+
+// String describes the current state of the ManualTime: its Now, and for
+// each ID with anything outstanding, the number of live registrations,
+// untriggered credits, and unconsumed deliveries. This is meant for
+// failure messages.
+func (mt *ManualTime) String() string {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.expireStale()
+	lines := []string{}
+	for id, ti := range mt.triggers {
+		ti.prune()
+		di := mt.deliveryInfo(id)
+		unconsumed := di.delivered - di.consumed
+		if len(ti.triggers) == 0 && ti.count == 0 && unconsumed == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("\n  id %s: %d registered, %d untriggered, %d unconsumed",
+			mt.idString(id), len(ti.triggers), ti.count, unconsumed))
+	}
+	sort.Strings(lines)
+
+	return fmt.Sprintf("ManualTime at %v; %d ids outstanding%s",
+		mt.now, len(lines), strings.Join(lines, ""))
+}
+
 // Now returns the ManualTime's current idea of "Now".
 //
 // If you have used QueueNow, this will advance to the next queued Now.
+//
+// Otherwise, Now doesn't take the ManualTime's lock, so code reading the
+// clock from hundreds of goroutines doesn't contend with itself, or with
+// the registrations and Triggers that do.
 func (mt *ManualTime) Now() time.Time {
+	if p := mt.published.Load(); p != nil && !p.queued {
+		return p.now
+	}
+
 	mt.Lock()
 	defer mt.Unlock()
 
-	if len(mt.nows) > 0 {
-		mt.now = mt.nows[0]
+	if len(mt.nows) > 0 && !mt.frozen {
+		next := mt.nows[0]
 		mt.nows = mt.nows[1:]
-		return mt.now
+		mt.setNow(next)
+		mt.publishNow()
 	}
+	return mt.reading()
+}
+
+// NowIn returns Now, in the given location. Like Now, this consumes a
+// queued Now if there is one.
+func (mt *ManualTime) NowIn(loc *time.Location) time.Time {
+	return mt.Now().In(loc)
+}
+
+// SetLocation sets the location Now reports the time in, simulating a
+// server running in that zone, for testing time zone and daylight saving
+// sensitive formatting and scheduling. Now keeps the same instant; only
+// its location changes. Times that Now later moves to, by Advance,
+// AdvanceTo, or queued Nows, are converted to the location as well, and
+// so are the times timers and tickers deliver.
+//
+// Converting a time to a location strips its monotonic clock reading, so
+// the ManualTime's monotonic clock and the wall clock are no longer
+// distinguishable in Now; Since and Until are unaffected.
+func (mt *ManualTime) SetLocation(loc *time.Location) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.location = loc
+	mt.now = mt.now.In(loc)
+	mt.publishNow()
+}
+
+// Location returns the location of Now.
+func (mt *ManualTime) Location() *time.Location {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.now.Location()
+}
+
+// currentNow returns the current now, without consuming any queued Nows.
+func (mt *ManualTime) currentNow() time.Time {
+	mt.Lock()
+	defer mt.Unlock()
+
 	return mt.now
 }
 
-// Advance advances the manual time's idea of "now" by the given
-// duration.
+// nowMoved updates everything that depends on now after it changes. The
+// lock must be held.
+func (mt *ManualTime) nowMoved() {
+	mt.publishNow()
+	mt.record(Advanced, nil, "")
+	mt.advanced.Broadcast()
+	mt.expireDeadlines()
+	mt.autoTriggerDue()
+}
+
+// expireDeadlines cancels the WithDeadlineAuto contexts whose deadline
+// has been reached. The lock must be held.
+func (mt *ManualTime) expireDeadlines() {
+	keep := mt.deadlines[:0]
+	due := []*contextTrigger{}
+	for _, ct := range mt.deadlines {
+		switch {
+		case !ct.live():
+		case ct.deadline.After(mt.now):
+			keep = append(keep, ct)
+		default:
+			due = append(due, ct)
+		}
+	}
+	for i := len(keep); i < len(mt.deadlines); i++ {
+		mt.deadlines[i] = nil
+	}
+	mt.deadlines = keep
+
+	// fire in deadline order, breaking ties by priority, then by
+	// registration order
+	sort.SliceStable(due, func(i, j int) bool {
+		if !due[i].deadline.Equal(due[j].deadline) {
+			return due[i].deadline.Before(due[j].deadline)
+		}
+		return mt.priorities[due[i].id] > mt.priorities[due[j].id]
+	})
+	for _, ct := range due {
+		mt.fireOne(ct)
+	}
+}
+
+// SetPriority sets the priority of the given ids, for breaking ties
+// between registrations that come due at the same virtual time: higher
+// priorities fire first, and registrations of equal priority fire in the
+// order they were registered. The default priority is 0. The order
+// chosen shows in the History.
+//
+// This makes simulations with coarse timestamps deterministic exactly
+// where they would otherwise be order-flaky. It applies wherever
+// ManualTime fires things by their deadline, rather than by Trigger: the
+// contexts of WithDeadlineAuto, and RunUntilIdle.
+func (mt *ManualTime) SetPriority(priority int, ids ...ID) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.priorities == nil {
+		mt.priorities = map[ID]int{}
+	}
+	for _, id := range ids {
+		mt.priorities[id] = priority
+	}
+}
+
+// waitUntil blocks until Now has reached at least t, without consuming
+// any queued Nows.
+func (mt *ManualTime) waitUntil(t time.Time) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	for mt.now.Before(t) {
+		mt.advanced.Wait()
+	}
+}
+
+// Since returns the time elapsed since t, according to Now.
+//
+// Once the wall and monotonic clocks have diverged, as described in
+// SetWallClock, this uses the monotonic clock for times this ManualTime
+// returned, just as time.Since does for times carrying monotonic readings.
+func (mt *ManualTime) Since(t time.Time) time.Duration {
+	now := mt.Now()
+
+	mt.Lock()
+	defer mt.Unlock()
+
+	if len(mt.epochs) > 1 {
+		if mono, known := mt.monotonicOf(t, false); known {
+			return mt.mono - mono
+		}
+	}
+	return now.Sub(t)
+}
+
+// Until returns the duration until t, according to Now.
+//
+// As with Since, this uses the monotonic clock once it has diverged from
+// the wall clock. A time in the future can't be placed unambiguously, so
+// it is assumed to be derived from a time returned since the wall clock
+// was last set.
+func (mt *ManualTime) Until(t time.Time) time.Duration {
+	now := mt.Now()
+
+	mt.Lock()
+	defer mt.Unlock()
+
+	if len(mt.epochs) > 1 {
+		if mono, known := mt.monotonicOf(t, true); known {
+			return mono - mt.mono
+		}
+	}
+	return t.Sub(now)
+}
+
+// A wallEpoch is a stretch of time over which the wall and monotonic
+// clocks have moved together, starting from the given readings.
+type wallEpoch struct {
+	wall time.Time
+	mono time.Duration
+}
+
+// newEpoch starts a new wallEpoch at the current readings, after the wall
+// and monotonic clocks have moved independently. The lock must be held.
+func (mt *ManualTime) newEpoch() {
+	mt.now = mt.now.Round(0)
+	mt.epochs = append(mt.epochs, wallEpoch{mt.now, mt.mono})
+	mt.publishNow()
+}
+
+// monotonicOf works out the monotonic reading a time returned by Now
+// would have carried, by finding the most recent epoch it fits in. A time
+// at the very start of an epoch could equally have come from the end of
+// the previous one; the more recent is assumed. If future is true, t may
+// lie beyond the current reading, as for a deadline. The lock must be
+// held.
+func (mt *ManualTime) monotonicOf(t time.Time, future bool) (time.Duration, bool) {
+	for i := len(mt.epochs) - 1; i >= 0; i-- {
+		epoch := mt.epochs[i]
+		mono := epoch.mono + t.Round(0).Sub(epoch.wall.Round(0))
+		if mono < epoch.mono {
+			continue
+		}
+		if i == len(mt.epochs)-1 && future {
+			return mono, true
+		}
+		end := mt.mono
+		if i+1 < len(mt.epochs) {
+			end = mt.epochs[i+1].mono
+		}
+		if mono <= end {
+			return mono, true
+		}
+	}
+	return 0, false
+}
+
+// SetWallClock sets the wall clock to the given time, without moving the
+// monotonic clock, as when an administrator or NTP steps the system
+// clock. Setting it backwards is not subject to the RewindPolicy, since
+// that is the point.
+//
+// A time.Time can only carry a monotonic reading taken from the real
+// runtime, so a ManualTime can't hand out times whose readings diverge
+// from their wall clocks. Instead, once the clocks have diverged, Now
+// returns times stripped of any monotonic reading, and Since and Until
+// work out the monotonic readings of the times Now returned themselves.
+// Code measuring elapsed time through the AbstractTime will therefore see
+// the monotonic clock, while comparing the times directly uses the wall
+// clock, which is the same distinction the real clock makes.
+func (mt *ManualTime) SetWallClock(t time.Time) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.now = t
+	mt.newEpoch()
+	mt.nowMoved()
+	mt.expireStale()
+}
+
+// AdvanceMonotonic advances the monotonic clock without moving the wall
+// clock. Advance moves both together.
+func (mt *ManualTime) AdvanceMonotonic(d time.Duration) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.mono += d
+	mt.newEpoch()
+}
+
+// Advance advances the manual time's idea of "now" by the given
+// duration.
+//
+// If there is a queue of "Nows" from QueueNows, note this won't
+// affect any of them.
+func (mt *ManualTime) Advance(d time.Duration) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.setNow(mt.now.Add(d))
+	mt.expireStale()
+}
+
+// AdvanceTo sets the manual time's idea of "now" to the given instant,
+// which is handy for tests built around calendar dates, such as billing
+// cycles. Moving to an earlier instant is subject to the RewindPolicy.
+//
+// As with Advance, this doesn't affect any queued Nows.
+func (mt *ManualTime) AdvanceTo(t time.Time) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.setNow(t)
+	mt.expireStale()
+}
+
+// RewindPolicy determines what a ManualTime does when asked to move Now
+// backwards. See SetRewindPolicy.
+type RewindPolicy int
+
+const (
+	// AllowRewind lets Now move backwards. This is the default.
+	AllowRewind RewindPolicy = iota
+
+	// PanicOnRewind panics with an error wrapping ErrRewind.
+	PanicOnRewind
+
+	// ReportRewind passes an error wrapping ErrRewind to the handler
+	// given to SetRewindPolicy, and leaves Now where it was.
+	ReportRewind
+)
+
+// ErrRewind is the error reported when Now would move backwards under a
+// RewindPolicy that forbids it.
+var ErrRewind = errors.New("time moved backwards")
+
+// SetRewindPolicy sets what happens when Now would move backwards, whether
+// by Advance with a negative duration, by AdvanceTo an earlier instant, or
+// by consuming a queued Now earlier than the current one. Forbidding it
+// keeps tests from silently creating non-monotonic sequences of times.
 //
-// If there is a queue of "Nows" from QueueNows, note this won't
-// affect any of them.
-func (mt *ManualTime) Advance(d time.Duration) {
+// onError is only used by ReportRewind. It is called with the ManualTime
+// locked, so it must not call back into it; t.Error is a typical choice.
+// If it is nil, ReportRewind panics just as PanicOnRewind does.
+func (mt *ManualTime) SetRewindPolicy(policy RewindPolicy, onError func(error)) {
 	mt.Lock()
 	defer mt.Unlock()
 
-	mt.now = mt.now.Add(d)
+	mt.rewindPolicy = policy
+	mt.onRewind = onError
+}
+
+// setNow moves now to t, subject to the rewind policy. The lock must be
+// held.
+func (mt *ManualTime) setNow(t time.Time) {
+	if mt.location != nil {
+		t = t.In(mt.location)
+	}
+	if t.Before(mt.now) && mt.rewindPolicy != AllowRewind {
+		err := fmt.Errorf("abtime: moving from %v back to %v: %w", mt.now, t, ErrRewind)
+		if mt.rewindPolicy == PanicOnRewind || mt.onRewind == nil {
+			panic(err)
+		}
+		mt.onRewind(err)
+		return
+	}
+	if t.Before(mt.now) {
+		mt.now = t
+		mt.newEpoch()
+	} else {
+		mt.mono += t.Round(0).Sub(mt.now.Round(0))
+		mt.now = t
+	}
+	mt.nowMoved()
 }
 
 // QueueNows allows you to set a number of times to be retrieved by
@@ -171,50 +1466,191 @@ func (mt *ManualTime) QueueNows(times ...time.Time) {
 	defer mt.Unlock()
 
 	mt.nows = append(mt.nows, times...)
+	mt.publishNow()
 }
 
 type afterTrigger struct {
-	mt *ManualTime
+	registration
 	d  time.Duration
 	ch chan time.Time
+
+	// fired is guarded by the ManualTime's lock, so a Restore does not
+	// bring back an After that has already gone off
+	fired bool
+
+	closeOnce sync.Once
 }
 
-func (afterT afterTrigger) trigger(mt *ManualTime) bool {
-	go func() { afterT.ch <- afterT.mt.now.Add(afterT.d) }()
+func (afterT *afterTrigger) trigger(mt *ManualTime) bool {
+	afterT.fired = true
+	fired := mt.fireTime(mt.now.Add(afterT.d))
+	if mt.buffer(afterT.id, afterT.ch, fired) {
+		return true
+	}
+	mt.deliver(afterT.id, func() {
+		if !mt.send(afterT.ch, fired) {
+			afterT.close(mt)
+		}
+	})
 	return true
 }
 
+func (afterT *afterTrigger) close(mt *ManualTime) {
+	afterT.closeOnce.Do(func() { close(afterT.ch) })
+}
+
+func (afterT *afterTrigger) live() bool {
+	return !afterT.fired
+}
+
 // After wraps time.After, and waits for the target id.
-func (mt *ManualTime) After(d time.Duration, id int) <-chan time.Time {
-	timeChan := make(chan time.Time)
-	trigger := afterTrigger{mt, d, timeChan}
-	mt.register(id, trigger)
+func (mt *ManualTime) After(d time.Duration, id ID) <-chan time.Time {
+	mt.Lock()
+	d = mt.faultDuration(id, d)
+	timeChan := mt.timeChan(false)
+	mt.Unlock()
+	trigger := &afterTrigger{d: d, ch: timeChan}
+	mt.registerTimed(id, trigger, d)
 	return timeChan
 }
 
 type sleepTrigger struct {
+	registration
+	d time.Duration
 	c chan struct{}
+
+	// for SleepContext, the context's Done channel; nil otherwise
+	done <-chan struct{}
+
+	// woken is guarded by the ManualTime's lock, as afterTrigger.fired
+	woken bool
 }
 
-func (st sleepTrigger) trigger(mt *ManualTime) bool {
-	go func() { st.c <- struct{}{} }()
+func (st *sleepTrigger) trigger(mt *ManualTime) bool {
+	st.woken = true
+	if cap(st.c) > 0 {
+		select {
+		case st.c <- struct{}{}:
+			mt.buffered(st.id)
+			return true
+		default:
+		}
+	}
+
+	// the sleeper may give up on its context, or be woken by Close,
+	// before receiving; a nil done never is
+	mt.deliveryInfo(st.id).delivered++
+	go func() {
+		select {
+		case st.c <- struct{}{}:
+			mt.acknowledge(st.id)
+		case <-st.done:
+			mt.retract(st.id, 1)
+		case <-mt.closing:
+			mt.retract(st.id, 1)
+		}
+	}()
 	return true
 }
 
-// Sleep halts execution until you release it via Trigger.
-func (mt *ManualTime) Sleep(d time.Duration, id int) {
-	ch := make(chan struct{})
+// close does nothing; sleepers wait on the ManualTime's closing as well.
+func (st *sleepTrigger) close(mt *ManualTime) {}
+
+func (st *sleepTrigger) live() bool {
+	if st.woken {
+		return false
+	}
+	select {
+	case <-st.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Sleep halts execution until you release it via Trigger, or the
+// ManualTime is closed.
+func (mt *ManualTime) Sleep(d time.Duration, id ID) {
+	_ = mt.SleepErr(d, id)
+}
+
+// SleepErr sleeps just as Sleep does, but returns ErrClosed if it was
+// woken by Close rather than by a Trigger, so loops can tell that they
+// should give up.
+func (mt *ManualTime) SleepErr(d time.Duration, id ID) error {
+	d = mt.faulted(id, d)
+	ch := mt.signalChan()
+
+	mt.registerTimed(id, &sleepTrigger{d: d, c: ch}, d)
+
+	select {
+	case <-ch:
+		return nil
+	case <-mt.closing:
+		return ErrClosed
+	}
+}
 
-	mt.register(id, sleepTrigger{ch})
+// SleepContext sleeps until the given ID is triggered, just as Sleep
+// does, unless the context is done first, in which case it returns the
+// context's error. A sleep abandoned this way is no longer live, so it
+// will not consume a later Trigger. If the ManualTime is closed, it
+// returns ErrClosed.
+func (mt *ManualTime) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d = mt.faulted(id, d)
+	ch := mt.signalChan()
+
+	mt.registerTimed(id, &sleepTrigger{d: d, c: ch, done: ctx.Done()}, d)
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-mt.closing:
+		return ErrClosed
+	}
+}
 
-	<-ch
+// A RecordingTicker is a Ticker that records the virtual times of every
+// tick it has delivered, and the intervals it has been Reset to. The
+// Tickers returned by ManualTime implement it.
+type RecordingTicker interface {
+	Ticker
+	Ticks() []time.Time
+	Resets() []time.Duration
 }
 
 type tickTrigger struct {
+	registration
+	mt      *ManualTime
 	C       chan time.Time
 	now     time.Time
 	d       time.Duration
 	stopped bool
+	ticks   []time.Time
+	resets  []time.Duration
+
+	// backlog is the most undelivered ticks to hold, or 0 for no limit.
+	// pending holds them, not including the one currently being sent,
+	// if any, nor those sitting in C's buffer.
+	backlog  int
+	pending  []time.Time
+	sending  bool
+	inFlight bool
+
+	// Under Go123Timers, closed to discard the tick being sent.
+	go123 bool
+	flush chan struct{}
+
+	// set once the ManualTime is closed, and once C has been closed in
+	// turn
+	closing bool
+	closedC bool
+
 	sync.Mutex
 }
 
@@ -227,63 +1663,297 @@ func (tt *tickTrigger) trigger(mt *ManualTime) bool {
 	}
 
 	tt.now = tt.now.Add(tt.d)
-	go func() { tt.C <- tt.now }()
+	undelivered := len(tt.pending) + len(tt.C)
+	if tt.inFlight {
+		undelivered++
+	}
+	if tt.backlog > 0 && undelivered >= tt.backlog {
+		// like time.Ticker, drop ticks for slow receivers
+		return false
+	}
+
+	tick := mt.fireTime(tt.now)
+	tt.ticks = append(tt.ticks, tick)
+	// a tick may only skip the sending goroutine if none are queued
+	// ahead of it
+	if !tt.sending && mt.buffer(tt.id, tt.C, tick) {
+		return false
+	}
+	tt.pending = append(tt.pending, tick)
+	mt.deliveryInfo(tt.id).delivered++
+	if !tt.sending {
+		tt.sending = true
+		go tt.send(mt)
+	}
 	return false
 }
 
-func (tt *tickTrigger) Stop() {
+// send delivers the pending ticks in order, until there are none left.
+func (tt *tickTrigger) send(mt *ManualTime) {
+	for {
+		tt.Lock()
+		if len(tt.pending) == 0 {
+			tt.sending = false
+			tt.closeC()
+			tt.Unlock()
+			return
+		}
+		next := tt.pending[0]
+		tt.pending = tt.pending[1:]
+		tt.inFlight = true
+		flush := tt.flush
+		tt.Unlock()
+
+		select {
+		case tt.C <- next:
+			mt.acknowledge(tt.id)
+		case <-flush:
+			mt.retract(tt.id, 1)
+		case <-mt.closing:
+			tt.Lock()
+			tt.sending = false
+			tt.inFlight = false
+			tt.pending = nil
+			tt.closeC()
+			tt.Unlock()
+			return
+		}
+
+		tt.Lock()
+		tt.inFlight = false
+		tt.Unlock()
+	}
+}
+
+// discard drops any undelivered ticks, under Go123Timers.
+func (tt *tickTrigger) discard() {
+	if !tt.go123 {
+		return
+	}
+
+	tt.mt.Lock()
+	defer tt.mt.Unlock()
+	tt.Lock()
+	defer tt.Unlock()
+
+	tt.mt.deliveryInfo(tt.id).delivered -= len(tt.pending)
+	tt.pending = nil
+	close(tt.flush)
+	tt.flush = make(chan struct{})
+	tt.mt.consumed.Broadcast()
+}
+
+func (tt *tickTrigger) live() bool {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return !tt.stopped
+}
+
+func (tt *tickTrigger) close(mt *ManualTime) {
 	tt.Lock()
 	defer tt.Unlock()
 
 	tt.stopped = true
+	tt.closing = true
+	if !tt.sending {
+		tt.closeC()
+	}
+}
+
+// closeC closes the channel, if the ManualTime has been closed and it
+// hasn't been already. The lock must be held.
+func (tt *tickTrigger) closeC() {
+	if tt.closing && !tt.closedC {
+		close(tt.C)
+		tt.closedC = true
+	}
+}
+
+func (tt *tickTrigger) Stop() {
+	tt.discard()
+
+	tt.Lock()
+	wasLive := !tt.stopped
+	tt.stopped = true
+	tt.Unlock()
+
+	if wasLive {
+		tt.mt.recordStop(tt)
+	}
 }
 
 func (tt *tickTrigger) Channel() <-chan time.Time {
 	return tt.C
 }
 
-func (tt *tickTrigger) Reset(time.Duration) {}
+// Reset changes the ticker's interval, restarting its period from the
+// current Now, so the next tick delivers Now plus the new interval. As
+// with time.Ticker, a stopped ticker is restarted, queued behind any
+// other registrations under its ID, and a non-positive interval panics.
+// The intervals it has been reset to are available from Resets.
+func (tt *tickTrigger) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("abtime: non-positive interval for Ticker.Reset")
+	}
+	tt.discard()
+
+	mt := tt.mt
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.closed {
+		return
+	}
+	tt.Lock()
+	tt.d = mt.faultDuration(tt.id, d)
+	tt.now = mt.now
+	tt.resets = append(tt.resets, d)
+	tt.stopped = false
+	tt.Unlock()
+
+	ti := mt.triggerInfo(tt.id)
+	for _, queued := range ti.triggers {
+		if queued == trigger(tt) {
+			return
+		}
+	}
+	tt.created = mt.now
+	mt.seq++
+	tt.seq = mt.seq
+	ti.triggers = append(ti.triggers, tt)
+	ti.fire(mt)
+}
+
+// Resets returns the intervals the ticker has been Reset to, in order.
+func (tt *tickTrigger) Resets() []time.Duration {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return append([]time.Duration(nil), tt.resets...)
+}
+
+// Ticks returns the times this ticker has delivered, in the order it
+// delivered them.
+func (tt *tickTrigger) Ticks() []time.Time {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return append([]time.Time(nil), tt.ticks...)
+}
 
 // NewTicker wraps time.NewTicker. It takes a snapshot of "now" at the
 // point of the TickToken call, and will increment the time it returns
 // by the Duration of the tick.
 //
-// Note that this can cause times to arrive out of order relative to
-// each other if you have many of these going at once, if you manually
-// trigger the ticks in such a way that they will be out of order.
-func (mt *ManualTime) NewTicker(d time.Duration, id int) Ticker {
-	ch := make(chan time.Time)
-	tt := &tickTrigger{C: ch, now: mt.now, d: d}
+// Each ticker delivers its ticks in order. Note that this can cause
+// times to arrive out of order relative to each other if you have many of
+// these going at once, if you manually trigger the ticks in such a way
+// that they will be out of order.
+//
+// By default, every Trigger results in a tick, no matter how far behind
+// the receiver is; see SetTickerBacklog.
+//
+// The returned Ticker is a RecordingTicker, so tests can examine the
+// exact sequence of ticks it delivered.
+//
+// As with time.NewTicker, a non-positive interval panics.
+func (mt *ManualTime) NewTicker(d time.Duration, id ID) Ticker {
+	if d <= 0 {
+		panic("abtime: non-positive interval for NewTicker")
+	}
+	mt.Lock()
+	d = mt.faultDuration(id, d)
+	tt := &tickTrigger{
+		mt:      mt,
+		C:       mt.timeChan(mt.timerSemantics == Go123Timers),
+		now:     mt.now,
+		d:       d,
+		backlog: mt.tickerBacklog,
+		go123:   mt.timerSemantics == Go123Timers,
+		flush:   make(chan struct{}),
+	}
+	mt.Unlock()
 	mt.register(id, tt)
 	return tt
 }
 
-// Tick allows you to create a ticker. See notes on NewTicker.
-func (mt *ManualTime) Tick(d time.Duration, id int) <-chan time.Time {
+// SetTickerBacklog sets the most undelivered ticks a ticker created after
+// this call will hold for a slow receiver. Triggers arriving while the
+// backlog is full are coalesced away, with the ticker's time still
+// advancing, as time.Ticker drops ticks for slow receivers. A backlog of 1
+// matches time.Ticker exactly. The default of 0 means no limit, so that
+// every Trigger is eventually delivered. Ticks sitting in a channel
+// buffered by the DeliveryPolicy count as undelivered.
+func (mt *ManualTime) SetTickerBacklog(backlog int) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.tickerBacklog = backlog
+}
+
+// Tick allows you to create a ticker. See notes on NewTicker. As with
+// time.Tick, a non-positive interval returns nil rather than panicking.
+func (mt *ManualTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
 	return mt.NewTicker(d, id).(*tickTrigger).C
 }
 
 type afterFuncTrigger struct {
+	registration
+	mt      *ManualTime
+	d       time.Duration
 	f       func()
 	stopped bool
 	sync.Mutex
 }
 
+// Reset re-arms the AfterFunc for the duration from the current Now, as
+// with time.Timer, returning whether it was still armed. If it had
+// already run or been stopped, it is queued again behind any other
+// registrations under its ID, and the next Trigger runs the function
+// again.
 func (af *afterFuncTrigger) Reset(d time.Duration) bool {
-	af.Lock()
-	defer af.Unlock()
+	mt := af.mt
+	mt.Lock()
+	defer mt.Unlock()
 
-	ret := af.stopped
+	if mt.closed {
+		return false
+	}
+	af.Lock()
+	ret := !af.stopped
+	af.d = mt.faultDuration(af.id, d)
 	af.stopped = false
+	defer mt.fireIfImmediate(af, af.d)
+	af.Unlock()
+
+	ti := mt.triggerInfo(af.id)
+	for _, queued := range ti.triggers {
+		if queued == trigger(af) {
+			af.created = mt.now
+			return ret
+		}
+	}
+	af.created = mt.now
+	mt.seq++
+	af.seq = mt.seq
+	ti.triggers = append(ti.triggers, af)
+	ti.fire(mt)
 	return ret
 }
 
 func (af *afterFuncTrigger) Stop() bool {
 	af.Lock()
-	defer af.Unlock()
-
 	ret := !af.stopped
 	af.stopped = true
+	af.Unlock()
+
+	if ret {
+		af.mt.recordStop(af)
+	}
 	return ret
 }
 
@@ -296,46 +1966,115 @@ func (af *afterFuncTrigger) trigger(mt *ManualTime) bool {
 	defer af.Unlock()
 
 	if !af.stopped {
-		go af.f()
+		mt.deliver(af.id, af.f)
 	}
 	af.stopped = true
 
 	return true
 }
 
+func (af *afterFuncTrigger) live() bool {
+	af.Lock()
+	defer af.Unlock()
+
+	return !af.stopped
+}
+
+// close simply drops the function; it never runs.
+func (af *afterFuncTrigger) close(mt *ManualTime) {
+	af.Lock()
+	defer af.Unlock()
+
+	af.stopped = true
+}
+
 // AfterFunc fires the function in its own goroutine when the id is
 // .Trigger()ed. The resulting Timer object will return nil for its Channel().
-func (mt *ManualTime) AfterFunc(d time.Duration, f func(), id int) Timer {
-	af := &afterFuncTrigger{f: f, stopped: false}
-	mt.register(id, af)
+func (mt *ManualTime) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	d = mt.faulted(id, d)
+	af := &afterFuncTrigger{mt: mt, d: d, f: f, stopped: false}
+	mt.registerTimed(id, af, d)
 	return af
 }
 
 type timerTrigger struct {
+	registration
+	mt         *ManualTime
 	c          chan time.Time
 	initialNow time.Time
 	duration   time.Duration
 	stopped    bool
+
+	// Under Go123Timers, a delivery still waiting to be received can be
+	// canceled by closing cancel; sent reports whether it was received.
+	go123  bool
+	cancel chan struct{}
+	sent   chan bool
+
+	// deliveries still being sent, which must finish before c can be
+	// closed when the ManualTime is
+	senders int
+	closing bool
+	closedC bool
+
 	sync.Mutex
 }
 
+// cancelDelivery cancels any delivery still waiting to be received, under
+// Go123Timers, returning whether there was one. The lock must be held.
+func (tt *timerTrigger) cancelDelivery() bool {
+	if tt.cancel == nil {
+		return false
+	}
+	close(tt.cancel)
+	sent := <-tt.sent
+	tt.cancel = nil
+	return !sent
+}
+
+// Reset changes the timer's duration and re-arms it. If it had already
+// fired or been stopped, it is queued behind any other registrations
+// under its ID.
 func (tt *timerTrigger) Reset(d time.Duration) bool {
-	tt.Lock()
-	defer tt.Unlock()
+	mt := tt.mt
+	mt.Lock()
+	defer mt.Unlock()
 
-	tt.duration = d
-	ret := !tt.stopped
+	if mt.closed {
+		return false
+	}
+	tt.Lock()
+	ret := tt.cancelDelivery() || !tt.stopped
+	tt.initialNow = mt.now
+	tt.duration = mt.faultDuration(tt.id, d)
 	tt.stopped = false
+	defer mt.fireIfImmediate(tt, tt.duration)
+	tt.Unlock()
+
+	ti := mt.triggerInfo(tt.id)
+	for _, queued := range ti.triggers {
+		if queued == trigger(tt) {
+			return ret
+		}
+	}
+	tt.created = mt.now
+	mt.seq++
+	tt.seq = mt.seq
+	ti.triggers = append(ti.triggers, tt)
+	ti.fire(mt)
 	return ret
 }
 
 func (tt *timerTrigger) Stop() bool {
 	tt.Lock()
-	defer tt.Unlock()
-
-	ret := tt.stopped
+	ret := tt.cancelDelivery() || !tt.stopped
 	tt.stopped = true
-	return !ret
+	tt.Unlock()
+
+	if ret {
+		tt.mt.recordStop(tt)
+	}
+	return ret
 }
 
 func (tt *timerTrigger) Channel() <-chan time.Time {
@@ -349,29 +2088,140 @@ func (tt *timerTrigger) trigger(mt *ManualTime) bool {
 		return true
 	}
 	tt.stopped = true
+	fired := mt.fireTime(tt.initialNow.Add(tt.duration))
+	if mt.buffer(tt.id, tt.c, fired) {
+		tt.Unlock()
+		return true
+	}
+	tt.senders++
+	if !tt.go123 {
+		tt.Unlock()
+		mt.deliver(tt.id, func() {
+			tt.sendDone(!mt.send(tt.c, fired))
+		})
+		return true
+	}
+
+	cancel := make(chan struct{})
+	sent := make(chan bool, 1)
+	tt.cancel = cancel
+	tt.sent = sent
 	tt.Unlock()
-	go func() { tt.c <- tt.initialNow.Add(tt.duration) }()
+	mt.deliveryInfo(tt.id).delivered++
+	go func() {
+		aborted := false
+		select {
+		case tt.c <- fired:
+			sent <- true
+			mt.acknowledge(tt.id)
+		case <-cancel:
+			sent <- false
+			mt.retract(tt.id, 1)
+		case <-mt.closing:
+			aborted = true
+			sent <- false
+			mt.retract(tt.id, 1)
+		}
+		tt.sendDone(aborted)
+	}()
 	return true
 }
 
+// sendDone records that a delivery is no longer being sent. If it was
+// aborted by Close, the receiver must be woken by closing the channel
+// instead, whether or not the timer was still queued.
+func (tt *timerTrigger) sendDone(aborted bool) {
+	tt.Lock()
+	defer tt.Unlock()
+
+	tt.senders--
+	if aborted {
+		tt.closing = true
+	}
+	tt.closeC()
+}
+
+// closeC closes the channel, if the ManualTime has been closed, nothing
+// is being sent, and it hasn't been already. The lock must be held.
+func (tt *timerTrigger) closeC() {
+	if tt.closing && tt.senders == 0 && !tt.closedC {
+		close(tt.c)
+		tt.closedC = true
+	}
+}
+
+func (tt *timerTrigger) live() bool {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return !tt.stopped
+}
+
+func (tt *timerTrigger) close(mt *ManualTime) {
+	tt.Lock()
+	defer tt.Unlock()
+
+	tt.stopped = true
+	tt.closing = true
+	tt.closeC()
+}
+
 // NewTimer allows you to create a Ticker, which can be triggered
 // via the given id, and also supports the Stop operation *time.Tickers have.
-func (mt *ManualTime) NewTimer(d time.Duration, id int) Timer {
-	tt := &timerTrigger{c: make(chan time.Time), initialNow: mt.now, duration: d}
-	mt.register(id, tt)
+func (mt *ManualTime) NewTimer(d time.Duration, id ID) Timer {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.newTimer(d, id)
+}
+
+// NewTimerAt creates a Timer just as NewTimer does, for the duration
+// between the current Now and t. When triggered it sends t.
+func (mt *ManualTime) NewTimerAt(t time.Time, id ID) Timer {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.newTimer(t.Sub(mt.now), id)
+}
+
+// newTimer creates and registers a Timer. The lock must be held.
+func (mt *ManualTime) newTimer(d time.Duration, id ID) Timer {
+	d = mt.faultDuration(id, d)
+	tt := &timerTrigger{
+		mt:         mt,
+		c:          mt.timeChan(mt.timerSemantics == Go123Timers),
+		initialNow: mt.now,
+		duration:   d,
+		go123:      mt.timerSemantics == Go123Timers,
+	}
+	mt.registerTimedLocked(id, tt, d)
 	return tt
 }
 
 type contextTrigger struct {
+	registration
+
+	// a real cancelable context under the parent, canceled along with
+	// this one so that context.Cause finds the cause
 	context.Context
-	deadline time.Time
-	closed   bool
-	done     chan struct{}
-	err      error
-	mu       sync.Mutex
+	release context.CancelCauseFunc
+
+	deadline    time.Time
+	hasDeadline bool
+
+	// the cause reported once the deadline is exceeded, if any
+	cause error
+
+	closed bool
+	done   chan struct{}
+	err    error
+	mu     sync.Mutex
 }
 
 func (ct *contextTrigger) Deadline() (time.Time, bool) {
+	if !ct.hasDeadline {
+		return ct.Context.Deadline()
+	}
 	return ct.deadline, true
 }
 
@@ -390,32 +2240,112 @@ func (ct *contextTrigger) Value(key interface{}) interface{} {
 }
 
 func (ct *contextTrigger) cancel(err error) {
+	ct.cancelWithCause(err, err)
+}
+
+// cancelWithCause cancels the context with the given error, reporting the
+// given cause via context.Cause.
+func (ct *contextTrigger) cancelWithCause(err, cause error) {
 	ct.mu.Lock()
 	defer ct.mu.Unlock()
 	if !ct.closed {
+		ct.release(cause)
 		close(ct.done)
 		ct.closed = true
 		ct.err = err
 	}
 }
 
-func (ct *contextTrigger) trigger(_ *ManualTime) bool {
-	ct.cancel(context.DeadlineExceeded)
+func (ct *contextTrigger) trigger(mt *ManualTime) bool {
+	if ct.hasDeadline {
+		cause := ct.cause
+		if cause == nil {
+			cause = context.DeadlineExceeded
+		}
+		ct.cancelWithCause(context.DeadlineExceeded, cause)
+	} else {
+		ct.cancel(context.Canceled)
+	}
+	mt.deliver(ct.id, func() {})
 	return true
 }
 
+func (ct *contextTrigger) live() bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	return !ct.closed
+}
+
+func (ct *contextTrigger) close(mt *ManualTime) {
+	ct.cancel(context.Canceled)
+}
+
 // WithDeadline is a valid Context that is meant to drop in over a regular
 // context.WithDeadline invocation. Instead of being canceled when reaching an
 // actual deadline the context is canceled either by Trigger or by the returned
 // CancelFunc.
-func (mt *ManualTime) WithDeadline(parent context.Context, deadline time.Time, id int) (context.Context, context.CancelFunc) {
+func (mt *ManualTime) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	return mt.newContext(parent, deadline, true, nil, id)
+}
+
+// WithDeadlineCause is like WithDeadline, except that when the context is
+// triggered, context.Cause reports the given cause rather than
+// context.DeadlineExceeded. Its Err is still context.DeadlineExceeded.
+func (mt *ManualTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	return mt.newContext(parent, deadline, true, cause, id)
+}
+
+// WithDeadlineAuto is like WithDeadline, except that the context is also
+// canceled with context.DeadlineExceeded as soon as the ManualTime's Now
+// reaches the deadline, whether by Advance, by consuming QueueNows, or by
+// auto-advance. This is how most people expect a manual clock to interact
+// with contexts, at the cost of the test no longer controlling exactly
+// when the cancellation happens. Triggering and the CancelFunc still work
+// as they do for WithDeadline.
+func (mt *ManualTime) WithDeadlineAuto(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := mt.newContext(parent, deadline, true, nil, id)
+
+	mt.Lock()
+	mt.deadlines = append(mt.deadlines, ctx.(*contextTrigger))
+	mt.expireDeadlines()
+	mt.Unlock()
+
+	return ctx, cancel
+}
+
+// WithTimeoutAuto is equivalent to WithDeadlineAuto invoked on a deadline
+// equal to the current time plus the timeout.
+func (mt *ManualTime) WithTimeoutAuto(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	return mt.WithDeadlineAuto(parent, mt.Now().Add(timeout), id)
+}
+
+// WithCancel is meant to drop in over a regular context.WithCancel
+// invocation. The context is canceled either by the returned CancelFunc,
+// or by Trigger, which cancels it with context.Canceled just as if the
+// CancelFunc had been called.
+func (mt *ManualTime) WithCancel(parent context.Context, id ID) (context.Context, context.CancelFunc) {
+	return mt.newContext(parent, time.Time{}, false, nil, id)
+}
+
+func (mt *ManualTime) newContext(parent context.Context, deadline time.Time, hasDeadline bool, cause error, id ID) (context.Context, context.CancelFunc) {
 	if parent == nil {
 		panic("cannot create context from nil parent")
 	}
+	if hasDeadline {
+		mt.Lock()
+		timeout := deadline.Sub(mt.now)
+		deadline = deadline.Add(mt.faultDuration(id, timeout) - timeout)
+		mt.Unlock()
+	}
+	inner, release := context.WithCancelCause(parent)
 	ct := &contextTrigger{
-		Context:  parent,
-		deadline: deadline,
-		done:     make(chan struct{}),
+		Context:     inner,
+		release:     release,
+		deadline:    deadline,
+		hasDeadline: hasDeadline,
+		cause:       cause,
+		done:        make(chan struct{}),
 	}
 	cancelF := func() {
 		ct.cancel(context.Canceled)
@@ -424,7 +2354,7 @@ func (mt *ManualTime) WithDeadline(parent context.Context, deadline time.Time, i
 	go func() {
 		select {
 		case <-parent.Done():
-			ct.cancel(parent.Err())
+			ct.cancelWithCause(parent.Err(), context.Cause(parent))
 		case <-ct.Done():
 			// do nothing
 		}
@@ -434,6 +2364,12 @@ func (mt *ManualTime) WithDeadline(parent context.Context, deadline time.Time, i
 
 // WithTimeout is equivalent to WithDeadline invoked on a deadline equal to the
 // current time plus the timeout.
-func (mt *ManualTime) WithTimeout(parent context.Context, timeout time.Duration, id int) (context.Context, context.CancelFunc) {
+func (mt *ManualTime) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
 	return mt.WithDeadline(parent, mt.Now().Add(timeout), id)
 }
+
+// WithTimeoutCause is equivalent to WithDeadlineCause invoked on a
+// deadline equal to the current time plus the timeout.
+func (mt *ManualTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	return mt.WithDeadlineCause(parent, mt.Now().Add(timeout), cause, id)
+}
@@ -3,6 +3,8 @@ package abtime
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -148,14 +150,20 @@ func TestAfterFunc(t *testing.T) {
 		t.Fatal("Channel on AfterFunc not working properly.")
 	}
 
-	// not that this really means much here
-	if timer.Reset(time.Second * 2) {
-		t.Fatal("Reset should not be returning true here")
+	if !timer.Reset(time.Second * 2) {
+		t.Fatal("Reset of an armed AfterFunc should return true")
 	}
 	at.Trigger(afterFuncID)
 
 	<-funcRun
 
+	// Reset re-arms the function after it has run
+	if timer.Reset(time.Second) {
+		t.Fatal("Reset of a run AfterFunc should return false")
+	}
+	at.Trigger(afterFuncID)
+	<-funcRun
+
 	timer2 := at.AfterFunc(time.Second, func() {
 		panic("I should never be run!")
 	}, afterFuncID+1)
@@ -164,8 +172,8 @@ func TestAfterFunc(t *testing.T) {
 		t.Fatal("Stop should not return true like this")
 	}
 	at.Trigger(afterFuncID + 1)
-	if timer2.Stop() || !timer2.Reset(time.Second*3) {
-		t.Fatal("Stop/Reset should be returning true here")
+	if timer2.Stop() {
+		t.Fatal("Stop should be returning false here")
 	}
 }
 
@@ -225,6 +233,17 @@ func TestInterfaceConformance(t *testing.T) {
 	at = NewManual()
 	at = NewRealTime()
 	at.Now()
+
+	// and the narrow interfaces can be declared on their own
+	var (
+		_ Clock          = at
+		_ ReadOnlyTime   = at
+		_ Sleeper        = at
+		_ AfterSource    = at
+		_ TimerFactory   = at
+		_ TickerFactory  = at
+		_ ContextFactory = at
+	)
 }
 
 func TestNowQueueing(t *testing.T) {
@@ -244,6 +263,36 @@ func TestNowQueueing(t *testing.T) {
 	}
 }
 
+func TestConcurrentNow(t *testing.T) {
+	start := time.Now()
+	mt := NewManualAtTime(start)
+
+	// reading the clock doesn't need the lock...
+	mt.Lock()
+	read := make(chan time.Time)
+	for i := 0; i < 100; i++ {
+		go func() { read <- mt.Now() }()
+	}
+	for i := 0; i < 100; i++ {
+		if now := <-read; !now.Equal(start) {
+			t.Fatalf("unexpected now %v", now)
+		}
+	}
+	mt.Unlock()
+
+	// ...but sees every move, and still consumes queued Nows
+	mt.Advance(time.Second)
+	if !mt.Now().Equal(start.Add(time.Second)) {
+		t.Fatal("Now didn't see the advance")
+	}
+	mt.QueueNows(start.Add(time.Minute), start.Add(time.Hour))
+	if !mt.Now().Equal(start.Add(time.Minute)) ||
+		!mt.Now().Equal(start.Add(time.Hour)) ||
+		!mt.Now().Equal(start.Add(time.Hour)) {
+		t.Fatal("queued Nows not consumed")
+	}
+}
+
 func TestTimerReset(t *testing.T) {
 	c := NewManual()
 	d := time.Hour
@@ -424,3 +473,994 @@ func TestContextNestedTimeout(t *testing.T) {
 		t.Fatal("context error is not context.DeadlineExceeded")
 	}
 }
+
+func TestRegistrationQueue(t *testing.T) {
+	at := NewManual()
+
+	// A loop creating a new timer under the same ID each time should be
+	// drivable one Trigger per iteration.
+	for i := 0; i < 3; i++ {
+		timer := at.NewTimer(time.Second, timerID)
+		go at.Trigger(timerID)
+		<-timer.Channel()
+	}
+
+	// Stopped timers are skipped in favor of the next live registration.
+	stopped := at.NewTimer(time.Second, timerID)
+	live := at.NewTimer(time.Second, timerID)
+	stopped.Stop()
+	at.Trigger(timerID)
+	<-live.Channel()
+
+	// Registrations are consumed oldest first.
+	first := at.After(time.Second, afterID)
+	second := at.After(2*time.Second, afterID)
+	at.Trigger(afterID)
+	select {
+	case <-second:
+		t.Fatal("second registration fired before the first")
+	case <-first:
+	}
+	at.Trigger(afterID)
+	<-second
+
+	at.Lock()
+	remaining := len(at.triggers[timerID].triggers) + len(at.triggers[afterID].triggers)
+	at.Unlock()
+	if remaining != 0 {
+		t.Fatal("fired and stopped registrations were not removed")
+	}
+}
+
+func TestMaxRegistrations(t *testing.T) {
+	at := NewManual()
+	at.SetMaxRegistrations(2)
+	at.SetMaxRegistrations(1, afterID)
+
+	_ = at.NewTimer(time.Second, timerID)
+	stopped := at.NewTimer(time.Second, timerID)
+	stopped.Stop()
+	// stopped timers don't count against the limit
+	_ = at.NewTimer(time.Second, timerID)
+
+	expectPanic := func(f func()) {
+		defer func() {
+			err, isErr := recover().(error)
+			if !isErr || !errors.Is(err, ErrTooManyRegistrations) {
+				t.Fatal("expected to panic with ErrTooManyRegistrations")
+			}
+		}()
+		f()
+	}
+	expectPanic(func() { at.NewTimer(time.Second, timerID) })
+
+	_ = at.After(time.Second, afterID)
+	expectPanic(func() { at.After(time.Second, afterID) })
+}
+
+func TestTriggerN(t *testing.T) {
+	at := NewManual()
+	at.SetMaxCredit(3, tickID)
+
+	// credit held before the ticker exists
+	at.TriggerN(tickID, 3)
+	if at.PendingTriggers(tickID) != 3 {
+		t.Fatalf("expected 3 held triggers, got %d", at.PendingTriggers(tickID))
+	}
+	func() {
+		defer func() {
+			err, isErr := recover().(error)
+			if !isErr || !errors.Is(err, ErrTooMuchCredit) {
+				t.Fatal("expected to panic with ErrTooMuchCredit")
+			}
+		}()
+		at.Trigger(tickID)
+	}()
+	if at.PendingTriggers(tickID) != 3 {
+		t.Fatal("excess trigger was held")
+	}
+
+	ticker := at.NewTicker(time.Second, tickID)
+	for i := 0; i < 3; i++ {
+		<-ticker.Channel()
+	}
+	if at.PendingTriggers(tickID) != 0 {
+		t.Fatal("credit not consumed by the ticker")
+	}
+
+	// a live ticker consumes any number at once
+	go at.TriggerN(tickID, 5)
+	for i := 0; i < 5; i++ {
+		<-ticker.Channel()
+	}
+	ticker.Stop()
+
+	if at.PendingTriggers(afterID) != 0 {
+		t.Fatal("unused id has held triggers")
+	}
+}
+
+func TestDeliveryAcknowledgement(t *testing.T) {
+	at := NewManual()
+
+	ch := at.After(time.Second, afterID)
+	at.Trigger(afterID)
+	if at.Delivered(afterID) != 1 {
+		t.Fatal("trigger not recorded as delivered")
+	}
+	if at.Consumed(afterID) != 0 {
+		t.Fatal("delivery recorded as consumed before anything received it")
+	}
+	<-ch
+	at.WaitConsumed(afterID, 1)
+
+	ran := false
+	at.AfterFunc(time.Second, func() { ran = true }, afterFuncID)
+	at.Trigger(afterFuncID)
+	at.WaitConsumed(afterFuncID, 1)
+	if !ran {
+		t.Fatal("AfterFunc acknowledged before it ran")
+	}
+}
+
+type testIDType string
+
+func TestNonIntIDs(t *testing.T) {
+	at := NewManual()
+
+	ch := at.After(time.Second, "billing.retry-timeout")
+	go at.Trigger("billing.retry-timeout")
+	<-ch
+
+	// typed IDs with the same underlying value remain distinct
+	ch = at.After(time.Second, testIDType("billing.retry-timeout"))
+	at.Trigger("billing.retry-timeout")
+	go at.Trigger(testIDType("billing.retry-timeout"))
+	<-ch
+	if at.Delivered("billing.retry-timeout") != 1 {
+		t.Fatal("string and typed IDs were conflated")
+	}
+}
+
+func TestString(t *testing.T) {
+	at := NewManualAtTime(time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC))
+	at.NewTimer(time.Second, timerID)
+	at.Trigger(afterID)
+
+	expected := "ManualTime at 2012-03-28 12:00:00 +0000 UTC; 2 ids outstanding\n" +
+		"  id 0: 0 registered, 1 untriggered, 0 unconsumed\n" +
+		"  id 5: 1 registered, 0 untriggered, 0 unconsumed"
+	if at.String() != expected {
+		t.Fatalf("unexpected description: %q", at.String())
+	}
+}
+
+func TestTriggerAndWait(t *testing.T) {
+	at := NewManual()
+
+	woke := false
+	done := make(chan struct{})
+	go func() {
+		at.Sleep(time.Second, sleepID)
+		woke = true
+		close(done)
+	}()
+	// Sleep's registration may well not have happened yet; TriggerAndWait
+	// has to cope with that too.
+	at.TriggerAndWait(sleepID)
+	<-done
+	if !woke {
+		t.Fatal("sleeper did not wake")
+	}
+
+	ticker := at.NewTicker(time.Second, tickID)
+	go func() {
+		for range ticker.Channel() {
+		}
+	}()
+	at.TriggerAndWait(tickID, tickID)
+	if at.Consumed(tickID) != 2 {
+		t.Fatal("TriggerAndWait returned before the ticks were consumed")
+	}
+}
+
+func TestFireInOrder(t *testing.T) {
+	at := NewManual()
+
+	// one consumer selecting over both; if both had fired, it could
+	// pick either first
+	after := at.After(time.Second, afterID)
+	timer := at.NewTimer(time.Second, timerID)
+	observed := make(chan []ID)
+	go func() {
+		order := []ID{}
+		for len(order) < 4 {
+			select {
+			case <-after:
+				order = append(order, afterID)
+				after = at.After(time.Second, afterID)
+			case <-timer.Channel():
+				order = append(order, timerID)
+				timer.Reset(time.Second)
+			}
+		}
+		observed <- order
+	}()
+
+	at.FireInOrder(timerID, afterID, afterID, timerID)
+	order := <-observed
+	if order[0] != timerID || order[1] != afterID || order[2] != afterID || order[3] != timerID {
+		t.Fatalf("observed firings in order %v", order)
+	}
+}
+
+func TestSinceUntilTimerAt(t *testing.T) {
+	at := NewManual()
+	start := at.Now()
+	at.Advance(time.Minute)
+
+	if at.Since(start) != time.Minute {
+		t.Fatal("Since is not derived from Now")
+	}
+	if at.Until(start.Add(time.Hour)) != 59*time.Minute {
+		t.Fatal("Until is not derived from Now")
+	}
+
+	deadline := start.Add(time.Hour)
+	timer := at.NewTimerAt(deadline, timerID)
+	go at.Trigger(timerID)
+	if <-timer.Channel() != deadline {
+		t.Fatal("NewTimerAt did not deliver its deadline")
+	}
+}
+
+func TestTickerHistory(t *testing.T) {
+	testTime := time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC)
+	at := NewManualAtTime(testTime)
+
+	ticker := at.NewTicker(time.Minute, tickID).(RecordingTicker)
+	if len(ticker.Ticks()) != 0 {
+		t.Fatal("ticker has history before ticking")
+	}
+	at.Trigger(tickID)
+	<-ticker.Channel()
+	at.Trigger(tickID)
+	<-ticker.Channel()
+
+	ticks := ticker.Ticks()
+	if len(ticks) != 2 || ticks[0] != testTime.Add(time.Minute) ||
+		ticks[1] != testTime.Add(2*time.Minute) {
+		t.Fatalf("unexpected tick history: %v", ticks)
+	}
+}
+
+func TestContextWithCancel(t *testing.T) {
+	mt := NewManual()
+
+	ctx, cancelF := mt.WithCancel(context.Background(), contextID)
+	defer cancelF()
+
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		t.Fatal("WithCancel context claims a deadline")
+	}
+
+	mt.Trigger(contextID)
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatal("triggered WithCancel context is not context.Canceled")
+	}
+}
+
+func TestContextCause(t *testing.T) {
+	mt := NewManual()
+	errSlow := errors.New("too slow")
+
+	ctx, cancelF := mt.WithTimeoutCause(context.Background(), time.Minute, errSlow, contextID)
+	defer cancelF()
+	child, childCancel := context.WithCancel(ctx)
+	defer childCancel()
+
+	mt.Trigger(contextID)
+	<-ctx.Done()
+	if ctx.Err() != context.DeadlineExceeded || context.Cause(ctx) != errSlow {
+		t.Fatalf("unexpected error %v and cause %v", ctx.Err(), context.Cause(ctx))
+	}
+	<-child.Done()
+	if context.Cause(child) != errSlow {
+		t.Fatalf("child did not inherit the cause: %v", context.Cause(child))
+	}
+
+	// canceling reports context.Canceled as the cause, as in the context
+	// package
+	ctx, cancelF = mt.WithDeadlineCause(context.Background(), mt.Now(), errSlow, contextID)
+	cancelF()
+	if context.Cause(ctx) != context.Canceled {
+		t.Fatalf("unexpected cause %v", context.Cause(ctx))
+	}
+
+	// without a cause, the cause is the error
+	ctx, cancelF = mt.WithTimeout(context.Background(), time.Minute, contextID)
+	defer cancelF()
+	mt.Trigger(contextID)
+	if context.Cause(ctx) != context.DeadlineExceeded {
+		t.Fatalf("unexpected cause %v", context.Cause(ctx))
+	}
+
+	// and a parent's cause passes through
+	parent, parentCancel := context.WithCancelCause(context.Background())
+	ctx, cancelF = mt.WithTimeoutCause(parent, time.Minute, errSlow, contextID)
+	defer cancelF()
+	errShutdown := errors.New("shutting down")
+	parentCancel(errShutdown)
+	<-ctx.Done()
+	if ctx.Err() != context.Canceled || context.Cause(ctx) != errShutdown {
+		t.Fatalf("unexpected error %v and cause %v", ctx.Err(), context.Cause(ctx))
+	}
+}
+
+func TestAutoAdvance(t *testing.T) {
+	at := NewManual()
+	start := at.Now()
+	at.SetAutoAdvance(true)
+
+	at.Sleep(time.Second, sleepID)
+	if at.Now() != start.Add(time.Second) {
+		t.Fatal("Sleep did not advance Now")
+	}
+
+	if <-at.After(time.Minute, afterID) != start.Add(time.Second+time.Minute) {
+		t.Fatal("After did not deliver the advanced time")
+	}
+
+	timer := at.NewTimer(time.Hour, timerID)
+	<-timer.Channel()
+	if at.Since(start) != time.Hour+time.Minute+time.Second {
+		t.Fatal("NewTimer did not advance Now")
+	}
+	if timer.Stop() {
+		t.Fatal("auto-advanced timer should already have fired")
+	}
+
+	at.SetAutoAdvance(false)
+	ch := at.After(time.Minute, afterID)
+	select {
+	case <-ch:
+		t.Fatal("After fired immediately with auto-advance off")
+	case <-time.After(time.Millisecond):
+	}
+}
+
+func TestFireNonPositive(t *testing.T) {
+	mt := NewManual()
+	mt.SetFireNonPositive(true)
+
+	now := mt.Now()
+	if fired := <-mt.After(0, afterID); !fired.Equal(now) {
+		t.Fatalf("unexpected fire time %v", fired)
+	}
+	mt.Sleep(-time.Second, sleepID)
+	timer := mt.NewTimer(-time.Second, timerID)
+	<-timer.Channel()
+	ran := make(chan struct{})
+	mt.AfterFunc(0, func() { close(ran) }, afterFuncID)
+	<-ran
+
+	// Resetting to fire now fires too, but positive durations still wait
+	timer.Reset(time.Second)
+	timer.Reset(0)
+	<-timer.Channel()
+	mt.After(time.Second, afterID)
+	if len(mt.PendingIDs()) != 1 {
+		t.Fatalf("unexpected pending ids: %v", mt.PendingIDs())
+	}
+
+	// tickers match the time package regardless
+	if mt.Tick(0, tickID) != nil {
+		t.Fatal("Tick of 0 returned a channel")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("NewTicker of 0 didn't panic")
+			}
+		}()
+		mt.NewTicker(0, tickID)
+	}()
+}
+
+func TestAdvanceOnFire(t *testing.T) {
+	at := NewManual()
+	start := at.Now()
+	at.SetAdvanceOnFire(true)
+
+	timer := at.NewTimer(30*time.Second, timerID)
+	ticker := at.NewTicker(time.Minute, tickID)
+	defer ticker.Stop()
+
+	at.Trigger(timerID)
+	if fired := <-timer.Channel(); fired != start.Add(30*time.Second) || at.Now() != fired {
+		t.Fatalf("Now %v not advanced to the fire time %v", at.Now(), fired)
+	}
+
+	at.Trigger(tickID)
+	<-ticker.Channel()
+	at.Trigger(tickID)
+	<-ticker.Channel()
+	if at.Now() != start.Add(2*time.Minute) {
+		t.Fatalf("Now %v not advanced by the ticks", at.Now())
+	}
+
+	// firing something already overdue leaves Now alone
+	at.After(time.Second, afterID)
+	at.Advance(time.Hour)
+	at.Trigger(afterID)
+	if at.Now() != start.Add(2*time.Minute+time.Hour) {
+		t.Fatal("overdue firing moved Now")
+	}
+
+	// and auto-advance only advances once
+	now := at.Now()
+	at.SetAutoAdvance(true)
+	<-at.After(time.Minute, afterID)
+	if at.Now() != now.Add(time.Minute) {
+		t.Fatalf("auto-advance moved Now to %v", at.Now())
+	}
+}
+
+// recordingT captures errors rather than failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (rt *recordingT) Helper() {}
+
+func (rt *recordingT) Errorf(format string, args ...interface{}) {
+	rt.errors = append(rt.errors, fmt.Sprintf(format, args...))
+}
+
+func TestPending(t *testing.T) {
+	at := NewManual()
+	at.VerifyNoPending(t)
+
+	timer := at.NewTimer(time.Second, timerID)
+	ticker := at.NewTicker(time.Second, tickID)
+	_, cancel := at.WithCancel(context.Background(), contextID)
+
+	ids := at.PendingIDs()
+	if len(ids) != 3 || ids[0] != tickID || ids[1] != timerID || ids[2] != contextID {
+		t.Fatalf("unexpected pending ids: %v", ids)
+	}
+
+	rt := &recordingT{}
+	at.VerifyNoPending(rt)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "Timer with id 5") ||
+		!strings.Contains(rt.errors[0], "abtime.TestPending") {
+		t.Fatalf("unexpected report: %v", rt.errors)
+	}
+
+	timer.Stop()
+	ticker.Stop()
+	cancel()
+	at.VerifyNoPending(t)
+}
+
+func TestTickerBacklog(t *testing.T) {
+	testTime := time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC)
+	at := NewManualAtTime(testTime)
+
+	// unlimited: every trigger is delivered, in order
+	ticker := at.NewTicker(time.Second, tickID)
+	at.Trigger(tickID, tickID, tickID)
+	for i := 1; i <= 3; i++ {
+		if tick := <-ticker.Channel(); tick != testTime.Add(time.Duration(i)*time.Second) {
+			t.Fatalf("tick %d arrived out of order: %v", i, tick)
+		}
+	}
+	ticker.Stop()
+
+	// a backlog of 1 coalesces like time.Ticker
+	at.SetTickerBacklog(1)
+	ticker = at.NewTicker(time.Second, tickID2)
+	at.Trigger(tickID2, tickID2, tickID2)
+	if tick := <-ticker.Channel(); tick != testTime.Add(time.Second) {
+		t.Fatalf("unexpected first tick %v", tick)
+	}
+	at.WaitConsumed(tickID2, 1)
+	at.Trigger(tickID2)
+	if tick := <-ticker.Channel(); tick != testTime.Add(4*time.Second) {
+		t.Fatalf("coalesced ticks should still advance the time; got %v", tick)
+	}
+	if at.Delivered(tickID2) != 2 {
+		t.Fatal("coalesced ticks were delivered")
+	}
+}
+
+func TestTriggerAndWaitDroppedTick(t *testing.T) {
+	testTime := time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC)
+	at := NewManualAtTime(testTime)
+	at.SetTickerBacklog(1)
+	ticker := at.NewTicker(time.Second, tickID)
+	at.Trigger(tickID)
+
+	// the backlog is full, so this tick is dropped; waiting must only
+	// wait for the tick that was actually delivered
+	events := at.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		at.TriggerAndWait(tickID)
+		close(done)
+	}()
+	for event := range events {
+		if event.Kind == Fired {
+			break
+		}
+	}
+	at.Unsubscribe(events)
+	if tick := <-ticker.Channel(); tick != testTime.Add(time.Second) {
+		t.Fatalf("unexpected tick %v", tick)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("TriggerAndWait hung on a dropped tick")
+	}
+	if at.Delivered(tickID) != 1 {
+		t.Fatal("dropped tick was delivered")
+	}
+}
+
+func TestDeliverNow(t *testing.T) {
+	at := NewManual()
+	at.SetDeliverNow(true)
+
+	timer := at.NewTimer(time.Minute, timerID)
+	ticker := at.NewTicker(time.Minute, tickID)
+	at.Advance(time.Hour)
+	at.Trigger(timerID, tickID)
+	now := at.Now()
+	if <-timer.Channel() != now || <-ticker.Channel() != now {
+		t.Fatal("timer and ticker did not deliver Now")
+	}
+
+	at.SetAutoAdvance(true)
+	fired := <-at.After(time.Second, afterID)
+	if fired != now.Add(time.Second) || at.Now() != fired {
+		t.Fatal("auto-advanced After did not deliver the advanced Now")
+	}
+}
+
+func TestStaleRegistrations(t *testing.T) {
+	at := NewManual()
+	at.SetStaleAfter(time.Minute)
+
+	at.NewTimer(time.Second, timerID)
+	at.Advance(30 * time.Second)
+	fresh := at.NewTimer(time.Second, timerID)
+	at.Advance(31 * time.Second)
+
+	// the first timer is now stale, so the Trigger goes to the second
+	at.Trigger(timerID)
+	<-fresh.Channel()
+
+	rt := &recordingT{}
+	at.VerifyNoPending(rt)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "Timer with id 5, expired as stale") {
+		t.Fatalf("unexpected report: %v", rt.errors)
+	}
+}
+
+func TestGo123Semantics(t *testing.T) {
+	at := NewManual()
+	at.SetTimerSemantics(Go123Timers)
+
+	timer := at.NewTimer(time.Second, timerID)
+	at.Trigger(timerID)
+	if !timer.Stop() {
+		t.Fatal("unreceived timer should count as active")
+	}
+	if at.Delivered(timerID) != 0 {
+		t.Fatal("canceled delivery still counted")
+	}
+	select {
+	case <-timer.Channel():
+		t.Fatal("stale value received after Stop")
+	case <-time.After(time.Millisecond):
+	}
+
+	// Reset re-arms the timer
+	if timer.Reset(time.Minute) {
+		t.Fatal("stopped timer should not count as active")
+	}
+	at.Trigger(timerID)
+	<-timer.Channel()
+
+	ticker := at.NewTicker(time.Second, tickID)
+	at.Trigger(tickID, tickID, tickID)
+	ticker.Reset(time.Second)
+	select {
+	case <-ticker.Channel():
+		t.Fatal("stale tick received after Reset")
+	case <-time.After(time.Millisecond):
+	}
+	// the tick that was in flight is retracted asynchronously
+	for i := 0; at.Delivered(tickID) != 0; i++ {
+		if i > 1000 {
+			t.Fatal("discarded ticks still counted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	ticker.Stop()
+
+	// and under the legacy semantics, the stale value is still there
+	at.SetTimerSemantics(LegacyTimers)
+	timer = at.NewTimer(time.Second, timerID)
+	at.Trigger(timerID)
+	if timer.Stop() {
+		t.Fatal("fired timer should not count as active")
+	}
+	<-timer.Channel()
+}
+
+func TestStrict(t *testing.T) {
+	var errs []error
+	at := NewManualStrict(func(err error) { errs = append(errs, err) })
+
+	at.Trigger("typo")
+	if len(errs) != 1 || !errors.Is(errs[0], ErrUnregisteredTrigger) {
+		t.Fatalf("unregistered trigger not reported: %v", errs)
+	}
+
+	// registering in a loop from one call site is fine
+	timers := []Timer{}
+	for i := 0; i < 3; i++ {
+		timers = append(timers, at.NewTimer(time.Second, timerID))
+	}
+	if len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	at.Trigger(timerID)
+	<-timers[0].Channel()
+
+	// but not from a second one while the first is live
+	at.NewTimer(time.Second, timerID)
+	if len(errs) != 2 || !errors.Is(errs[1], ErrDuplicateRegistration) {
+		t.Fatalf("duplicate registration not reported: %v", errs)
+	}
+
+	// once the first call site's registrations are gone, it's fine
+	at.Unregister(timerID)
+	at.NewTimer(time.Second, timerID)
+	if len(errs) != 2 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// with no handler, it panics
+	at = NewManualStrict(nil)
+	func() {
+		defer func() {
+			err, isErr := recover().(error)
+			if !isErr || !errors.Is(err, ErrUnregisteredTrigger) {
+				t.Fatalf("expected panic, got %v", err)
+			}
+		}()
+		at.Trigger(sleepID)
+	}()
+}
+
+func TestSleepContext(t *testing.T) {
+	at := NewManual()
+
+	done := make(chan error)
+	go func() {
+		done <- at.SleepContext(context.Background(), time.Second, sleepID)
+	}()
+	at.Trigger(sleepID)
+	if err := <-done; err != nil {
+		t.Fatalf("triggered SleepContext returned %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- at.SleepContext(ctx, time.Second, sleepID)
+	}()
+	for len(at.PendingIDs()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("canceled SleepContext returned %v", err)
+	}
+
+	// the abandoned sleep doesn't swallow the next Trigger
+	if pending := at.PendingIDs(); len(pending) != 0 {
+		t.Fatalf("canceled sleep still pending: %v", pending)
+	}
+	at.Trigger(sleepID)
+	at.Sleep(time.Second, sleepID)
+
+	if at.SleepContext(ctx, time.Second, sleepID) != context.Canceled {
+		t.Fatal("SleepContext with a done context should return at once")
+	}
+}
+
+func TestContextDeadlineAuto(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	ctx, cancelF := mt.WithDeadlineAuto(context.Background(), start.Add(time.Minute), contextID)
+	defer cancelF()
+	timeout, cancelTimeout := mt.WithTimeoutAuto(context.Background(), time.Hour, childContextID)
+	defer cancelTimeout()
+
+	mt.Advance(59 * time.Second)
+	if ctx.Err() != nil {
+		t.Fatal("context canceled before its deadline")
+	}
+	mt.Advance(time.Second)
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatal("context passing its deadline is not context.DeadlineExceeded")
+	}
+	mt.AssertFiredInOrder(t, contextID)
+
+	mt.QueueNows(start.Add(2 * time.Hour))
+	mt.Now()
+	<-timeout.Done()
+
+	// a deadline already past cancels immediately
+	past, cancelPast := mt.WithDeadlineAuto(context.Background(), start, contextID)
+	defer cancelPast()
+	<-past.Done()
+}
+
+func TestAdvanceTo(t *testing.T) {
+	start := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	mt := NewManualAtTime(start)
+
+	billing := time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)
+	mt.AdvanceTo(billing)
+	if !mt.Now().Equal(billing) {
+		t.Fatal("AdvanceTo did not move Now")
+	}
+
+	// rewinding is allowed by default
+	mt.AdvanceTo(start)
+	if !mt.Now().Equal(start) {
+		t.Fatal("AdvanceTo did not rewind Now")
+	}
+
+	var errs []error
+	mt.SetRewindPolicy(ReportRewind, func(err error) { errs = append(errs, err) })
+	mt.Advance(-time.Second)
+	mt.QueueNows(start.Add(time.Second), start)
+	mt.Now()
+	mt.Now()
+	if len(errs) != 2 || !errors.Is(errs[0], ErrRewind) || !errors.Is(errs[1], ErrRewind) {
+		t.Fatalf("rewinds not reported: %v", errs)
+	}
+	if !mt.Now().Equal(start.Add(time.Second)) {
+		t.Fatal("reported rewind still moved Now")
+	}
+
+	mt.SetRewindPolicy(PanicOnRewind, nil)
+	defer func() {
+		err, isErr := recover().(error)
+		if !isErr || !errors.Is(err, ErrRewind) {
+			t.Fatalf("expected panic, got %v", err)
+		}
+	}()
+	mt.AdvanceTo(start)
+}
+
+func TestWallClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mt := NewManualAtTime(start)
+
+	before := mt.Now()
+	mt.Advance(time.Minute)
+
+	// step the wall clock back an hour; elapsed time keeps counting up
+	mt.SetWallClock(start.Add(-time.Hour))
+	mt.Advance(time.Minute)
+	if mt.Now().After(before) {
+		t.Fatal("wall clock was not set back")
+	}
+	if elapsed := mt.Since(before); elapsed != 2*time.Minute {
+		t.Fatalf("Since did not use the monotonic clock: %v", elapsed)
+	}
+
+	wall := mt.Now()
+	mt.AdvanceMonotonic(time.Second)
+	if !mt.Now().Equal(wall) {
+		t.Fatal("AdvanceMonotonic moved the wall clock")
+	}
+
+	// times from the new epoch work too
+	after := mt.Now()
+	deadline := after.Add(time.Hour)
+	mt.Advance(time.Second)
+	if elapsed := mt.Since(after); elapsed != time.Second {
+		t.Fatalf("Since in the current epoch: %v", elapsed)
+	}
+	if elapsed := mt.Since(before); elapsed != 2*time.Minute+2*time.Second {
+		t.Fatalf("Since lost track of the first epoch: %v", elapsed)
+	}
+	if remaining := mt.Until(deadline); remaining != time.Hour-time.Second {
+		t.Fatalf("Until did not use the monotonic clock: %v", remaining)
+	}
+
+	// times the clock never returned fall back to the wall clock
+	if elapsed := mt.Since(start.Add(-2 * time.Hour)); elapsed != time.Hour+time.Minute+time.Second {
+		t.Fatalf("Since of a foreign time: %v", elapsed)
+	}
+}
+
+func TestAdvanceMonotonicStripsNow(t *testing.T) {
+	mt := NewManualAtTime(time.Now())
+	if now := mt.Now(); now == now.Round(0) {
+		t.Fatal("expected a monotonic reading to start with")
+	}
+
+	// once the clocks diverge, Now carries no monotonic reading, even
+	// when read without the lock
+	mt.AdvanceMonotonic(time.Second)
+	if now := mt.Now(); now != now.Round(0) {
+		t.Fatalf("Now still carries a monotonic reading: %v", now)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	mt := NewManual()
+	deadline := mt.Now().Add(time.Minute)
+
+	for _, id := range []ID{"low", "first", "second", "high"} {
+		_, cancel := mt.WithDeadlineAuto(context.Background(), deadline, id)
+		defer cancel()
+	}
+	_, cancel := mt.WithDeadlineAuto(context.Background(), deadline.Add(-time.Second), "earlier")
+	defer cancel()
+	mt.SetPriority(10, "high")
+	mt.SetPriority(-1, "low")
+
+	mt.Advance(time.Hour)
+	fired := []ID{}
+	for _, event := range mt.History() {
+		if event.Kind == Fired {
+			fired = append(fired, event.ID)
+		}
+	}
+	if fmt.Sprint(fired) != "[earlier high first second low]" {
+		t.Fatalf("fired in the wrong order: %v", fired)
+	}
+}
+
+func TestTriggerWithin(t *testing.T) {
+	mt := NewManual()
+
+	timer := mt.NewTimer(time.Second, timerID)
+	go func() {
+		<-timer.Channel()
+	}()
+	if err := mt.TriggerWithin(time.Second, timerID); err != nil {
+		t.Fatalf("consumed trigger failed: %v", err)
+	}
+
+	// nobody is listening to this one
+	mt.NewTimer(time.Second, "ignored")
+	err := mt.TriggerWithin(10*time.Millisecond, "ignored")
+	if !errors.Is(err, ErrNotConsumed) || !strings.Contains(err.Error(), "ignored (1 unconsumed)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTickerReset(t *testing.T) {
+	testTime := time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC)
+	at := NewManualAtTime(testTime)
+	ticker := at.NewTicker(time.Second, tickID).(RecordingTicker)
+
+	at.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != testTime.Add(time.Second) {
+		t.Fatalf("unexpected first tick %v", tick)
+	}
+
+	at.Advance(10 * time.Second)
+	ticker.Reset(time.Minute)
+	at.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != testTime.Add(10*time.Second+time.Minute) {
+		t.Fatalf("reset interval not used: %v", tick)
+	}
+
+	ticker.Stop()
+	ticker.Reset(time.Hour)
+	if at.PendingTriggers(tickID) != 0 || len(at.Pending(tickID)) != 1 {
+		t.Fatal("reset did not restart the stopped ticker")
+	}
+	at.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != testTime.Add(10*time.Second+time.Hour) {
+		t.Fatalf("restarted ticker delivered %v", tick)
+	}
+
+	resets := ticker.Resets()
+	if len(resets) != 2 || resets[0] != time.Minute || resets[1] != time.Hour {
+		t.Fatalf("unexpected resets %v", resets)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("non-positive reset did not panic")
+		}
+	}()
+	ticker.Reset(0)
+}
+
+func TestLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	mt := NewManualAtTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if mt.Location() != time.UTC {
+		t.Fatal("unexpected initial location")
+	}
+	start := mt.Now()
+	mt.SetLocation(tokyo)
+	if now := mt.Now(); now.Location() != tokyo || !now.Equal(start) || now.Hour() != 9 {
+		t.Fatalf("unexpected Now %v", now)
+	}
+	mt.AdvanceTo(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if now := mt.Now(); now.Location() != tokyo || now.Day() != 2 || now.Hour() != 9 {
+		t.Fatalf("AdvanceTo did not convert %v", now)
+	}
+	timer := mt.NewTimer(time.Hour, timerID)
+	mt.Trigger(timerID)
+	if fired := <-timer.Channel(); fired.Location() != tokyo || fired.Hour() != 10 {
+		t.Fatalf("timer delivered %v", fired)
+	}
+	if mt.NowIn(time.UTC).Hour() != 0 {
+		t.Fatal("NowIn did not convert")
+	}
+}
+
+// benchmarkNowContended reads the clock with read from parallel
+// goroutines while another keeps moving Now, as a busy test would.
+func benchmarkNowContended(b *testing.B, read func(*ManualTime)) {
+	mt := NewManual()
+	mt.SetHistoryLimit(1)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				mt.Advance(time.Millisecond)
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			read(mt)
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
+
+func BenchmarkManualNowParallel(b *testing.B) {
+	benchmarkNowContended(b, func(mt *ManualTime) { mt.Now() })
+}
+
+// BenchmarkManualNowLockedParallel reads Now under the lock, as Now used
+// to, for comparison with BenchmarkManualNowParallel.
+func BenchmarkManualNowLockedParallel(b *testing.B) {
+	benchmarkNowContended(b, func(mt *ManualTime) {
+		mt.Lock()
+		_ = mt.now
+		mt.Unlock()
+	})
+}
@@ -0,0 +1,408 @@
+package abtime
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Metric names one of the metrics kept by a MeteredTime.
+type Metric string
+
+// The metrics kept by a MeteredTime. The Active metrics are gauges, which
+// go up and down; the others are counters.
+const (
+	ActiveTimers     Metric = "active_timers"
+	ActiveTickers    Metric = "active_tickers"
+	ActiveSleeps     Metric = "active_sleeps"
+	ActiveContexts   Metric = "active_contexts"
+	Firings          Metric = "fired"
+	ContextsCanceled Metric = "contexts_canceled"
+)
+
+// A Collector receives every change to a MeteredTime's metrics, for
+// bridging to a metrics system such as Prometheus. Add is called
+// synchronously by whichever goroutine made the change, so it must be safe
+// for concurrent use, and should be quick.
+type Collector interface {
+	Add(metric Metric, delta int64)
+}
+
+// Metrics is a snapshot of a MeteredTime's metrics.
+type Metrics struct {
+	ActiveTimers     int64 `json:"active_timers"`
+	ActiveTickers    int64 `json:"active_tickers"`
+	ActiveSleeps     int64 `json:"active_sleeps"`
+	ActiveContexts   int64 `json:"active_contexts"`
+	Fired            int64 `json:"fired"`
+	ContextsCanceled int64 `json:"contexts_canceled"`
+}
+
+// MeteredTime decorates another AbstractTime, counting the active timers
+// (including Afters and AfterFuncs), tickers, sleeps, and contexts made
+// through it, along with how many times they've fired and how many of the
+// contexts have been canceled. A timer count that only ever goes up is a
+// timer leak, in production or in a test.
+//
+// The counts are available as a Metrics snapshot, which can be published
+// with expvar via Var, and are also reported as they change to an optional
+// Collector.
+//
+// To see their firings, the channels of timers and tickers are forwarded
+// by a goroutine from the underlying ones, as with TruncatedTime. The
+// forwarded channels have a buffer of one, as the real time package's do.
+// Contexts are watched by a goroutine until they are done.
+type MeteredTime struct {
+	AbstractTime
+	collector Collector
+	counts    [6]int64
+}
+
+var metricOrder = [...]Metric{ActiveTimers, ActiveTickers, ActiveSleeps,
+	ActiveContexts, Firings, ContextsCanceled}
+
+// NewMeteredTime wraps the given AbstractTime, keeping metrics on its use
+// and reporting them to the collector, which may be nil.
+func NewMeteredTime(at AbstractTime, collector Collector) *MeteredTime {
+	return &MeteredTime{AbstractTime: at, collector: collector}
+}
+
+func (m *MeteredTime) add(metric Metric, delta int64) {
+	for i, known := range metricOrder {
+		if known == metric {
+			atomic.AddInt64(&m.counts[i], delta)
+		}
+	}
+	if m.collector != nil {
+		m.collector.Add(metric, delta)
+	}
+}
+
+// realClock reports whether the wrapped clock follows the real clock; see
+// CoalescedTime.
+func (m *MeteredTime) realClock() bool {
+	return isRealClock(m.AbstractTime)
+}
+
+// Metrics returns a snapshot of the current metrics.
+func (m *MeteredTime) Metrics() Metrics {
+	return Metrics{
+		ActiveTimers:     atomic.LoadInt64(&m.counts[0]),
+		ActiveTickers:    atomic.LoadInt64(&m.counts[1]),
+		ActiveSleeps:     atomic.LoadInt64(&m.counts[2]),
+		ActiveContexts:   atomic.LoadInt64(&m.counts[3]),
+		Fired:            atomic.LoadInt64(&m.counts[4]),
+		ContextsCanceled: atomic.LoadInt64(&m.counts[5]),
+	}
+}
+
+// Var returns an expvar.Var reporting the current metrics, for publishing
+// under a name of your choosing:
+//
+//	expvar.Publish("clock", metered.Var())
+func (m *MeteredTime) Var() expvar.Var {
+	return expvar.Func(func() interface{} { return m.Metrics() })
+}
+
+// fired records a timer, AfterFunc, or sleep finishing by firing.
+func (m *MeteredTime) fired(active Metric) {
+	m.add(active, -1)
+	m.add(Firings, 1)
+}
+
+// After wraps After, counting it as a timer until it fires.
+func (m *MeteredTime) After(d time.Duration, id ID) <-chan time.Time {
+	m.add(ActiveTimers, 1)
+	inner := m.AbstractTime.After(d, id)
+	c := make(chan time.Time, 1)
+	go func() {
+		fired, ok := <-inner
+		if !ok {
+			// closed by a ManualTime's Close, without firing
+			m.add(ActiveTimers, -1)
+			close(c)
+			return
+		}
+		m.fired(ActiveTimers)
+		c <- fired
+	}()
+	return c
+}
+
+// Sleep wraps Sleep, counting it while it sleeps.
+func (m *MeteredTime) Sleep(d time.Duration, id ID) {
+	m.add(ActiveSleeps, 1)
+	m.AbstractTime.Sleep(d, id)
+	m.fired(ActiveSleeps)
+}
+
+// SleepContext wraps SleepContext, counting it while it sleeps. Only a
+// sleep that runs its course counts as fired.
+func (m *MeteredTime) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	m.add(ActiveSleeps, 1)
+	err := m.AbstractTime.SleepContext(ctx, d, id)
+	if err != nil {
+		m.add(ActiveSleeps, -1)
+	} else {
+		m.fired(ActiveSleeps)
+	}
+	return err
+}
+
+// Tick wraps Tick, counting it as a ticker that is never stopped.
+func (m *MeteredTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	return m.NewTicker(d, id).Channel()
+}
+
+// NewTicker wraps NewTicker, counting it until it is stopped.
+func (m *MeteredTime) NewTicker(d time.Duration, id ID) Ticker {
+	m.add(ActiveTickers, 1)
+	ticker := &meteredTicker{
+		Ticker: m.AbstractTime.NewTicker(d, id),
+		m:      m,
+		c:      make(chan time.Time, 1),
+		stop:   make(chan struct{}),
+	}
+	go ticker.forward(ticker.stop)
+	return ticker
+}
+
+// AfterFunc wraps AfterFunc, counting it as a timer.
+func (m *MeteredTime) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	m.add(ActiveTimers, 1)
+	timer := &meteredFuncTimer{m: m, active: true}
+	timer.Timer = m.AbstractTime.AfterFunc(d, func() {
+		if timer.deactivate() {
+			m.fired(ActiveTimers)
+		}
+		f()
+	}, id)
+	return timer
+}
+
+// NewTimer wraps NewTimer, counting it while it is armed.
+func (m *MeteredTime) NewTimer(d time.Duration, id ID) Timer {
+	return m.newTimer(m.AbstractTime.NewTimer(d, id))
+}
+
+// NewTimerAt wraps NewTimerAt, counting it while it is armed.
+func (m *MeteredTime) NewTimerAt(t time.Time, id ID) Timer {
+	return m.newTimer(m.AbstractTime.NewTimerAt(t, id))
+}
+
+func (m *MeteredTime) newTimer(inner Timer) *meteredTimer {
+	timer := &meteredTimer{Timer: inner, m: m, c: make(chan time.Time, 1)}
+	timer.arm()
+	return timer
+}
+
+// WithCancel wraps WithCancel, counting the context until it is done.
+func (m *MeteredTime) WithCancel(parent context.Context, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := m.AbstractTime.WithCancel(parent, id)
+	m.watch(ctx)
+	return ctx, cancel
+}
+
+// WithDeadline wraps WithDeadline, counting the context until it is done.
+func (m *MeteredTime) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := m.AbstractTime.WithDeadline(parent, deadline, id)
+	m.watch(ctx)
+	return ctx, cancel
+}
+
+// WithTimeout wraps WithTimeout, counting the context until it is done.
+func (m *MeteredTime) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := m.AbstractTime.WithTimeout(parent, timeout, id)
+	m.watch(ctx)
+	return ctx, cancel
+}
+
+// WithDeadlineCause wraps WithDeadlineCause, counting the context until it
+// is done.
+func (m *MeteredTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := m.AbstractTime.WithDeadlineCause(parent, deadline, cause, id)
+	m.watch(ctx)
+	return ctx, cancel
+}
+
+// WithTimeoutCause wraps WithTimeoutCause, counting the context until it
+// is done.
+func (m *MeteredTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := m.AbstractTime.WithTimeoutCause(parent, timeout, cause, id)
+	m.watch(ctx)
+	return ctx, cancel
+}
+
+func (m *MeteredTime) watch(ctx context.Context) {
+	m.add(ActiveContexts, 1)
+	go func() {
+		<-ctx.Done()
+		m.add(ActiveContexts, -1)
+		m.add(ContextsCanceled, 1)
+	}()
+}
+
+type meteredTimer struct {
+	Timer
+	m *MeteredTime
+	c chan time.Time
+
+	// the forwarding goroutine runs while the timer is armed; closing
+	// stop ends it
+	active bool
+	stop   chan struct{}
+	mu     sync.Mutex
+}
+
+// arm counts the timer as active and starts the forwarding goroutine, if
+// it isn't already. The lock must be held, or the timer not yet shared.
+func (tm *meteredTimer) arm() {
+	if tm.active {
+		return
+	}
+	tm.active = true
+	tm.m.add(ActiveTimers, 1)
+	stop := make(chan struct{})
+	tm.stop = stop
+	go func() {
+		select {
+		case fired := <-tm.Timer.Channel():
+			tm.mu.Lock()
+			current := tm.stop == stop
+			if current {
+				tm.active = false
+			}
+			tm.mu.Unlock()
+			if current {
+				tm.m.fired(ActiveTimers)
+			}
+			select {
+			case tm.c <- fired:
+			default:
+			}
+		case <-stop:
+		}
+	}()
+}
+
+func (tm *meteredTimer) Channel() <-chan time.Time {
+	return tm.c
+}
+
+func (tm *meteredTimer) Stop() bool {
+	ret := tm.Timer.Stop()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if tm.active {
+		// clearing stop tells a forwarding goroutine that has already
+		// received the firing that the timer is no longer its to count
+		close(tm.stop)
+		tm.stop = nil
+		tm.active = false
+		tm.m.add(ActiveTimers, -1)
+	}
+	return ret
+}
+
+func (tm *meteredTimer) Reset(d time.Duration) bool {
+	ret := tm.Timer.Reset(d)
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.arm()
+	return ret
+}
+
+type meteredFuncTimer struct {
+	Timer
+	m      *MeteredTime
+	active bool
+	mu     sync.Mutex
+}
+
+// deactivate marks the timer inactive, returning whether it was active.
+func (mf *meteredFuncTimer) deactivate() bool {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	was := mf.active
+	mf.active = false
+	return was
+}
+
+func (mf *meteredFuncTimer) Stop() bool {
+	ret := mf.Timer.Stop()
+	if mf.deactivate() {
+		mf.m.add(ActiveTimers, -1)
+	}
+	return ret
+}
+
+func (mf *meteredFuncTimer) Reset(d time.Duration) bool {
+	ret := mf.Timer.Reset(d)
+
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+	if !mf.active {
+		mf.active = true
+		mf.m.add(ActiveTimers, 1)
+	}
+	return ret
+}
+
+type meteredTicker struct {
+	Ticker
+	m *MeteredTime
+	c chan time.Time
+
+	// closing stop ends forward; it is nil while the ticker is stopped
+	stop chan struct{}
+	mu   sync.Mutex
+}
+
+func (tk *meteredTicker) forward(stop chan struct{}) {
+	for {
+		select {
+		case tick := <-tk.Ticker.Channel():
+			tk.m.add(Firings, 1)
+			select {
+			case tk.c <- tick:
+			case <-stop:
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (tk *meteredTicker) Channel() <-chan time.Time {
+	return tk.c
+}
+
+func (tk *meteredTicker) Reset(d time.Duration) {
+	tk.mu.Lock()
+	defer tk.mu.Unlock()
+
+	tk.Ticker.Reset(d)
+	if tk.stop == nil {
+		tk.stop = make(chan struct{})
+		tk.m.add(ActiveTickers, 1)
+		go tk.forward(tk.stop)
+	}
+}
+
+func (tk *meteredTicker) Stop() {
+	tk.mu.Lock()
+	defer tk.mu.Unlock()
+
+	tk.Ticker.Stop()
+	if tk.stop != nil {
+		close(tk.stop)
+		tk.stop = nil
+		tk.m.add(ActiveTickers, -1)
+	}
+}
@@ -0,0 +1,92 @@
+package abtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sumCollector struct {
+	sums map[Metric]int64
+	mu   sync.Mutex
+}
+
+func (sc *sumCollector) Add(metric Metric, delta int64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.sums[metric] += delta
+}
+
+func TestMeteredTime(t *testing.T) {
+	mt := NewManual()
+	collector := &sumCollector{sums: map[Metric]int64{}}
+	metered := NewMeteredTime(mt, collector)
+
+	timer := metered.NewTimer(time.Second, timerID)
+	stopped := metered.NewTimer(time.Second, timerID)
+	stopped.Stop()
+	ran := make(chan struct{})
+	metered.AfterFunc(time.Second, func() { close(ran) }, afterFuncID)
+	after := metered.After(time.Second, afterID)
+	ticker := metered.NewTicker(time.Second, tickID)
+	ctx, cancel := metered.WithCancel(context.Background(), contextID)
+	slept := make(chan struct{})
+	go func() {
+		metered.Sleep(time.Second, sleepID)
+		close(slept)
+	}()
+	for len(mt.Pending(sleepID)) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	m := metered.Metrics()
+	if m.ActiveTimers != 3 || m.ActiveTickers != 1 || m.ActiveSleeps != 1 ||
+		m.ActiveContexts != 1 || m.Fired != 0 {
+		t.Fatalf("unexpected metrics: %+v", m)
+	}
+
+	mt.Trigger(timerID, afterFuncID, afterID, sleepID)
+	<-timer.Channel()
+	<-after
+	<-ran
+	<-slept
+	mt.TriggerN(tickID, 2)
+	<-ticker.Channel()
+	<-ticker.Channel()
+	ticker.Stop()
+
+	// a stopped ticker is counted and forwarded again once Reset
+	ticker.Reset(time.Second)
+	if metered.Metrics().ActiveTickers != 1 {
+		t.Fatal("Reset ticker not counted as active")
+	}
+	mt.Trigger(tickID)
+	<-ticker.Channel()
+	ticker.Stop()
+	cancel()
+	<-ctx.Done()
+
+	expected := Metrics{Fired: 7, ContextsCanceled: 1}
+	deadline := time.Now().Add(time.Second)
+	for metered.Metrics() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected metrics: %+v", metered.Metrics())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the collector saw every change
+	collector.mu.Lock()
+	if collector.sums[Firings] != 7 || collector.sums[ActiveTimers] != 0 {
+		t.Fatalf("collector out of step: %v", collector.sums)
+	}
+	collector.mu.Unlock()
+
+	var published Metrics
+	if err := json.Unmarshal([]byte(metered.Var().String()), &published); err != nil || published != expected {
+		t.Fatalf("expvar not published properly: %v, %+v", err, published)
+	}
+}
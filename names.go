@@ -0,0 +1,38 @@
+package abtime
+
+import "fmt"
+
+// DeclareID gives an id a human-readable name, which ManualTime then uses
+// alongside the id in its panics, history dumps, and leak reports. Acting
+// on "id 7 (billing.graceperiod) was never triggered" is a lot easier
+// than on "id 7 was never triggered".
+//
+// Declaring an id is optional, and doesn't register anything; it's
+// purely descriptive. Declaring it again replaces the name, and an empty
+// name removes it.
+func (mt *ManualTime) DeclareID(id ID, name string) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if name == "" {
+		delete(mt.names, id)
+		return
+	}
+	if mt.names == nil {
+		mt.names = map[ID]string{}
+	}
+	mt.names[id] = name
+}
+
+// idString formats the id for a message, along with its declared name if
+// it has one, or else the name it was allocated under by an IDSpace. The
+// lock must be held.
+func (mt *ManualTime) idString(id ID) string {
+	if name, named := mt.names[id]; named {
+		return fmt.Sprintf("%v (%s)", id, name)
+	}
+	if name, named := spaceName(id); named {
+		return fmt.Sprintf("%v (%s)", id, name)
+	}
+	return fmt.Sprint(id)
+}
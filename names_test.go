@@ -0,0 +1,47 @@
+package abtime
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeclareID(t *testing.T) {
+	mt := NewManual()
+	mt.DeclareID(timerID, "billing.graceperiod")
+
+	mt.NewTimer(time.Second, timerID)
+	mt.After(time.Second, afterID)
+
+	rt := &recordingT{}
+	mt.AssertTriggered(rt, timerID)
+	mt.VerifyNoPending(rt)
+	if len(rt.errors) != 2 ||
+		!strings.Contains(rt.errors[0], "id 5 (billing.graceperiod) was never triggered") ||
+		!strings.Contains(rt.errors[0], "Registered Timer id 5 (billing.graceperiod) at") ||
+		!strings.Contains(rt.errors[1], "Timer with id 5 (billing.graceperiod), created at") ||
+		!strings.Contains(rt.errors[1], "After with id 0, created at") {
+		t.Fatalf("unexpected reports: %v", rt.errors)
+	}
+	if !strings.Contains(mt.String(), "id 5 (billing.graceperiod): 1 registered") {
+		t.Fatalf("unexpected description: %v", mt)
+	}
+
+	mt.SetMaxRegistrations(1, timerID)
+	func() {
+		defer func() {
+			err, isErr := recover().(error)
+			if !isErr || !errors.Is(err, ErrTooManyRegistrations) ||
+				!strings.Contains(err.Error(), "billing.graceperiod") {
+				t.Fatalf("unexpected panic: %v", err)
+			}
+		}()
+		mt.NewTimer(time.Second, timerID)
+	}()
+
+	mt.DeclareID(timerID, "")
+	if strings.Contains(mt.String(), "billing") {
+		t.Fatal("name not removed")
+	}
+}
@@ -0,0 +1,158 @@
+package abtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// A ScopedID is an ID as seen from outside the Namespace it was used in.
+type ScopedID struct {
+	Namespace string
+	ID        ID
+}
+
+func (sid ScopedID) String() string {
+	return fmt.Sprintf("%s/%v", sid.Namespace, sid.ID)
+}
+
+// A Namespace is a view of a ManualTime whose IDs are scoped to it, so
+// that components can each be handed their own ID space without
+// colliding on their IDs, while one test harness drives the master clock.
+//
+// An id used through the Namespace is registered on the ManualTime as the
+// ScopedID {prefix, id}. It can be triggered either through the
+// Namespace with the plain id, or on the ManualTime with the ScopedID,
+// which ID returns.
+type Namespace struct {
+	mt     *ManualTime
+	prefix string
+}
+
+// Namespace returns a view of the ManualTime scoping its IDs under the
+// given prefix.
+func (mt *ManualTime) Namespace(prefix string) *Namespace {
+	return &Namespace{mt, prefix}
+}
+
+// Namespace returns a namespace nested within this one, whose prefix is
+// this one's and the given one joined by a slash.
+func (ns *Namespace) Namespace(prefix string) *Namespace {
+	return &Namespace{ns.mt, ns.prefix + "/" + prefix}
+}
+
+// ID returns the ID the ManualTime knows the given id by.
+func (ns *Namespace) ID(id ID) ID {
+	return ScopedID{ns.prefix, id}
+}
+
+// Manual returns the ManualTime the namespace is a view of.
+func (ns *Namespace) Manual() *ManualTime {
+	return ns.mt
+}
+
+// scoped scopes each of the ids.
+func (ns *Namespace) scoped(ids []ID) []ID {
+	scoped := make([]ID, len(ids))
+	for i, id := range ids {
+		scoped[i] = ns.ID(id)
+	}
+	return scoped
+}
+
+// Trigger triggers the given ids within the namespace.
+func (ns *Namespace) Trigger(ids ...ID) {
+	ns.mt.Trigger(ns.scoped(ids)...)
+}
+
+// Unregister unregisters the given ids within the namespace.
+func (ns *Namespace) Unregister(ids ...ID) {
+	ns.mt.Unregister(ns.scoped(ids)...)
+}
+
+// Now returns the ManualTime's Now.
+func (ns *Namespace) Now() time.Time {
+	return ns.mt.Now()
+}
+
+// NowIn returns the ManualTime's NowIn.
+func (ns *Namespace) NowIn(loc *time.Location) time.Time {
+	return ns.mt.NowIn(loc)
+}
+
+// Since returns the ManualTime's Since.
+func (ns *Namespace) Since(t time.Time) time.Duration {
+	return ns.mt.Since(t)
+}
+
+// Until returns the ManualTime's Until.
+func (ns *Namespace) Until(t time.Time) time.Duration {
+	return ns.mt.Until(t)
+}
+
+// After wraps the ManualTime's After with a scoped id.
+func (ns *Namespace) After(d time.Duration, id ID) <-chan time.Time {
+	return ns.mt.After(d, ns.ID(id))
+}
+
+// Sleep wraps the ManualTime's Sleep with a scoped id.
+func (ns *Namespace) Sleep(d time.Duration, id ID) {
+	ns.mt.Sleep(d, ns.ID(id))
+}
+
+// SleepContext wraps the ManualTime's SleepContext with a scoped id.
+func (ns *Namespace) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	return ns.mt.SleepContext(ctx, d, ns.ID(id))
+}
+
+// Tick wraps the ManualTime's Tick with a scoped id.
+func (ns *Namespace) Tick(d time.Duration, id ID) <-chan time.Time {
+	return ns.mt.Tick(d, ns.ID(id))
+}
+
+// NewTicker wraps the ManualTime's NewTicker with a scoped id.
+func (ns *Namespace) NewTicker(d time.Duration, id ID) Ticker {
+	return ns.mt.NewTicker(d, ns.ID(id))
+}
+
+// AfterFunc wraps the ManualTime's AfterFunc with a scoped id.
+func (ns *Namespace) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	return ns.mt.AfterFunc(d, f, ns.ID(id))
+}
+
+// NewTimer wraps the ManualTime's NewTimer with a scoped id.
+func (ns *Namespace) NewTimer(d time.Duration, id ID) Timer {
+	return ns.mt.NewTimer(d, ns.ID(id))
+}
+
+// NewTimerAt wraps the ManualTime's NewTimerAt with a scoped id.
+func (ns *Namespace) NewTimerAt(t time.Time, id ID) Timer {
+	return ns.mt.NewTimerAt(t, ns.ID(id))
+}
+
+// WithCancel wraps the ManualTime's WithCancel with a scoped id.
+func (ns *Namespace) WithCancel(parent context.Context, id ID) (context.Context, context.CancelFunc) {
+	return ns.mt.WithCancel(parent, ns.ID(id))
+}
+
+// WithDeadline wraps the ManualTime's WithDeadline with a scoped id.
+func (ns *Namespace) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	return ns.mt.WithDeadline(parent, deadline, ns.ID(id))
+}
+
+// WithTimeout wraps the ManualTime's WithTimeout with a scoped id.
+func (ns *Namespace) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	return ns.mt.WithTimeout(parent, timeout, ns.ID(id))
+}
+
+// WithDeadlineCause wraps the ManualTime's WithDeadlineCause with a scoped
+// id.
+func (ns *Namespace) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	return ns.mt.WithDeadlineCause(parent, deadline, cause, ns.ID(id))
+}
+
+// WithTimeoutCause wraps the ManualTime's WithTimeoutCause with a scoped
+// id.
+func (ns *Namespace) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	return ns.mt.WithTimeoutCause(parent, timeout, cause, ns.ID(id))
+}
@@ -0,0 +1,53 @@
+package abtime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamespace(t *testing.T) {
+	mt := NewManual()
+	billing := mt.Namespace("billing")
+	mail := mt.Namespace("mail")
+	var _ AbstractTime = billing
+
+	// the same id in two namespaces doesn't collide
+	billingTimer := billing.NewTimer(time.Second, timerID)
+	mailTimer := mail.NewTimer(time.Second, timerID)
+
+	billing.Trigger(timerID)
+	<-billingTimer.Channel()
+	select {
+	case <-mailTimer.Channel():
+		t.Fatal("trigger crossed namespaces")
+	default:
+	}
+
+	// the master clock can trigger with the scoped id
+	go mt.Trigger(ScopedID{"mail", timerID})
+	<-mailTimer.Channel()
+
+	retry := mail.Namespace("retry")
+	if id := retry.ID(sleepID); id.(ScopedID).String() != "mail/retry/1" {
+		t.Fatalf("unexpected nested id %v", id)
+	}
+
+	retry.NewTimer(time.Second, timerID)
+	retry.Unregister(timerID)
+	if pending := mt.PendingIDs(); len(pending) != 0 {
+		t.Fatalf("unregistering through the namespace failed: %v", pending)
+	}
+}
+
+func TestNamespaceCreation(t *testing.T) {
+	mt := NewManual()
+	mt.Namespace("billing").NewTimer(time.Second, timerID)
+
+	rt := &recordingT{TB: t}
+	mt.VerifyNoPending(rt)
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "namespace_test.go") ||
+		strings.Contains(rt.errors[0], "abtime/namespace.go") {
+		t.Fatalf("creation stack does not show the caller: %v", rt.errors)
+	}
+}
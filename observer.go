@@ -0,0 +1,180 @@
+package abtime
+
+// A subscription queues events for one subscriber, so that recording an
+// event never blocks on a slow one.
+type subscription struct {
+	events []Event
+	more   chan struct{}
+	stop   chan struct{}
+	out    chan Event
+}
+
+// publish queues the event. The ManualTime's lock must be held.
+func (sub *subscription) publish(event Event) {
+	sub.events = append(sub.events, event)
+	select {
+	case sub.more <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe returns a channel receiving every subsequent Event, as
+// recorded in the History, in order. Any number of subscribers may be
+// active at once; each has its own unbounded queue, so a slow subscriber
+// never holds up the ManualTime or the other subscribers.
+//
+// The channel is closed by Unsubscribe.
+func (mt *ManualTime) Subscribe() <-chan Event {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return mt.subscribe()
+}
+
+// subscribe adds a new subscription. The lock must be held.
+func (mt *ManualTime) subscribe() <-chan Event {
+	sub := &subscription{
+		more: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		out:  make(chan Event),
+	}
+	mt.subscriptions = append(mt.subscriptions, sub)
+	go mt.forward(sub)
+	return sub.out
+}
+
+// Unsubscribe ends a subscription made by Subscribe, closing its channel.
+// Events still queued for it are discarded.
+func (mt *ManualTime) Unsubscribe(events <-chan Event) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.unsubscribe(events)
+}
+
+// unsubscribe ends the subscription with the given channel, if any. The
+// lock must be held.
+func (mt *ManualTime) unsubscribe(events <-chan Event) {
+	for i, sub := range mt.subscriptions {
+		if sub.out == events {
+			close(sub.stop)
+			mt.subscriptions = append(mt.subscriptions[:i], mt.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+// forward delivers a subscription's queued events until it is stopped.
+func (mt *ManualTime) forward(sub *subscription) {
+	defer close(sub.out)
+
+	for {
+		select {
+		case <-sub.more:
+		case <-sub.stop:
+			return
+		}
+
+		mt.Lock()
+		events := sub.events
+		sub.events = nil
+		mt.Unlock()
+
+		for _, event := range events {
+			select {
+			case sub.out <- event:
+			case <-sub.stop:
+				return
+			}
+		}
+	}
+}
+
+// An Observer is notified of the events in a ManualTime's history as they
+// happen. This allows test frameworks to build higher-level orchestration,
+// such as tracing or automatic triggering policies, on top of ManualTime.
+//
+// The methods are called in the order the events happened, from a single
+// goroutine dedicated to the Observer, so they are never called
+// concurrently and may freely call back into the ManualTime. As a
+// consequence, they are called shortly after the events happen, rather
+// than synchronously with them.
+type Observer interface {
+	OnRegister(Event)
+	OnTrigger(Event)
+	OnStop(Event)
+	OnFire(Event)
+}
+
+// ObserverFuncs adapts a set of functions to the Observer interface. Nil
+// functions are skipped.
+type ObserverFuncs struct {
+	Register func(Event)
+	Trigger  func(Event)
+	Stop     func(Event)
+	Fire     func(Event)
+}
+
+// OnRegister calls the Register function, if any.
+func (of ObserverFuncs) OnRegister(event Event) {
+	if of.Register != nil {
+		of.Register(event)
+	}
+}
+
+// OnTrigger calls the Trigger function, if any.
+func (of ObserverFuncs) OnTrigger(event Event) {
+	if of.Trigger != nil {
+		of.Trigger(event)
+	}
+}
+
+// OnStop calls the Stop function, if any.
+func (of ObserverFuncs) OnStop(event Event) {
+	if of.Stop != nil {
+		of.Stop(event)
+	}
+}
+
+// OnFire calls the Fire function, if any.
+func (of ObserverFuncs) OnFire(event Event) {
+	if of.Fire != nil {
+		of.Fire(event)
+	}
+}
+
+// SetObserver sets the Observer to be notified of events from now on,
+// replacing any previous one. A nil Observer stops notification.
+//
+// The Observer is simply a subscriber; see Subscribe.
+func (mt *ManualTime) SetObserver(observer Observer) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.observer != nil {
+		mt.unsubscribe(mt.observer)
+		mt.observer = nil
+	}
+	if observer == nil {
+		return
+	}
+
+	mt.observer = mt.subscribe()
+	go dispatch(observer, mt.observer)
+}
+
+// dispatch delivers events to the observer until the channel is closed.
+func dispatch(observer Observer, events <-chan Event) {
+	for event := range events {
+		switch event.Kind {
+		case Registered:
+			observer.OnRegister(event)
+		case Triggered:
+			observer.OnTrigger(event)
+		case Stopped:
+			observer.OnStop(event)
+		case Fired:
+			observer.OnFire(event)
+		}
+	}
+}
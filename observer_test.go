@@ -0,0 +1,83 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserver(t *testing.T) {
+	mt := NewManual()
+
+	events := make(chan Event, 10)
+	mt.SetObserver(ObserverFuncs{
+		Register: func(event Event) {
+			events <- event
+			// auto-trigger anything registered, to show the observer
+			// can call back into the clock
+			mt.Trigger(event.ID)
+		},
+		Fire: func(event Event) { events <- event },
+		Stop: func(event Event) { events <- event },
+	})
+
+	timer := mt.NewTimer(time.Second, timerID)
+	<-timer.Channel()
+	stopped := mt.NewTimer(time.Second, "stopped")
+	stopped.Stop()
+
+	for _, expected := range []struct {
+		kind EventKind
+		id   ID
+	}{{Registered, timerID}, {Fired, timerID}, {Registered, "stopped"}} {
+		event := <-events
+		if event.Kind != expected.kind || event.ID != expected.id {
+			t.Fatalf("unexpected event %v", event)
+		}
+	}
+	// The stop and the observer's Trigger race; either may happen first.
+	for i := 0; i < 1; i++ {
+		event := <-events
+		if event.ID != "stopped" || (event.Kind != Stopped && event.Kind != Fired) {
+			t.Fatalf("unexpected event %v", event)
+		}
+	}
+
+	mt.SetObserver(nil)
+	mt.NewTimer(time.Second, timerID)
+	select {
+	case event := <-events:
+		if event.ID != "stopped" {
+			t.Fatalf("observer still notified after removal: %v", event)
+		}
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	mt := NewManual()
+
+	first := mt.Subscribe()
+	second := mt.Subscribe()
+
+	timer := mt.NewTimer(time.Second, timerID)
+	mt.Advance(time.Second)
+	mt.Trigger(timerID)
+	<-timer.Channel()
+
+	for _, events := range []<-chan Event{first, second} {
+		for _, expected := range []EventKind{Registered, Advanced, Triggered, Fired} {
+			if event := <-events; event.Kind != expected {
+				t.Fatalf("expected %v, got %v", expected, event)
+			}
+		}
+	}
+
+	mt.Unsubscribe(first)
+	if _, open := <-first; open {
+		t.Fatal("unsubscribed channel still open")
+	}
+	mt.Advance(time.Second)
+	if event := <-second; event.Kind != Advanced {
+		t.Fatalf("remaining subscriber got %v", event)
+	}
+}
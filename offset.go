@@ -0,0 +1,21 @@
+package abtime
+
+import "time"
+
+// NewOffsetTime returns a clock that behaves like RealTime, running at
+// real speed with timers and tickers delegated to the real runtime, but
+// with Now shifted by the given offset.
+//
+// This is a ScaledTime with a multiplier of 1, so times delivered on
+// timer and ticker channels are shifted too, and contexts report their
+// real deadlines; see ScaledTime.
+func NewOffsetTime(offset time.Duration) *ScaledTime {
+	return NewScaledTimeWithOffset(1, offset)
+}
+
+// NewRealTimeAt returns a clock as NewOffsetTime does, with the offset
+// chosen so that Now starts at the given time. This lets production-like
+// code run "as if" it were, say, just before midnight on New Year's Eve.
+func NewRealTimeAt(t time.Time) *ScaledTime {
+	return NewOffsetTime(time.Until(t))
+}
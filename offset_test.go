@@ -0,0 +1,24 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOffsetTime(t *testing.T) {
+	newYear := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := NewRealTimeAt(newYear.Add(-10 * time.Millisecond))
+
+	if at.Now().After(newYear) {
+		t.Fatal("offset clock did not start at the requested time")
+	}
+	fired := <-at.After(10*time.Millisecond, 0)
+	if fired.Before(newYear) || fired.Sub(newYear) > time.Second {
+		t.Fatalf("timer delivered %v, expected just after %v", fired, newYear)
+	}
+
+	at = NewOffsetTime(-time.Hour)
+	if diff := time.Since(at.Now()); diff < time.Hour || diff > time.Hour+time.Second {
+		t.Fatal("offset not applied to Now")
+	}
+}
@@ -0,0 +1,50 @@
+package abtime
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// SetTrackOwnership sets whether the ManualTime records which goroutine
+// made each registration, along with the stack it always records. With
+// it on, leak reports from VerifyNoPending and WithScope name the
+// goroutine, Registrations carry it, and Registered events in the History
+// carry it and the call site, so when a test hangs or leaks it's clear
+// exactly which code, running where, made the registration holding things
+// up.
+//
+// It's off by default because finding the goroutine means formatting a
+// stack trace on every registration, which is slow enough to notice in
+// tests that make a lot of them.
+func (mt *ManualTime) SetTrackOwnership(track bool) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.trackOwnership = track
+}
+
+// goroutineID returns the id of the calling goroutine, as the runtime
+// prints it in stack traces. Go deliberately doesn't expose this; it's
+// only fit for diagnostics like these.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// "goroutine 123 [running]:..."
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if end := bytes.IndexByte(buf, ' '); end >= 0 {
+		buf = buf[:end]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// origin introduces the creation stack of the registration in a report,
+// naming the goroutine that made it if it's known.
+func (r *registration) origin() string {
+	if r.goroutine != 0 {
+		return fmt.Sprintf("created by goroutine %d at", r.goroutine)
+	}
+	return "created at"
+}
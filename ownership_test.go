@@ -0,0 +1,45 @@
+package abtime
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackOwnership(t *testing.T) {
+	mt := NewManual()
+	mt.SetTrackOwnership(true)
+
+	me := goroutineID()
+	if me == 0 {
+		t.Fatal("couldn't find the goroutine id")
+	}
+	other := make(chan uint64)
+	go func() {
+		mt.NewTimer(time.Second, timerID)
+		other <- goroutineID()
+	}()
+	them := <-other
+	mt.After(time.Second, afterID)
+
+	registrations := mt.Registrations()
+	if len(registrations) != 2 ||
+		registrations[0].Goroutine != me || registrations[1].Goroutine != them ||
+		!strings.Contains(registrations[1].String(), fmt.Sprintf("(goroutine %d)", them)) {
+		t.Fatalf("unexpected registrations: %v", registrations)
+	}
+
+	history := mt.History()
+	if history[0].Goroutine != them || !strings.Contains(history[0].CreatedBy, "ownership_test.go:") ||
+		!strings.Contains(history[0].String(), fmt.Sprintf("by goroutine %d at", them)) {
+		t.Fatalf("unexpected history: %v", history)
+	}
+
+	rt := &recordingT{}
+	mt.VerifyNoPending(rt)
+	if len(rt.errors) != 1 ||
+		!strings.Contains(rt.errors[0], fmt.Sprintf("Timer with id 5, created by goroutine %d at:", them)) {
+		t.Fatalf("unexpected report: %v", rt.errors)
+	}
+}
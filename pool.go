@@ -0,0 +1,59 @@
+package abtime
+
+// TriggerOne fires exactly one registration under the id: the oldest live
+// one, just as Trigger would. Unlike Trigger, if there is no live
+// registration nothing is held for a later one, and it returns false.
+//
+// This suits pools of workers that all register under the same ID, where
+// the test wants to time out one worker at a time and know whether there
+// was one to time out.
+func (mt *ManualTime) TriggerOne(id ID) bool {
+	return mt.triggerLive(id, 1) == 1
+}
+
+// TriggerAll fires every live registration under the id, once each,
+// returning how many there were. Tickers receive a single tick. As with
+// TriggerOne, nothing is held if there are no live registrations.
+//
+// Registrations made by the code reacting to the firings are not fired;
+// they weren't live when TriggerAll was called.
+func (mt *ManualTime) TriggerAll(id ID) int {
+	return mt.triggerLive(id, -1)
+}
+
+// triggerLive fires up to max of the registrations currently live under
+// the id, or all of them if max is negative, returning how many fired.
+func (mt *ManualTime) triggerLive(id ID, max int) int {
+	mt.Lock()
+	defer mt.Unlock()
+
+	if mt.closed {
+		return 0
+	}
+	mt.expireStale()
+	mt.record(Triggered, id, "")
+	ti := mt.triggerInfo(id)
+	ti.prune()
+	live := append([]trigger(nil), ti.triggers...)
+
+	di := mt.deliveryInfo(id)
+	before := di.delivered
+	fired := 0
+	for _, trig := range live {
+		if fired == max {
+			break
+		}
+		if !trig.live() {
+			continue
+		}
+		if mt.fireOne(trig) {
+			mt.remove(trig)
+		}
+		fired++
+	}
+
+	if mt.delivery.synchronous {
+		mt.waitTargets(map[ID]int{id: di.consumed + di.delivered - before})
+	}
+	return fired
+}
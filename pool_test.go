@@ -0,0 +1,57 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTriggerOneAndAll(t *testing.T) {
+	mt := NewManual()
+
+	if mt.TriggerOne("worker") || mt.TriggerAll("worker") != 0 {
+		t.Fatal("fired with nothing registered")
+	}
+	if mt.PendingTriggers("worker") != 0 {
+		t.Fatal("trigger held with nothing registered")
+	}
+
+	timeouts := make([]<-chan time.Time, 4)
+	for i := range timeouts {
+		timeouts[i] = mt.After(time.Second, "worker")
+	}
+
+	if !mt.TriggerOne("worker") {
+		t.Fatal("TriggerOne fired nothing")
+	}
+	<-timeouts[0]
+
+	if fired := mt.TriggerAll("worker"); fired != 3 {
+		t.Fatalf("TriggerAll fired %d", fired)
+	}
+	for _, timeout := range timeouts[1:] {
+		<-timeout
+	}
+	if len(mt.PendingIDs()) != 0 {
+		t.Fatal("fired registrations left behind")
+	}
+
+	ticker := mt.NewTicker(time.Second, "worker")
+	defer ticker.Stop()
+	sleeping := make(chan struct{})
+	go func() {
+		mt.Sleep(time.Second, "worker")
+		close(sleeping)
+	}()
+	for len(mt.Pending("worker")) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	mt.SetDeliveryPolicy(Synchronous)
+	go func() { <-ticker.Channel() }()
+	if fired := mt.TriggerAll("worker"); fired != 2 {
+		t.Fatalf("TriggerAll fired %d", fired)
+	}
+	<-sleeping
+	if len(mt.Pending("worker")) != 1 {
+		t.Fatal("ticker did not stay registered")
+	}
+}
@@ -0,0 +1,36 @@
+package abtime
+
+import "time"
+
+// SleepWithProgress sleeps for d on the given clock, calling fn with the
+// remaining duration every interval along the way, so long waits can
+// report their progress.
+//
+// The intervals are timed with a Ticker, and the final partial interval
+// with a Sleep, both under the given id. On a ManualTime each Trigger of
+// the id therefore steps through one report, and the Trigger after the
+// last report ends the sleep. fn is not called when the sleep finishes.
+func SleepWithProgress(at AbstractTime, d, every time.Duration, fn func(remaining time.Duration), id ID) {
+	end := at.Now().Add(d)
+	remaining := d
+
+	if every > 0 && every < d {
+		ticker := at.NewTicker(every, id)
+		for remaining > every {
+			tick := <-ticker.Channel()
+			remaining = end.Sub(tick)
+			if remaining <= every {
+				// stop before reporting, so the next Trigger is
+				// sure to go to the Sleep
+				ticker.Stop()
+			}
+			if remaining > 0 {
+				fn(remaining)
+			}
+		}
+	}
+
+	if remaining > 0 {
+		at.Sleep(remaining, id)
+	}
+}
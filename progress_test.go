@@ -0,0 +1,38 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSleepWithProgress(t *testing.T) {
+	mt := NewManual()
+
+	reports := make(chan time.Duration)
+	done := make(chan struct{})
+	go func() {
+		SleepWithProgress(mt, 2500*time.Millisecond, time.Second, func(remaining time.Duration) {
+			reports <- remaining
+		}, sleepID)
+		close(done)
+	}()
+
+	for _, expected := range []time.Duration{1500 * time.Millisecond, 500 * time.Millisecond} {
+		mt.Trigger(sleepID)
+		if remaining := <-reports; remaining != expected {
+			t.Fatalf("reported %v remaining, expected %v", remaining, expected)
+		}
+	}
+	mt.Trigger(sleepID)
+	<-done
+
+	// and on the real clock, it all has to add up
+	count := 0
+	start := time.Now()
+	SleepWithProgress(NewRealTime(), 25*time.Millisecond, 10*time.Millisecond, func(time.Duration) {
+		count++
+	}, 0)
+	if count != 2 || time.Since(start) < 25*time.Millisecond {
+		t.Fatalf("real progress sleep made %d reports over %v", count, time.Since(start))
+	}
+}
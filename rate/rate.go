@@ -0,0 +1,230 @@
+/*
+Package rate provides a token-bucket rate limiter timed through an
+abtime.AbstractTime, so that code using it can be tested deterministically
+with a ManualTime.
+
+It follows the design of golang.org/x/time/rate, which can't have its clock
+injected: a Limiter allows events at a sustained rate, with bursts of up to
+a given size, refilling its bucket continuously as time passes.
+*/
+package rate
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// Limit is a rate of events per second.
+type Limit float64
+
+// Inf is the infinite rate limit; it allows all events.
+const Inf = Limit(math.MaxFloat64)
+
+// Every converts a minimum interval between events to a Limit.
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Inf
+	}
+	return 1 / Limit(interval.Seconds())
+}
+
+// ErrExceedsBurst is returned by Wait when the limiter could never allow
+// the event, because its burst size is zero.
+var ErrExceedsBurst = errors.New("rate: wait exceeds limiter's burst")
+
+// ErrWouldExceedDeadline is returned by Wait when the wait would outlast
+// the context's deadline.
+var ErrWouldExceedDeadline = errors.New("rate: wait would exceed context deadline")
+
+// A Limiter allows events at up to its Limit per second, with bursts of up
+// to its burst size. It is safe for concurrent use.
+type Limiter struct {
+	at    abtime.AbstractTime
+	id    abtime.ID
+	limit Limit
+	burst int
+
+	tokens float64
+	last   time.Time
+
+	mu sync.Mutex
+}
+
+// NewLimiter returns a Limiter allowing events at rate r, with bursts of
+// up to b, starting with a full bucket. Its time is read from the given
+// AbstractTime, and Wait sleeps on it under the given ID.
+func NewLimiter(at abtime.AbstractTime, r Limit, b int, id abtime.ID) *Limiter {
+	return &Limiter{
+		at:     at,
+		id:     id,
+		limit:  r,
+		burst:  b,
+		tokens: float64(b),
+		last:   at.Now(),
+	}
+}
+
+// Limit returns the limiter's rate.
+func (l *Limiter) Limit() Limit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+// Burst returns the limiter's burst size.
+func (l *Limiter) Burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.burst
+}
+
+// Allow reports whether an event may happen now, consuming a token if so.
+func (l *Limiter) Allow() bool {
+	return l.reserve(l.at.Now(), 0).ok
+}
+
+// Reserve reserves a token for an event, returning a Reservation saying
+// how long the caller must wait before acting. Reserve doesn't wait
+// itself; use Wait for that.
+func (l *Limiter) Reserve() *Reservation {
+	return l.reserve(l.at.Now(), time.Duration(math.MaxInt64))
+}
+
+// Wait blocks until an event may happen, consuming a token. It returns an
+// error, without consuming a token, if the context is done first or the
+// wait would outlast the context's deadline.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.Burst() <= 0 && l.Limit() != Inf {
+		return ErrExceedsBurst
+	}
+
+	now := l.at.Now()
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		maxWait = deadline.Sub(now)
+	}
+	r := l.reserve(now, maxWait)
+	if !r.ok {
+		return ErrWouldExceedDeadline
+	}
+
+	delay := r.timeToAct.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	if err := l.at.SleepContext(ctx, delay, l.id); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
+
+// advance returns the tokens the bucket would hold at the given time. The
+// lock must be held.
+func (l *Limiter) advance(now time.Time) float64 {
+	elapsed := now.Sub(l.last)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens := l.tokens + elapsed.Seconds()*float64(l.limit)
+	if burst := float64(l.burst); tokens > burst {
+		tokens = burst
+	}
+	return tokens
+}
+
+// reserve takes a token at the given time, if it will be available
+// within maxWait.
+func (l *Limiter) reserve(now time.Time, maxWait time.Duration) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == Inf {
+		return &Reservation{ok: true, lim: l, timeToAct: now}
+	}
+
+	tokens := l.advance(now) - 1
+	var wait time.Duration
+	if tokens < 0 {
+		if l.limit <= 0 {
+			return &Reservation{lim: l}
+		}
+		wait = time.Duration(-tokens / float64(l.limit) * float64(time.Second))
+	}
+	if l.burst < 1 || wait > maxWait {
+		return &Reservation{lim: l}
+	}
+
+	l.tokens = tokens
+	l.last = now
+	return &Reservation{ok: true, lim: l, timeToAct: now.Add(wait)}
+}
+
+// A Reservation is a token reserved by Reserve, usable once its delay has
+// passed.
+type Reservation struct {
+	ok        bool
+	lim       *Limiter
+	timeToAct time.Time
+	canceled  bool
+}
+
+// OK returns whether the limiter can ever provide the token. If it is
+// false, Delay is meaningless and the event should not happen.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long the caller must wait before acting, according to
+// the limiter's AbstractTime.
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(r.lim.at.Now())
+}
+
+// DelayFrom returns how long after the given time the caller must wait
+// before acting. A Reservation that is not OK returns the maximum
+// duration.
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !r.ok {
+		return time.Duration(math.MaxInt64)
+	}
+	delay := r.timeToAct.Sub(now)
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Cancel returns the reserved token to the limiter, for when the caller
+// decides not to act after all. This is simpler than x/time/rate's
+// version: the token is returned in full, up to the burst size.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.canceled || r.lim.limit == Inf {
+		return
+	}
+	r.canceled = true
+
+	l := r.lim
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.at.Now()
+	if now.Before(l.last) {
+		now = l.last
+	}
+	l.tokens = l.advance(now) + 1
+	if burst := float64(l.burst); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+}
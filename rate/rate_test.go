@@ -0,0 +1,90 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const waitID = "wait"
+
+func TestAllow(t *testing.T) {
+	mt := abtime.NewManual()
+	l := NewLimiter(mt, Every(time.Second), 2, waitID)
+
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("burst not allowed")
+	}
+	if l.Allow() {
+		t.Fatal("allowed beyond the burst")
+	}
+	mt.Advance(500 * time.Millisecond)
+	if l.Allow() {
+		t.Fatal("allowed before a token refilled")
+	}
+	mt.Advance(500 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("refilled token not allowed")
+	}
+
+	// the bucket never holds more than the burst
+	mt.Advance(time.Hour)
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Fatal("bucket overfilled")
+	}
+
+	if !NewLimiter(mt, Inf, 0, waitID).Allow() {
+		t.Fatal("infinite limit did not allow")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	mt := abtime.NewManual()
+	l := NewLimiter(mt, 10, 1, waitID)
+
+	if r := l.Reserve(); !r.OK() || r.Delay() != 0 {
+		t.Fatal("first reservation should be immediate")
+	}
+	r := l.Reserve()
+	if !r.OK() || r.Delay() != 100*time.Millisecond {
+		t.Fatalf("unexpected delay %v", r.Delay())
+	}
+	r.Cancel()
+	if r := l.Reserve(); r.Delay() != 100*time.Millisecond {
+		t.Fatalf("canceled reservation not returned: %v", r.Delay())
+	}
+
+	if NewLimiter(mt, 10, 0, waitID).Reserve().OK() {
+		t.Fatal("zero burst reservation should not be OK")
+	}
+}
+
+func TestWait(t *testing.T) {
+	mt := abtime.NewManual()
+	l := NewLimiter(mt, 1, 1, waitID)
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first wait failed: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- l.Wait(context.Background())
+	}()
+	mt.Trigger(waitID)
+	if err := <-done; err != nil {
+		t.Fatalf("second wait failed: %v", err)
+	}
+
+	ctx, cancel := mt.WithTimeout(context.Background(), time.Millisecond, "ctx")
+	defer cancel()
+	if err := l.Wait(ctx); err != ErrWouldExceedDeadline {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	if err := NewLimiter(mt, 1, 0, waitID).Wait(context.Background()); err != ErrExceedsBurst {
+		t.Fatalf("expected burst error, got %v", err)
+	}
+}
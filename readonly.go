@@ -0,0 +1,39 @@
+package abtime
+
+import (
+	"time"
+)
+
+// ReadOnlyTime is the part of an AbstractTime that observes the time
+// without scheduling anything: no timers, tickers, sleeps, or contexts.
+type ReadOnlyTime interface {
+	Clock
+	Since(time.Time) time.Duration
+	Until(time.Time) time.Duration
+}
+
+// ReadOnly returns a view of the AbstractTime that can only tell the time,
+// for handing to plugins or sandboxed components that must observe the
+// clock but not schedule on it.
+//
+// The view doesn't expose the AbstractTime it wraps, so a type assertion
+// can't recover it.
+func ReadOnly(at AbstractTime) ReadOnlyTime {
+	return readOnly{at}
+}
+
+type readOnly struct {
+	at AbstractTime
+}
+
+func (ro readOnly) Now() time.Time {
+	return ro.at.Now()
+}
+
+func (ro readOnly) Since(t time.Time) time.Duration {
+	return ro.at.Since(t)
+}
+
+func (ro readOnly) Until(t time.Time) time.Duration {
+	return ro.at.Until(t)
+}
@@ -0,0 +1,21 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadOnly(t *testing.T) {
+	mt := NewManual()
+	ro := ReadOnly(mt)
+
+	start := ro.Now()
+	mt.Advance(time.Minute)
+	if ro.Since(start) != time.Minute || ro.Until(start) != -time.Minute {
+		t.Fatal("read-only view doesn't follow the clock")
+	}
+
+	if _, schedules := ro.(AbstractTime); schedules {
+		t.Fatal("read-only view can be used as an AbstractTime")
+	}
+}
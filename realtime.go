@@ -11,7 +11,28 @@ func NewRealTime() RealTime {
 	return RealTime{}
 }
 
+// NewRealTimeWithSemantics returns a RealTime whose timers and tickers
+// follow the given TimerSemantics.
+func NewRealTimeWithSemantics(semantics TimerSemantics) RealTime {
+	return RealTime{semantics: semantics}
+}
+
+// NewRealTimeIn returns a RealTime whose Now reports the time in the given
+// location, as a server running in that zone would see it. Since RealTime
+// is a plain value, its location is fixed when it is made.
+//
+// Converting to a location strips the monotonic clock reading, so Since
+// and Until on its times use the wall clock.
+func NewRealTimeIn(loc *time.Location) RealTime {
+	return RealTime{location: loc}
+}
+
 // TimerWrap wraps a Timer-conforming wrapper around a *time.Timer.
+//
+// Like all the RealTime wrappers, it's a struct of a single pointer, which
+// Go stores directly in an interface value, so handing it back as a Timer
+// costs no allocation over the *time.Timer itself. Keep it that way;
+// TestRealTimeAllocs checks.
 type TimerWrap struct {
 	T *time.Timer
 }
@@ -32,46 +53,115 @@ func (tw TimerWrap) Reset(d time.Duration) bool {
 }
 
 // The RealTime object implements the direct calls to the time module.
-type RealTime struct{}
+type RealTime struct {
+	semantics TimerSemantics
+	location  *time.Location
+}
+
+// realClock reports that RealTime follows the real clock; see
+// CoalescedTime.
+func (rt RealTime) realClock() bool {
+	return true
+}
 
-// Now wraps time.Now.
+// Now wraps time.Now, in the RealTime's location if it has one.
 func (rt RealTime) Now() time.Time {
+	if rt.location != nil {
+		return time.Now().In(rt.location)
+	}
 	return time.Now()
 }
 
+// NowIn returns time.Now in the given location.
+func (rt RealTime) NowIn(loc *time.Location) time.Time {
+	return time.Now().In(loc)
+}
+
+// Location returns the location Now reports the time in; time.Local
+// unless the RealTime was made by NewRealTimeIn.
+func (rt RealTime) Location() *time.Location {
+	if rt.location != nil {
+		return rt.location
+	}
+	return time.Local
+}
+
+// Since wraps time.Since.
+func (rt RealTime) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Until wraps time.Until.
+func (rt RealTime) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
 // After wraps time.After.
-func (rt RealTime) After(d time.Duration, token int) <-chan time.Time {
+func (rt RealTime) After(d time.Duration, token ID) <-chan time.Time {
 	return time.After(d)
 }
 
 // Sleep wraps time.Sleep.
-func (rt RealTime) Sleep(d time.Duration, token int) {
+func (rt RealTime) Sleep(d time.Duration, token ID) {
 	time.Sleep(d)
 }
 
+// SleepContext sleeps for the given duration, unless the context is done
+// first, in which case it returns the context's error.
+func (rt RealTime) SleepContext(ctx context.Context, d time.Duration, token ID) error {
+	return sleepContext(ctx, d)
+}
+
+// sleepContext sleeps in real time for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Tick wraps time.Tick.
-func (rt RealTime) Tick(d time.Duration, token int) <-chan time.Time {
+func (rt RealTime) Tick(d time.Duration, token ID) <-chan time.Time {
 	return time.Tick(d) // nolint: megacheck
 }
 
 // NewTicker wraps time.NewTicker. It returns something conforming to the
 // abtime.Ticker interface.
-func (rt RealTime) NewTicker(d time.Duration, token int) Ticker {
+func (rt RealTime) NewTicker(d time.Duration, token ID) Ticker {
+	if rt.semantics == Go123Timers {
+		return drainingTicker{time.NewTicker(d)}
+	}
 	return tickerWrapper{time.NewTicker(d)}
 }
 
 // AfterFunc wraps time.AfterFunc. It returns something conforming to the
 // abtime.Timer interface.
-func (rt RealTime) AfterFunc(d time.Duration, f func(), token int) Timer {
+func (rt RealTime) AfterFunc(d time.Duration, f func(), token ID) Timer {
 	return TimerWrap{time.AfterFunc(d, f)}
 }
 
 // NewTimer wraps time.NewTimer. It returns something conforming to the
 // abtime.Timer interface.
-func (rt RealTime) NewTimer(d time.Duration, token int) Timer {
+func (rt RealTime) NewTimer(d time.Duration, token ID) Timer {
+	if rt.semantics == Go123Timers {
+		return drainingTimer{time.NewTimer(d)}
+	}
 	return TimerWrap{time.NewTimer(d)}
 }
 
+// NewTimerAt creates a timer that fires at the given time, by wrapping
+// time.NewTimer with the duration until then.
+func (rt RealTime) NewTimerAt(t time.Time, token ID) Timer {
+	return rt.NewTimer(time.Until(t), token)
+}
+
 type tickerWrapper struct {
 	*time.Ticker
 }
@@ -84,12 +174,85 @@ func (tw tickerWrapper) Reset(d time.Duration) {
 	tw.Ticker.Reset(d)
 }
 
+// drainingTimer implements Go123Timers for a *time.Timer, by draining any
+// stale value on Stop and Reset.
+type drainingTimer struct {
+	T *time.Timer
+}
+
+func (dt drainingTimer) Channel() <-chan time.Time {
+	return dt.T.C
+}
+
+func (dt drainingTimer) Stop() bool {
+	if dt.T.Stop() {
+		return true
+	}
+	// a value that was never received means the timer is still
+	// considered active
+	select {
+	case <-dt.T.C:
+		return true
+	default:
+		return false
+	}
+}
+
+func (dt drainingTimer) Reset(d time.Duration) bool {
+	active := dt.Stop()
+	dt.T.Reset(d)
+	return active
+}
+
+// drainingTicker implements Go123Timers for a *time.Ticker, by draining
+// any stale tick on Stop and Reset.
+type drainingTicker struct {
+	*time.Ticker
+}
+
+func (dt drainingTicker) Channel() <-chan time.Time {
+	return dt.C
+}
+
+func (dt drainingTicker) drain() {
+	select {
+	case <-dt.C:
+	default:
+	}
+}
+
+func (dt drainingTicker) Stop() {
+	dt.Ticker.Stop()
+	dt.drain()
+}
+
+func (dt drainingTicker) Reset(d time.Duration) {
+	dt.Ticker.Stop()
+	dt.drain()
+	dt.Ticker.Reset(d)
+}
+
+// WithCancel wraps context's normal WithCancel invocation.
+func (rt RealTime) WithCancel(parent context.Context, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithCancel(parent)
+}
+
 // WithDeadline wraps context's normal WithDeadline invocation.
-func (rt RealTime) WithDeadline(parent context.Context, deadline time.Time, _ int) (context.Context, context.CancelFunc) {
+func (rt RealTime) WithDeadline(parent context.Context, deadline time.Time, _ ID) (context.Context, context.CancelFunc) {
 	return context.WithDeadline(parent, deadline)
 }
 
 // WithTimeout wraps context's normal WithTimeout invocation.
-func (rt RealTime) WithTimeout(parent context.Context, timeout time.Duration, _ int) (context.Context, context.CancelFunc) {
+func (rt RealTime) WithTimeout(parent context.Context, timeout time.Duration, _ ID) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(parent, timeout)
 }
+
+// WithDeadlineCause wraps context's normal WithDeadlineCause invocation.
+func (rt RealTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithDeadlineCause(parent, deadline, cause)
+}
+
+// WithTimeoutCause wraps context's normal WithTimeoutCause invocation.
+func (rt RealTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(parent, timeout, cause)
+}
@@ -1,6 +1,8 @@
 package abtime
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -11,12 +13,23 @@ import (
 
 func TestConcrete(t *testing.T) {
 	rt := NewRealTime()
-	rt.Now()
+	now := rt.Now()
+	if rt.Since(now) < 0 || rt.Until(now) > 0 {
+		t.Fatal("Since/Until aren't working properly")
+	}
 
 	ch := rt.After(time.Nanosecond, 0)
 	<-ch
 
 	rt.Sleep(time.Nanosecond, 0)
+	if rt.SleepContext(context.Background(), time.Nanosecond, 0) != nil {
+		t.Fatal("SleepContext isn't working properly")
+	}
+	canceled, cancelSleep := context.WithCancel(context.Background())
+	cancelSleep()
+	if rt.SleepContext(canceled, time.Hour, 0) != context.Canceled {
+		t.Fatal("SleepContext ignores its context")
+	}
 
 	ch = rt.Tick(time.Nanosecond, 0)
 	<-ch
@@ -39,4 +52,183 @@ func TestConcrete(t *testing.T) {
 	}
 	timer.Reset(time.Millisecond)
 	timer.Stop()
+
+	timer = rt.NewTimerAt(rt.Now(), 0)
+	<-timer.Channel()
+
+	ctx, cancel := rt.WithCancel(context.Background(), 0)
+	cancel()
+	<-ctx.Done()
+
+	errSlow := errors.New("too slow")
+	ctx, cancel = rt.WithTimeoutCause(context.Background(), time.Nanosecond, errSlow, 0)
+	defer cancel()
+	<-ctx.Done()
+	if context.Cause(ctx) != errSlow {
+		t.Fatal("WithTimeoutCause doesn't report its cause")
+	}
+}
+
+func TestRealGo123Semantics(t *testing.T) {
+	rt := NewRealTimeWithSemantics(Go123Timers)
+
+	timer := rt.NewTimer(time.Nanosecond, 0)
+	time.Sleep(10 * time.Millisecond)
+	if !timer.Stop() {
+		t.Fatal("unreceived timer should count as active")
+	}
+	select {
+	case <-timer.Channel():
+		t.Fatal("stale value received after Stop")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if timer.Reset(time.Nanosecond) {
+		t.Fatal("stopped timer should not count as active")
+	}
+	<-timer.Channel()
+
+	ticker := rt.NewTicker(time.Nanosecond, 0)
+	time.Sleep(10 * time.Millisecond)
+	ticker.Reset(time.Hour)
+	select {
+	case <-ticker.Channel():
+		t.Fatal("stale tick received after Reset")
+	default:
+	}
+	ticker.Stop()
+}
+
+func TestRealTimeLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	rt := NewRealTimeIn(tokyo)
+	if rt.Location() != tokyo || rt.Now().Location() != tokyo || NewRealTime().Location() != time.Local {
+		t.Fatal("RealTime location not used")
+	}
+	if rt.NowIn(time.UTC).Location() != time.UTC {
+		t.Fatal("RealTime NowIn did not convert")
+	}
+}
+
+// The RealTime wrappers are all single-pointer structs, which the
+// compiler stores directly in an interface value without allocating. This
+// keeps it that way, for both timer semantics.
+func TestRealTimeAllocs(t *testing.T) {
+	f := func() {}
+	ctx := context.Background()
+	for _, rt := range []RealTime{NewRealTime(), NewRealTimeWithSemantics(Go123Timers)} {
+		var at AbstractTime = rt
+		pairs := []struct {
+			name        string
+			abtime, std func()
+		}{
+			{"NewTimer",
+				func() { at.NewTimer(time.Hour, timerID).Stop() },
+				func() { time.NewTimer(time.Hour).Stop() }},
+			{"AfterFunc",
+				func() { at.AfterFunc(time.Hour, f, afterFuncID).Stop() },
+				func() { time.AfterFunc(time.Hour, f).Stop() }},
+			{"NewTicker",
+				func() { at.NewTicker(time.Hour, tickID).Stop() },
+				func() { time.NewTicker(time.Hour).Stop() }},
+			{"WithTimeout",
+				func() { _, cancel := at.WithTimeout(ctx, time.Hour, contextID); cancel() },
+				func() { _, cancel := context.WithTimeout(ctx, time.Hour); cancel() }},
+			{"Now",
+				func() { at.Now() },
+				func() { time.Now() }},
+		}
+		for _, pair := range pairs {
+			got := testing.AllocsPerRun(100, pair.abtime)
+			want := testing.AllocsPerRun(100, pair.std)
+			if got > want {
+				t.Errorf("%s: %v allocations, time package does it in %v",
+					pair.name, got, want)
+			}
+		}
+	}
+}
+
+// The benchmarks below pair each RealTime call with the time package call
+// it wraps; the production path through abtime ought to cost the same.
+
+func BenchmarkRealTimeNewTimer(b *testing.B) {
+	var at AbstractTime = NewRealTime()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		at.NewTimer(time.Hour, timerID).Stop()
+	}
+}
+
+func BenchmarkTimeNewTimer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		time.NewTimer(time.Hour).Stop()
+	}
+}
+
+func BenchmarkRealTimeAfterFunc(b *testing.B) {
+	var at AbstractTime = NewRealTime()
+	f := func() {}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		at.AfterFunc(time.Hour, f, afterFuncID).Stop()
+	}
+}
+
+func BenchmarkTimeAfterFunc(b *testing.B) {
+	f := func() {}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		time.AfterFunc(time.Hour, f).Stop()
+	}
+}
+
+func BenchmarkRealTimeNewTicker(b *testing.B) {
+	var at AbstractTime = NewRealTime()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		at.NewTicker(time.Hour, tickID).Stop()
+	}
+}
+
+func BenchmarkTimeNewTicker(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		time.NewTicker(time.Hour).Stop()
+	}
+}
+
+func BenchmarkRealTimeWithTimeout(b *testing.B) {
+	var at AbstractTime = NewRealTime()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, cancel := at.WithTimeout(ctx, time.Hour, contextID)
+		cancel()
+	}
+}
+
+func BenchmarkTimeWithTimeout(b *testing.B) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, cancel := context.WithTimeout(ctx, time.Hour)
+		cancel()
+	}
+}
+
+func BenchmarkRealTimeNow(b *testing.B) {
+	var at AbstractTime = NewRealTime()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		at.Now()
+	}
+}
+
+func BenchmarkTimeNow(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		time.Now()
+	}
 }
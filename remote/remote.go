@@ -0,0 +1,251 @@
+/*
+Package remote lets a test harness in one process drive a ManualTime in
+another, for multi-process integration tests.
+
+The child process under test serves its ManualTime over whatever stream
+the harness can reach it by, such as a socket or a pair of pipes:
+
+	mt := abtime.NewManual()
+	server := remote.NewServer(mt, retryID, "billing.graceperiod")
+	go server.Serve(conn)
+
+and the harness drives it with a Client over the other end:
+
+	clock := remote.NewClient(conn)
+	err := clock.Trigger(retryID)
+	err = clock.Advance(time.Minute)
+
+The protocol is newline-delimited JSON, one Request answered by one
+Response, each carrying the ProtocolVersion so that a harness and child
+built against different versions of this package fail clearly rather than
+misunderstand each other.
+
+IDs have to cross the process boundary as text, so they are sent formatted
+with fmt.Sprint. The server resolves them against the IDs it was given and
+then the ManualTime's pending IDs, so any ID whose formatting is unique
+works; give the server the IDs it should know about even when nothing is
+registered under them, so Triggers can be held for them.
+*/
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+// ProtocolVersion is the version of the protocol spoken by this package.
+// It changes only when the meaning of a Request or Response does.
+const ProtocolVersion = 1
+
+// The operations a Request may ask for.
+const (
+	OpNow       = "now"
+	OpAdvance   = "advance"
+	OpAdvanceTo = "advance_to"
+	OpTrigger   = "trigger"
+	OpPending   = "pending"
+)
+
+// ErrVersion is returned when the two ends speak different versions of
+// the protocol.
+var ErrVersion = errors.New("remote: protocol version mismatch")
+
+// ErrUnknownID is returned when the server can't resolve an ID.
+var ErrUnknownID = errors.New("remote: unknown id")
+
+// A Request is one command to the server.
+type Request struct {
+	Version  int           `json:"version"`
+	Op       string        `json:"op"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Time     time.Time     `json:"time,omitempty"`
+	IDs      []string      `json:"ids,omitempty"`
+}
+
+// A Response answers a Request. Now is always the ManualTime's Now after
+// the Request was carried out; Pending is filled in for OpPending.
+type Response struct {
+	Version int       `json:"version"`
+	Now     time.Time `json:"now"`
+	Pending []string  `json:"pending,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// A Server carries out Requests on a ManualTime.
+type Server struct {
+	mt  *abtime.ManualTime
+	ids map[string]abtime.ID
+}
+
+// NewServer returns a Server for the ManualTime, resolving the given ids
+// by their formatting.
+func NewServer(mt *abtime.ManualTime, ids ...abtime.ID) *Server {
+	known := map[string]abtime.ID{}
+	for _, id := range ids {
+		known[fmt.Sprint(id)] = id
+	}
+	return &Server{mt, known}
+}
+
+// Serve reads Requests from the stream and writes their Responses, until
+// the stream ends, when it returns nil, or fails, when it returns the
+// error. A Request that can't be carried out is answered with its error;
+// it doesn't stop the Server.
+func (s *Server) Serve(conn io.ReadWriter) error {
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		var req Request
+		if err := decoder.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		resp := s.handle(req)
+		if err := encoder.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req Request) (resp Response) {
+	defer func() {
+		resp.Version = ProtocolVersion
+		resp.Now = s.mt.Now()
+		// the ManualTime panics on misuse, such as rewinding it; that's
+		// the harness's error, not reason to bring down the child
+		if r := recover(); r != nil {
+			resp.Error = fmt.Sprint(r)
+		}
+	}()
+
+	if req.Version != ProtocolVersion {
+		resp.Error = fmt.Sprintf("%v: server speaks %d, request is %d",
+			ErrVersion, ProtocolVersion, req.Version)
+		return resp
+	}
+	switch req.Op {
+	case OpNow:
+	case OpAdvance:
+		s.mt.Advance(req.Duration)
+	case OpAdvanceTo:
+		s.mt.AdvanceTo(req.Time)
+	case OpTrigger:
+		ids := make([]abtime.ID, len(req.IDs))
+		for i, name := range req.IDs {
+			id, known := s.resolve(name)
+			if !known {
+				resp.Error = fmt.Sprintf("%v: %q", ErrUnknownID, name)
+				return resp
+			}
+			ids[i] = id
+		}
+		s.mt.Trigger(ids...)
+	case OpPending:
+		resp.Pending = []string{}
+		for _, id := range s.mt.PendingIDs() {
+			resp.Pending = append(resp.Pending, fmt.Sprint(id))
+		}
+	default:
+		resp.Error = fmt.Sprintf("remote: unknown op %q", req.Op)
+	}
+	return resp
+}
+
+// resolve finds the ID formatted as name.
+func (s *Server) resolve(name string) (abtime.ID, bool) {
+	if id, known := s.ids[name]; known {
+		return id, true
+	}
+	for _, id := range s.mt.PendingIDs() {
+		if fmt.Sprint(id) == name {
+			return id, true
+		}
+	}
+	return nil, false
+}
+
+// A Client drives a ManualTime served by a Server in another process. It
+// is safe for concurrent use, though the Requests are carried out one at
+// a time.
+type Client struct {
+	reader *bufio.Reader
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+// NewClient returns a Client speaking to a Server over the stream.
+func NewClient(conn io.ReadWriter) *Client {
+	return &Client{reader: bufio.NewReader(conn), writer: conn}
+}
+
+// Do sends the Request, filling in its Version, and returns the Response.
+// A Response carrying an error is returned along with that error.
+func (c *Client) Do(req Request) (Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req.Version = ProtocolVersion
+	if err := json.NewEncoder(c.writer).Encode(req); err != nil {
+		return Response{}, err
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return Response{}, err
+	}
+	if resp.Version != ProtocolVersion {
+		return resp, fmt.Errorf("%w: client speaks %d, server %d",
+			ErrVersion, ProtocolVersion, resp.Version)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Now returns the remote ManualTime's Now.
+func (c *Client) Now() (time.Time, error) {
+	resp, err := c.Do(Request{Op: OpNow})
+	return resp.Now, err
+}
+
+// Advance advances the remote ManualTime by d.
+func (c *Client) Advance(d time.Duration) error {
+	_, err := c.Do(Request{Op: OpAdvance, Duration: d})
+	return err
+}
+
+// AdvanceTo advances the remote ManualTime to t.
+func (c *Client) AdvanceTo(t time.Time) error {
+	_, err := c.Do(Request{Op: OpAdvanceTo, Time: t})
+	return err
+}
+
+// Trigger triggers the given ids on the remote ManualTime, which must be
+// able to resolve them; see the package documentation.
+func (c *Client) Trigger(ids ...abtime.ID) error {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = fmt.Sprint(id)
+	}
+	_, err := c.Do(Request{Op: OpTrigger, IDs: names})
+	return err
+}
+
+// PendingIDs returns the formatted pending ids of the remote ManualTime.
+func (c *Client) PendingIDs() ([]string, error) {
+	resp, err := c.Do(Request{Op: OpPending})
+	return resp.Pending, err
+}
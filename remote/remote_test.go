@@ -0,0 +1,92 @@
+package remote
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+const (
+	retryID = iota
+	pollID
+)
+
+func TestRemote(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mt := abtime.NewManualAtTime(start)
+	mt.SetRewindPolicy(abtime.PanicOnRewind, nil)
+	served := make(chan error)
+	go func() {
+		served <- NewServer(mt, retryID, "grace").Serve(serverConn)
+		serverConn.Close()
+	}()
+	clock := NewClient(clientConn)
+
+	if err := clock.Advance(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := clock.AdvanceTo(start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	now, err := clock.Now()
+	if err != nil || !now.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected now %v, %v", now, err)
+	}
+
+	// a known id can be triggered before anything registers...
+	if err := clock.Trigger(retryID); err != nil {
+		t.Fatal(err)
+	}
+	<-mt.After(time.Second, retryID)
+
+	// ...and a pending one by its formatting
+	poll := mt.After(time.Second, pollID)
+	grace := mt.After(time.Second, "grace")
+	pending, err := clock.PendingIDs()
+	if err != nil || len(pending) != 2 || pending[0] != "1" || pending[1] != "grace" {
+		t.Fatalf("unexpected pending %v, %v", pending, err)
+	}
+	if err := clock.Trigger(pollID, "grace"); err != nil {
+		t.Fatal(err)
+	}
+	<-poll
+	<-grace
+
+	if err := clock.Trigger("nonesuch"); err == nil || !strings.Contains(err.Error(), "unknown id") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// misuse is reported, and the server carries on
+	if err := clock.AdvanceTo(start); err == nil || !strings.Contains(err.Error(), "backwards") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := clock.Do(Request{Op: "explode"}); err == nil {
+		t.Fatal("unknown op accepted")
+	}
+
+	clientConn.Close()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVersionMismatch(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		defer serverConn.Close()
+		var buf [512]byte
+		serverConn.Read(buf[:])
+		serverConn.Write([]byte(`{"version":2,"now":"2020-01-01T00:00:00Z"}` + "\n"))
+	}()
+
+	if _, err := NewClient(clientConn).Now(); !errors.Is(err, ErrVersion) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
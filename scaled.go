@@ -0,0 +1,262 @@
+package abtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ScaledTime is an AbstractTime backed by the real clock, but running at
+// a multiple of real speed. With a multiplier of 100, one real second
+// counts as 100 virtual seconds: Now advances 100 times faster, and
+// durations passed to After, Sleep, timers, tickers, and contexts are
+// scaled down by 100 before being handed to the real runtime.
+//
+// This sits between RealTime and ManualTime, and is useful for soak tests
+// that need hours of simulated traffic in minutes.
+//
+// Times delivered on timer and ticker channels are virtual times. Contexts
+// are real contexts, so their Deadline reports the real deadline.
+type ScaledTime struct {
+	multiplier   float64
+	realStart    time.Time
+	virtualStart time.Time
+
+	location *time.Location
+	mu       sync.Mutex
+}
+
+// NewScaledTime returns a ScaledTime running at the given multiple of real
+// speed, starting from the real Now.
+func NewScaledTime(multiplier float64) *ScaledTime {
+	return NewScaledTimeWithOffset(multiplier, 0)
+}
+
+// NewScaledTimeWithOffset returns a ScaledTime running at the given
+// multiple of real speed, starting from the real Now shifted by offset.
+func NewScaledTimeWithOffset(multiplier float64, offset time.Duration) *ScaledTime {
+	if multiplier <= 0 {
+		panic("scaled time multiplier must be positive")
+	}
+	now := time.Now()
+	return &ScaledTime{
+		multiplier:   multiplier,
+		realStart:    now,
+		virtualStart: now.Add(offset),
+	}
+}
+
+// real converts a virtual duration to a real one. A positive duration
+// never scales down to nothing, which tickers would panic on.
+func (st *ScaledTime) real(d time.Duration) time.Duration {
+	r := time.Duration(float64(d) / st.multiplier)
+	if d > 0 && r < 1 {
+		return 1
+	}
+	return r
+}
+
+// realTime converts a virtual instant to a real one.
+func (st *ScaledTime) realTime(t time.Time) time.Time {
+	return st.realStart.Add(st.real(t.Sub(st.virtualStart)))
+}
+
+// Now returns the virtual now, in the ScaledTime's location if it has
+// been given one.
+func (st *ScaledTime) Now() time.Time {
+	elapsed := time.Since(st.realStart)
+	now := st.virtualStart.Add(time.Duration(float64(elapsed) * st.multiplier))
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.location != nil {
+		return now.In(st.location)
+	}
+	return now
+}
+
+// NowIn returns the virtual now in the given location.
+func (st *ScaledTime) NowIn(loc *time.Location) time.Time {
+	return st.Now().In(loc)
+}
+
+// SetLocation sets the location Now reports the virtual time in.
+func (st *ScaledTime) SetLocation(loc *time.Location) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.location = loc
+}
+
+// Location returns the location Now reports the virtual time in.
+func (st *ScaledTime) Location() *time.Location {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.location != nil {
+		return st.location
+	}
+	return st.virtualStart.Location()
+}
+
+// Since returns the virtual time elapsed since t.
+func (st *ScaledTime) Since(t time.Time) time.Duration {
+	return st.Now().Sub(t)
+}
+
+// Until returns the virtual duration until t.
+func (st *ScaledTime) Until(t time.Time) time.Duration {
+	return t.Sub(st.Now())
+}
+
+// After returns a channel that receives the virtual Now after the scaled
+// duration.
+func (st *ScaledTime) After(d time.Duration, id ID) <-chan time.Time {
+	return st.NewTimer(d, id).Channel()
+}
+
+// Sleep sleeps for the scaled duration.
+func (st *ScaledTime) Sleep(d time.Duration, id ID) {
+	time.Sleep(st.real(d))
+}
+
+// SleepContext sleeps for the scaled duration, unless the context is done
+// first.
+func (st *ScaledTime) SleepContext(ctx context.Context, d time.Duration, id ID) error {
+	return sleepContext(ctx, st.real(d))
+}
+
+// Tick returns the channel of a scaled ticker.
+func (st *ScaledTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	return st.NewTicker(d, id).Channel()
+}
+
+// NewTicker returns a Ticker ticking at the scaled interval, delivering
+// virtual times.
+func (st *ScaledTime) NewTicker(d time.Duration, id ID) Ticker {
+	sticker := &scaledTicker{
+		st:     st,
+		ticker: time.NewTicker(st.real(d)),
+		c:      make(chan time.Time, 1),
+		stop:   make(chan struct{}),
+	}
+	go sticker.run(sticker.stop)
+	return sticker
+}
+
+// AfterFunc wraps time.AfterFunc with the scaled duration.
+func (st *ScaledTime) AfterFunc(d time.Duration, f func(), id ID) Timer {
+	return &scaledTimer{st: st, Timer: time.AfterFunc(st.real(d), f)}
+}
+
+// NewTimer returns a Timer firing after the scaled duration, delivering
+// the virtual time.
+func (st *ScaledTime) NewTimer(d time.Duration, id ID) Timer {
+	c := make(chan time.Time, 1)
+	timer := &scaledTimer{st: st, c: c}
+	timer.Timer = time.AfterFunc(st.real(d), func() {
+		select {
+		case c <- st.Now():
+		default:
+		}
+	})
+	return timer
+}
+
+// NewTimerAt returns a Timer firing at the given virtual time.
+func (st *ScaledTime) NewTimerAt(t time.Time, id ID) Timer {
+	return st.NewTimer(st.Until(t), id)
+}
+
+// WithCancel wraps context's normal WithCancel invocation.
+func (st *ScaledTime) WithCancel(parent context.Context, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithCancel(parent)
+}
+
+// WithDeadline returns a context canceled when the virtual deadline is
+// reached.
+func (st *ScaledTime) WithDeadline(parent context.Context, deadline time.Time, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(parent, st.realTime(deadline))
+}
+
+// WithTimeout returns a context canceled after the scaled timeout.
+func (st *ScaledTime) WithTimeout(parent context.Context, timeout time.Duration, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, st.real(timeout))
+}
+
+// WithDeadlineCause returns a context canceled with the given cause when
+// the virtual deadline is reached.
+func (st *ScaledTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithDeadlineCause(parent, st.realTime(deadline), cause)
+}
+
+// WithTimeoutCause returns a context canceled with the given cause after
+// the scaled timeout.
+func (st *ScaledTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, _ ID) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(parent, st.real(timeout), cause)
+}
+
+type scaledTimer struct {
+	st *ScaledTime
+	c  chan time.Time
+	*time.Timer
+}
+
+func (stimer *scaledTimer) Channel() <-chan time.Time {
+	return stimer.c
+}
+
+func (stimer *scaledTimer) Reset(d time.Duration) bool {
+	return stimer.Timer.Reset(stimer.st.real(d))
+}
+
+type scaledTicker struct {
+	st     *ScaledTime
+	ticker *time.Ticker
+	c      chan time.Time
+
+	// closing stop ends run; it is nil while the ticker is stopped
+	stop chan struct{}
+	mu   sync.Mutex
+}
+
+func (sticker *scaledTicker) run(stop chan struct{}) {
+	for {
+		select {
+		case <-sticker.ticker.C:
+			// like time.Ticker, drop ticks for slow receivers
+			select {
+			case sticker.c <- sticker.st.Now():
+			default:
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (sticker *scaledTicker) Channel() <-chan time.Time {
+	return sticker.c
+}
+
+func (sticker *scaledTicker) Reset(d time.Duration) {
+	sticker.mu.Lock()
+	defer sticker.mu.Unlock()
+
+	sticker.ticker.Reset(sticker.st.real(d))
+	if sticker.stop == nil {
+		sticker.stop = make(chan struct{})
+		go sticker.run(sticker.stop)
+	}
+}
+
+func (sticker *scaledTicker) Stop() {
+	sticker.mu.Lock()
+	defer sticker.mu.Unlock()
+
+	sticker.ticker.Stop()
+	if sticker.stop != nil {
+		close(sticker.stop)
+		sticker.stop = nil
+	}
+}
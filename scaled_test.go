@@ -0,0 +1,86 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScaledTime(t *testing.T) {
+	st := NewScaledTimeWithOffset(1000, time.Hour)
+	realStart := time.Now()
+	virtualStart := st.Now()
+	if virtualStart.Sub(realStart) < time.Hour-time.Second {
+		t.Fatal("offset not applied")
+	}
+
+	// a virtual minute should be 60ms of real time
+	st.Sleep(time.Minute, 0)
+	if time.Since(realStart) < 60*time.Millisecond {
+		t.Fatal("sleep was too short")
+	}
+	if st.Since(virtualStart) < time.Minute {
+		t.Fatal("virtual time did not advance at scale")
+	}
+
+	if fired := <-st.After(time.Minute, 0); fired.Sub(virtualStart) < 2*time.Minute {
+		t.Fatal("After did not deliver virtual time")
+	}
+
+	ticker := st.NewTicker(10*time.Second, 0)
+	<-ticker.Channel()
+	ticker.Reset(20 * time.Second)
+	<-ticker.Channel()
+	ticker.Stop()
+	ticker.Stop()
+
+	// a stopped ticker ticks again once Reset
+	select {
+	case <-ticker.Channel():
+	default:
+	}
+	ticker.Reset(10 * time.Second)
+	<-ticker.Channel()
+	ticker.Stop()
+
+	// a duration too short to scale still makes a working ticker
+	ticker = NewScaledTime(1e12).NewTicker(time.Nanosecond, 0)
+	<-ticker.Channel()
+	ticker.Stop()
+
+	timer := st.NewTimerAt(st.Now().Add(10*time.Second), 0)
+	<-timer.Channel()
+	if timer.Reset(time.Hour) || !timer.Stop() {
+		t.Fatal("timer Reset/Stop not passing through")
+	}
+
+	ran := make(chan struct{})
+	st.AfterFunc(time.Second, func() { close(ran) }, 0)
+	<-ran
+
+	ctx, cancel := st.WithTimeout(context.Background(), time.Second, 0)
+	<-ctx.Done()
+	cancel()
+	ctx, cancel = st.WithDeadline(context.Background(), st.Now().Add(time.Second), 0)
+	<-ctx.Done()
+	cancel()
+	ctx, cancel = st.WithCancel(context.Background(), 0)
+	cancel()
+	<-ctx.Done()
+
+	var at AbstractTime = st
+	<-at.Tick(time.Second, 0)
+	if at.Until(virtualStart) > 0 {
+		t.Fatal("Until is not virtual")
+	}
+}
+
+func TestScaledTimeLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	st := NewScaledTime(1)
+	st.SetLocation(tokyo)
+	if st.Location() != tokyo || st.Now().Location() != tokyo {
+		t.Fatal("ScaledTime location not used")
+	}
+}
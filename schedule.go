@@ -0,0 +1,252 @@
+package abtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Spec determines when a scheduled Job runs.
+type Spec interface {
+	// Next returns the first time the Job should run strictly after the
+	// given time, or the zero time if it never will again.
+	Next(time.Time) time.Time
+}
+
+// ParseSpec parses a schedule specification. It accepts:
+//
+//   - "@every <duration>", running at fixed intervals, with the
+//     duration in time.ParseDuration's format, such as "@every 1h30m".
+//   - Standard five-field cron expressions, "minute hour day-of-month
+//     month day-of-week", each field being "*" or a comma-separated list
+//     of numbers or ranges, optionally with a "/step". Months and days of
+//     the week are numeric, with Sunday as 0. As in cron, if both the day
+//     of the month and the day of the week are restricted, a day matching
+//     either one will do.
+//   - The cron shorthands @yearly, @monthly, @weekly, @daily, and
+//     @hourly.
+//
+// Cron expressions are evaluated in the location of the times they are
+// given.
+func ParseSpec(spec string) (Spec, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("abtime: schedule %q: %v", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("abtime: schedule %q: interval must be positive", spec)
+		}
+		return every(d), nil
+	}
+
+	switch spec {
+	case "@yearly", "@annually":
+		spec = "0 0 1 1 *"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@hourly":
+		spec = "0 * * * *"
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("abtime: schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+	cs := &cronSpec{}
+	bounds := []struct {
+		set      *uint64
+		min, max int
+	}{
+		{&cs.minute, 0, 59},
+		{&cs.hour, 0, 23},
+		{&cs.dom, 1, 31},
+		{&cs.month, 1, 12},
+		{&cs.dow, 0, 6},
+	}
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("abtime: schedule %q: %v", spec, err)
+		}
+		*bounds[i].set = set
+	}
+	cs.domStar = fields[2] == "*"
+	cs.dowStar = fields[4] == "*"
+	return cs, nil
+}
+
+// every is a Spec running at fixed intervals.
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// cronSpec is a parsed cron expression, each field a bitset of the
+// values it matches.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// parseCronField parses one comma-separated cron field into a bitset.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[slash+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			part = part[:slash]
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var errLow, errHigh error
+			low, errLow = strconv.Atoi(bounds[0])
+			high, errHigh = strconv.Atoi(bounds[1])
+			if errLow != nil || errHigh != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+		default:
+			var err error
+			low, err = strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			high = low
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (cs *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+	if cs.domStar || cs.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next finds the next matching minute by skipping whole months, days,
+// and hours that can't match, starting over whenever a larger unit rolls
+// over.
+func (cs *cronSpec) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+	for t.Year() <= yearLimit {
+		if cs.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !cs.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if cs.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if cs.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// A Job runs a function on a schedule, timed entirely through an
+// AbstractTime. With a ManualTime, each run happens when the Job's ID is
+// Triggered, so tests can assert exactly how many times it ran.
+type Job struct {
+	at   AbstractTime
+	spec Spec
+	fn   func()
+	id   ID
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Schedule parses the spec, as described in ParseSpec, and runs fn on
+// that schedule until the returned Job is stopped. Each run waits on a
+// timer registered under the given ID.
+//
+// Runs happen one at a time, in the Job's own goroutine. The time of each
+// run is computed from the later of the previous scheduled time and Now,
+// so runs missed while fn was busy are skipped rather than piling up.
+func Schedule(at AbstractTime, spec string, fn func(), id ID) (*Job, error) {
+	parsed, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{
+		at:   at,
+		spec: parsed,
+		fn:   fn,
+		id:   id,
+		stop: make(chan struct{}),
+	}
+	go job.run()
+	return job, nil
+}
+
+func (j *Job) run() {
+	previous := j.at.Now()
+	for {
+		next := j.spec.Next(previous)
+		if next.IsZero() {
+			return
+		}
+		timer := j.at.NewTimerAt(next, j.id)
+		select {
+		case <-timer.Channel():
+		case <-j.stop:
+			timer.Stop()
+			return
+		}
+		// both may have been ready
+		select {
+		case <-j.stop:
+			return
+		default:
+		}
+
+		j.fn()
+
+		previous = next
+		if now := j.at.Now(); now.After(previous) {
+			previous = now
+		}
+	}
+}
+
+// Stop stops the Job from running again. A run already in progress
+// completes.
+func (j *Job) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+}
@@ -0,0 +1,66 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSpec(t *testing.T) {
+	start := time.Date(2021, 1, 31, 10, 30, 15, 0, time.UTC)
+	for _, test := range []struct {
+		spec string
+		next time.Time
+	}{
+		{"@every 90s", start.Add(90 * time.Second)},
+		{"* * * * *", time.Date(2021, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2021, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either the 15th or a Wednesday
+		{"0 0 15 * 3", time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		spec, err := ParseSpec(test.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", test.spec, err)
+		}
+		if next := spec.Next(start); !next.Equal(test.next) {
+			t.Fatalf("%q: expected %v, got %v", test.spec, test.next, next)
+		}
+	}
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every -1s", "@every soon"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Fatalf("%q parsed", bad)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	mt := NewManualAtTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	runs := make(chan time.Time)
+	job, err := Schedule(mt, "@hourly", func() { runs <- mt.Now() }, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		mt.Advance(time.Hour)
+		mt.Trigger("job")
+		<-runs
+	}
+
+	job.Stop()
+	mt.Trigger("job")
+	select {
+	case <-runs:
+		t.Fatal("stopped job ran")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if _, err := Schedule(mt, "bogus", func() {}, "job"); err == nil {
+		t.Fatal("bad spec scheduled")
+	}
+}
@@ -0,0 +1,177 @@
+package abtime
+
+import (
+	"fmt"
+	"time"
+)
+
+// Scheduled is implemented by the Timers and Tickers returned by
+// ManualTime, so tests can assert not only that the code armed a timer,
+// but what it armed it for.
+type Scheduled interface {
+	// ScheduledAt returns the virtual time the timer is due to fire, or
+	// the ticker's next tick is due. This is purely informational;
+	// ManualTime still fires only on Trigger.
+	ScheduledAt() time.Time
+
+	// Duration returns the duration the timer was last armed with, or
+	// the ticker's interval.
+	Duration() time.Duration
+}
+
+// Pending describes a live registration, as returned by
+// ManualTime.Pending.
+type Pending struct {
+	// Kind names the kind of registration, such as "Timer" or "Sleep".
+	Kind string
+
+	// Duration is the duration the registration was armed with. For a
+	// ticker this is its interval; for a context with a deadline, the
+	// time from its creation to the deadline; for WithCancel contexts,
+	// zero.
+	Duration time.Duration
+
+	// At is the virtual time the registration is due to fire, or the
+	// zero time for WithCancel contexts.
+	At time.Time
+}
+
+// Pending returns the live registrations under the given id, in the order
+// Triggers will be consumed by them.
+func (mt *ManualTime) Pending(id ID) []Pending {
+	mt.Lock()
+	defer mt.Unlock()
+
+	ti, exists := mt.triggers[id]
+	if !exists {
+		return nil
+	}
+	ti.prune()
+	pending := make([]Pending, 0, len(ti.triggers))
+	for _, trig := range ti.triggers {
+		pending = append(pending, pendingOf(trig))
+	}
+	return pending
+}
+
+// A Registration describes one live registration on a ManualTime, as
+// returned by Registrations.
+type Registration struct {
+	ID ID
+	Pending
+
+	// CreatedBy is the file:line of the call that made the registration.
+	CreatedBy string
+
+	// Goroutine is the goroutine that made the registration, under
+	// SetTrackOwnership, and 0 otherwise.
+	Goroutine uint64
+}
+
+// String formats the registration as a line of a table of what the code
+// is waiting for.
+func (r Registration) String() string {
+	at := "-"
+	if !r.At.IsZero() {
+		at = r.At.Format(time.RFC3339Nano)
+	}
+	createdBy := r.CreatedBy
+	if r.Goroutine != 0 {
+		createdBy = fmt.Sprintf("%s (goroutine %d)", createdBy, r.Goroutine)
+	}
+	return fmt.Sprintf("%-12v %-9s %-12v %-35s %s", r.ID, r.Kind, r.Duration, at, createdBy)
+}
+
+// Registrations returns every live registration on the ManualTime,
+// grouped by ID in the same order as PendingIDs, and within an ID in the
+// order Triggers will be consumed by them. Printing them one per line
+// gives a table of what the code under test is waiting for, and when each
+// would fire if this were real time.
+func (mt *ManualTime) Registrations() []Registration {
+	mt.Lock()
+	defer mt.Unlock()
+
+	registrations := []Registration{}
+	for _, id := range mt.pendingIDs() {
+		for _, trig := range mt.triggers[id].triggers {
+			registrations = append(registrations, Registration{
+				ID:        id,
+				Pending:   pendingOf(trig),
+				CreatedBy: trig.reg().callSite(),
+				Goroutine: trig.reg().goroutine,
+			})
+		}
+	}
+	return registrations
+}
+
+// pendingOf describes the given registration.
+func pendingOf(trig trigger) Pending {
+	p := Pending{Kind: kind(trig)}
+	switch trig := trig.(type) {
+	case Scheduled:
+		p.Duration = trig.Duration()
+		p.At = trig.ScheduledAt()
+	case *afterTrigger:
+		p.Duration = trig.d
+		p.At = trig.created.Add(trig.d)
+	case *sleepTrigger:
+		p.Duration = trig.d
+		p.At = trig.created.Add(trig.d)
+	case *contextTrigger:
+		if trig.hasDeadline {
+			p.Duration = trig.deadline.Sub(trig.created)
+			p.At = trig.deadline
+		}
+	}
+	return p
+}
+
+// ScheduledAt returns the time the timer is due to fire, which is also the
+// time it will deliver, unless SetDeliverNow is in effect.
+func (tt *timerTrigger) ScheduledAt() time.Time {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return tt.initialNow.Add(tt.duration)
+}
+
+// Duration returns the duration the timer was last armed with.
+func (tt *timerTrigger) Duration() time.Duration {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return tt.duration
+}
+
+// ScheduledAt returns the time the function is due to run.
+func (af *afterFuncTrigger) ScheduledAt() time.Time {
+	af.Lock()
+	defer af.Unlock()
+
+	return af.created.Add(af.d)
+}
+
+// Duration returns the duration the AfterFunc was armed with.
+func (af *afterFuncTrigger) Duration() time.Duration {
+	af.Lock()
+	defer af.Unlock()
+
+	return af.d
+}
+
+// ScheduledAt returns the time of the ticker's next tick.
+func (tt *tickTrigger) ScheduledAt() time.Time {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return tt.now.Add(tt.d)
+}
+
+// Duration returns the ticker's interval.
+func (tt *tickTrigger) Duration() time.Duration {
+	tt.Lock()
+	defer tt.Unlock()
+
+	return tt.d
+}
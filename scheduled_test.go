@@ -0,0 +1,97 @@
+package abtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduled(t *testing.T) {
+	mt := NewManual()
+	start := mt.Now()
+
+	timer := mt.NewTimer(30*time.Second, timerID).(Scheduled)
+	if timer.Duration() != 30*time.Second || timer.ScheduledAt() != start.Add(30*time.Second) {
+		t.Fatalf("unexpected timer schedule: %v at %v", timer.Duration(), timer.ScheduledAt())
+	}
+	mt.Advance(time.Minute)
+	timer.(Timer).Reset(time.Second)
+	if timer.Duration() != time.Second || timer.ScheduledAt() != start.Add(time.Minute+time.Second) {
+		t.Fatal("Reset not reflected in the schedule")
+	}
+
+	af := mt.AfterFunc(time.Hour, func() {}, afterFuncID).(Scheduled)
+	if af.Duration() != time.Hour || af.ScheduledAt() != start.Add(time.Minute+time.Hour) {
+		t.Fatal("unexpected AfterFunc schedule")
+	}
+
+	ticker := mt.NewTicker(time.Second, tickID)
+	if ticker.(Scheduled).ScheduledAt() != start.Add(time.Minute+time.Second) {
+		t.Fatal("unexpected ticker schedule")
+	}
+	ticker.Stop()
+}
+
+func TestPendingSchedules(t *testing.T) {
+	mt := NewManual()
+	now := mt.Now()
+
+	mt.NewTimer(30*time.Second, "retry")
+	mt.After(time.Minute, "retry")
+	mt.WithTimeout(context.Background(), time.Hour, "retry")
+	mt.WithCancel(context.Background(), "retry")
+	stopped := mt.NewTimer(time.Second, "retry")
+	stopped.Stop()
+
+	expected := []Pending{
+		{"Timer", 30 * time.Second, now.Add(30 * time.Second)},
+		{"After", time.Minute, now.Add(time.Minute)},
+		{"Context", time.Hour, now.Add(time.Hour)},
+		{"Context", 0, time.Time{}},
+	}
+	pending := mt.Pending("retry")
+	if len(pending) != len(expected) {
+		t.Fatalf("unexpected pending registrations: %v", pending)
+	}
+	for i := range expected {
+		if pending[i] != expected[i] {
+			t.Fatalf("registration %d: expected %v, got %v", i, expected[i], pending[i])
+		}
+	}
+
+	if mt.Pending("nothing") != nil {
+		t.Fatal("pending registrations for an unused id")
+	}
+}
+
+func TestRegistrations(t *testing.T) {
+	mt := NewManual()
+	now := mt.Now()
+
+	mt.NewTimer(30*time.Second, "b")
+	mt.NewTicker(time.Second, "a")
+	_, cancel := mt.WithCancel(context.Background(), "c")
+	defer cancel()
+
+	registrations := mt.Registrations()
+	if len(registrations) != 3 {
+		t.Fatalf("unexpected registrations: %v", registrations)
+	}
+	ticker, timer, ctx := registrations[0], registrations[1], registrations[2]
+	if ticker.ID != "a" || ticker.Kind != "Ticker" || ticker.At != now.Add(time.Second) {
+		t.Fatalf("unexpected ticker registration: %v", ticker)
+	}
+	if timer.ID != "b" || timer.Duration != 30*time.Second || timer.At != now.Add(30*time.Second) {
+		t.Fatalf("unexpected timer registration: %v", timer)
+	}
+	if ctx.Kind != "Context" || !ctx.At.IsZero() {
+		t.Fatalf("unexpected context registration: %v", ctx)
+	}
+	if !strings.Contains(timer.CreatedBy, "scheduled_test.go:") {
+		t.Fatalf("unexpected call site %q", timer.CreatedBy)
+	}
+	if line := ctx.String(); !strings.Contains(line, "Context") || !strings.Contains(line, " - ") {
+		t.Fatalf("unexpected formatting %q", line)
+	}
+}
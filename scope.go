@@ -0,0 +1,70 @@
+package abtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// WithScope runs fn, then removes every registration made while it ran
+// that is still pending, so the code fn calls can't leave dangling timers
+// behind to interfere with what follows. Goroutines blocked in Sleep on
+// such a registration are released, and contexts are canceled.
+//
+// If failOnLeaks is true, leftover registrations also fail the test,
+// listed with the stacks that created them, which makes "this function
+// cleans up all of its timers" a one-line assertion.
+//
+// Registrations made by other goroutines while fn runs count as made
+// within the scope.
+func (mt *ManualTime) WithScope(t testing.TB, failOnLeaks bool, fn func()) {
+	t.Helper()
+
+	mt.Lock()
+	before := map[trigger]bool{}
+	for _, ti := range mt.triggers {
+		for _, trig := range ti.triggers {
+			before[trig] = true
+		}
+	}
+	mt.Unlock()
+
+	fn()
+
+	mt.Lock()
+	leaked := []trigger{}
+	for _, id := range mt.pendingIDs() {
+		ti := mt.triggers[id]
+		keep := ti.triggers[:0]
+		for _, trig := range ti.triggers {
+			if before[trig] {
+				keep = append(keep, trig)
+			} else {
+				leaked = append(leaked, trig)
+			}
+		}
+		for i := len(keep); i < len(ti.triggers); i++ {
+			ti.triggers[i] = nil
+		}
+		ti.triggers = keep
+	}
+
+	report := []string{}
+	for _, trig := range leaked {
+		switch leak := trig.(type) {
+		case *sleepTrigger:
+			leak.trigger(mt)
+		case *contextTrigger:
+			leak.cancel(context.Canceled)
+		}
+		report = append(report, fmt.Sprintf("%s with id %s, %s:\n%s",
+			kind(trig), mt.idString(trig.reg().id), trig.reg().origin(), trig.reg().creation()))
+	}
+	mt.Unlock()
+
+	if failOnLeaks && len(report) > 0 {
+		t.Errorf("%d registrations outlived their scope:\n%s",
+			len(report), strings.Join(report, "\n"))
+	}
+}
@@ -0,0 +1,47 @@
+package abtime
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithScope(t *testing.T) {
+	mt := NewManual()
+	outside := mt.NewTimer(time.Second, "outside")
+
+	// a tidy function passes
+	mt.WithScope(t, true, func() {
+		timer := mt.NewTimer(time.Second, timerID)
+		timer.Stop()
+	})
+
+	// a leaky one fails, and is cleaned up after
+	rt := &recordingT{TB: t}
+	slept := make(chan struct{})
+	var ctx context.Context
+	mt.WithScope(rt, true, func() {
+		mt.NewTimer(time.Second, timerID)
+		ctx, _ = mt.WithCancel(context.Background(), contextID)
+		go func() {
+			mt.Sleep(time.Second, sleepID)
+			close(slept)
+		}()
+		for len(mt.PendingIDs()) < 4 {
+			time.Sleep(time.Millisecond)
+		}
+	})
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "3 registrations outlived their scope") {
+		t.Fatalf("unexpected failures: %v", rt.errors)
+	}
+	<-slept
+	<-ctx.Done()
+
+	// registrations from before the scope are untouched
+	if pending := mt.PendingIDs(); len(pending) != 1 || pending[0] != "outside" {
+		t.Fatalf("unexpected pending ids: %v", pending)
+	}
+	mt.Trigger("outside")
+	<-outside.Channel()
+}
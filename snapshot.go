@@ -0,0 +1,73 @@
+package abtime
+
+import "time"
+
+// A State is a snapshot of a ManualTime, taken by Snapshot and put back by
+// Restore.
+type State struct {
+	now       time.Time
+	nows      []time.Time
+	mono      time.Duration
+	epochs    []wallEpoch
+	triggers  map[ID]*triggerInfo
+	deadlines []*contextTrigger
+}
+
+// Now returns the Now the snapshot was taken at.
+func (s State) Now() time.Time {
+	return s.now
+}
+
+// copyTriggers copies a registration table, down to the queues.
+func copyTriggers(triggers map[ID]*triggerInfo) map[ID]*triggerInfo {
+	copied := make(map[ID]*triggerInfo, len(triggers))
+	for id, ti := range triggers {
+		copied[id] = &triggerInfo{
+			count:    ti.count,
+			triggers: append([]trigger(nil), ti.triggers...),
+		}
+	}
+	return copied
+}
+
+// Snapshot captures the ManualTime's Now, its queued Nows, and its table
+// of registrations and held Triggers, to be put back later with Restore.
+// This makes it cheap for table-driven tests to branch from a common
+// setup, rather than rebuilding the clock for every case.
+//
+// Registrations are captured by reference, not copied. Restoring brings
+// back the queues as they were, but a registration that has since fired
+// or been stopped stays that way, and is discarded as usual. Settings,
+// History, and delivery counts are not part of the snapshot.
+func (mt *ManualTime) Snapshot() State {
+	mt.Lock()
+	defer mt.Unlock()
+
+	return State{
+		now:       mt.now,
+		nows:      append([]time.Time(nil), mt.nows...),
+		mono:      mt.mono,
+		epochs:    append([]wallEpoch(nil), mt.epochs...),
+		triggers:  copyTriggers(mt.triggers),
+		deadlines: append([]*contextTrigger(nil), mt.deadlines...),
+	}
+}
+
+// Restore puts the ManualTime back into a State taken by Snapshot. Moving
+// Now back this way is not subject to the RewindPolicy, though it is
+// recorded as an advance.
+//
+// A State may be restored any number of times, but only to the ManualTime
+// it came from.
+func (mt *ManualTime) Restore(s State) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	mt.now = s.now
+	mt.nows = append([]time.Time{}, s.nows...)
+	mt.mono = s.mono
+	mt.epochs = append([]wallEpoch(nil), s.epochs...)
+	mt.triggers = copyTriggers(s.triggers)
+	mt.deadlines = append([]*contextTrigger(nil), s.deadlines...)
+	mt.nowMoved()
+}
@@ -0,0 +1,78 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	mt := NewManual()
+	mt.Advance(time.Hour)
+	start := mt.Now()
+	mt.QueueNows(start.Add(time.Second), start.Add(2*time.Second))
+	timer := mt.NewTimer(time.Minute, timerID)
+
+	state := mt.Snapshot()
+	if state.Now() != start {
+		t.Fatal("snapshot has the wrong Now")
+	}
+
+	for _, advance := range []time.Duration{time.Minute, time.Hour} {
+		mt.Restore(state)
+
+		// each branch consumes the queued Nows, advances, and makes
+		// registrations of its own
+		mt.Now()
+		mt.Now()
+		mt.Advance(advance)
+		mt.NewTimer(time.Second, afterID)
+		mt.Trigger(sleepID)
+
+		mt.Restore(state)
+		if mt.Now() != start.Add(time.Second) || mt.Now() != start.Add(2*time.Second) {
+			t.Fatal("queued Nows not restored")
+		}
+		if mt.Since(start) != 2*time.Second {
+			t.Fatalf("monotonic clock not restored: %v", mt.Since(start))
+		}
+		pending := mt.PendingIDs()
+		if len(pending) != 1 || pending[0] != timerID {
+			t.Fatalf("registrations not restored: %v", pending)
+		}
+	}
+
+	// the restored registration still works
+	go mt.Trigger(timerID)
+	<-timer.Channel()
+	mt.Restore(state)
+	if len(mt.PendingIDs()) != 0 {
+		t.Fatal("fired registration came back")
+	}
+}
+
+func TestRestoreAfterFire(t *testing.T) {
+	mt := NewManual()
+	after := mt.After(time.Second, afterID)
+	slept := make(chan struct{})
+	go func() {
+		mt.Sleep(time.Second, sleepID)
+		close(slept)
+	}()
+	for len(mt.PendingIDs()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	state := mt.Snapshot()
+	mt.Trigger(afterID, sleepID)
+	<-after
+	<-slept
+
+	mt.Restore(state)
+	if pending := mt.PendingIDs(); len(pending) != 0 {
+		t.Fatalf("fired After and Sleep came back: %v", pending)
+	}
+	mt.Trigger(afterID, sleepID)
+	if mt.Delivered(afterID) != 1 || mt.Delivered(sleepID) != 1 {
+		t.Fatal("restored After or Sleep fired again")
+	}
+}
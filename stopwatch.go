@@ -0,0 +1,86 @@
+package abtime
+
+import (
+	"sync"
+	"time"
+)
+
+// A Stopwatch measures elapsed time by the Now of its AbstractTime. Each
+// operation reads Now exactly once, so with a ManualTime, measurements can
+// be scripted precisely with QueueNows, rather than asserting on durations
+// that depend on how fast the test happened to run.
+type Stopwatch struct {
+	at      AbstractTime
+	running bool
+	started time.Time
+	lap     time.Time
+	elapsed time.Duration
+	mu      sync.Mutex
+}
+
+// NewStopwatch returns a new, stopped Stopwatch.
+func NewStopwatch(at AbstractTime) *Stopwatch {
+	return &Stopwatch{at: at}
+}
+
+// Start starts the stopwatch running, adding to any time already
+// accumulated. Starting a running Stopwatch does nothing.
+func (sw *Stopwatch) Start() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.running {
+		return
+	}
+	sw.running = true
+	sw.started = sw.at.Now()
+	sw.lap = sw.started
+}
+
+// Stop stops the stopwatch, returning the total elapsed time.
+func (sw *Stopwatch) Stop() time.Duration {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.running {
+		sw.elapsed += sw.at.Now().Sub(sw.started)
+		sw.running = false
+	}
+	return sw.elapsed
+}
+
+// Lap returns the time since the last Lap, or since the Stopwatch was
+// started if there has been none, and starts a new lap. It returns 0 if
+// the Stopwatch isn't running.
+func (sw *Stopwatch) Lap() time.Duration {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if !sw.running {
+		return 0
+	}
+	now := sw.at.Now()
+	lap := now.Sub(sw.lap)
+	sw.lap = now
+	return lap
+}
+
+// Elapsed returns the total time the Stopwatch has been running.
+func (sw *Stopwatch) Elapsed() time.Duration {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if !sw.running {
+		return sw.elapsed
+	}
+	return sw.elapsed + sw.at.Now().Sub(sw.started)
+}
+
+// Reset stops the Stopwatch and clears its elapsed time.
+func (sw *Stopwatch) Reset() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.running = false
+	sw.elapsed = 0
+}
@@ -0,0 +1,46 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStopwatch(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mt := NewManualAtTime(start)
+	sw := NewStopwatch(mt)
+
+	mt.QueueNows(
+		start,                            // Start
+		start.Add(time.Second),           // Lap
+		start.Add(3*time.Second),         // Lap
+		start.Add(4*time.Second),         // Stop
+		start.Add(time.Hour),             // Start
+		start.Add(time.Hour+time.Second), // Elapsed
+	)
+
+	sw.Start()
+	if lap := sw.Lap(); lap != time.Second {
+		t.Fatalf("first lap: %v", lap)
+	}
+	if lap := sw.Lap(); lap != 2*time.Second {
+		t.Fatalf("second lap: %v", lap)
+	}
+	if elapsed := sw.Stop(); elapsed != 4*time.Second {
+		t.Fatalf("stopped at %v", elapsed)
+	}
+	if sw.Lap() != 0 || sw.Elapsed() != 4*time.Second {
+		t.Fatal("stopped stopwatch still running")
+	}
+
+	// time while stopped doesn't count
+	sw.Start()
+	if elapsed := sw.Elapsed(); elapsed != 5*time.Second {
+		t.Fatalf("restarted stopwatch elapsed %v", elapsed)
+	}
+
+	sw.Reset()
+	if sw.Elapsed() != 0 {
+		t.Fatal("Reset did not clear the stopwatch")
+	}
+}
@@ -0,0 +1,112 @@
+//go:build tinygo || abtime_tiny
+// +build tinygo abtime_tiny
+
+/*
+Package tiny provides minimal clocks for embedded and tinygo targets,
+where the full machinery of abtime, with its contexts, registries, and
+testing support, is too heavy.
+
+The clocks here offer only Now, Sleep, and After. Their times are kept as
+plain nanosecond counts, and they satisfy abtime.Clock. Sleep and After
+take an id just as abtime's do, so code written against either reads the
+same, but the ids are ignored: a Manual fires everything that is due when
+it is Advanced, rather than by Trigger.
+
+This package is only built for tinygo, or with the abtime_tiny build tag.
+*/
+package tiny
+
+import (
+	"sync"
+	"time"
+)
+
+// Real is a minimal clock backed by the time package.
+type Real struct{}
+
+// Now wraps time.Now.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep wraps time.Sleep.
+func (Real) Sleep(d time.Duration, id interface{}) {
+	time.Sleep(d)
+}
+
+// After wraps time.After.
+func (Real) After(d time.Duration, id interface{}) <-chan time.Time {
+	return time.After(d)
+}
+
+// Manual is a minimal clock under the test's control. Now only moves when
+// Advanced, and Sleeps and Afters fire once it has been Advanced past
+// their deadline.
+type Manual struct {
+	now     int64
+	waiters []waiter
+	mu      sync.Mutex
+}
+
+type waiter struct {
+	at int64
+	c  chan time.Time
+}
+
+// NewManual returns a new Manual set to the given time. The times it
+// returns are in the local time zone.
+func NewManual(now time.Time) *Manual {
+	return &Manual{now: now.UnixNano()}
+}
+
+// Now returns the Manual's current time.
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return time.Unix(0, m.now)
+}
+
+// Advance moves the Manual's time forward, firing everything that is now
+// due.
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now += int64(d)
+	keep := m.waiters[:0]
+	for _, w := range m.waiters {
+		if w.at > m.now {
+			keep = append(keep, w)
+			continue
+		}
+		w.c <- time.Unix(0, w.at)
+	}
+	for i := len(keep); i < len(m.waiters); i++ {
+		m.waiters[i] = waiter{}
+	}
+	m.waiters = keep
+}
+
+// After returns a channel that receives the deadline once the Manual has
+// been Advanced to it. The id is ignored.
+func (m *Manual) After(d time.Duration, id interface{}) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// buffered so Advance never blocks, as with time.After
+	c := make(chan time.Time, 1)
+	at := m.now + int64(d)
+	if d <= 0 {
+		c <- time.Unix(0, at)
+		return c
+	}
+	m.waiters = append(m.waiters, waiter{at, c})
+	return c
+}
+
+// Sleep blocks until the Manual has been Advanced by the duration. The id
+// is ignored.
+func (m *Manual) Sleep(d time.Duration, id interface{}) {
+	<-m.After(d, id)
+}
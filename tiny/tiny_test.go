@@ -0,0 +1,58 @@
+//go:build tinygo || abtime_tiny
+// +build tinygo abtime_tiny
+
+package tiny
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thejerf/abtime"
+)
+
+var (
+	_ abtime.Clock = Real{}
+	_ abtime.Clock = &Manual{}
+)
+
+func TestManual(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewManual(start)
+
+	minute := m.After(time.Minute, 0)
+	hour := m.After(time.Hour, 0)
+	if !m.Now().Equal(start) {
+		t.Fatal("Now is not the starting time")
+	}
+
+	m.Advance(time.Minute)
+	if fired := <-minute; !fired.Equal(start.Add(time.Minute)) {
+		t.Fatalf("After delivered %v", fired)
+	}
+	select {
+	case <-hour:
+		t.Fatal("After fired early")
+	default:
+	}
+
+	slept := make(chan struct{})
+	go func() {
+		m.Sleep(time.Second, 0)
+		close(slept)
+	}()
+	for {
+		m.mu.Lock()
+		waiting := len(m.waiters)
+		m.mu.Unlock()
+		if waiting == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Advance(time.Hour)
+	<-slept
+	<-hour
+
+	Real{}.Sleep(time.Nanosecond, 0)
+	<-Real{}.After(time.Nanosecond, 0)
+}
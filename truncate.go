@@ -0,0 +1,254 @@
+package abtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TruncatedTime decorates another AbstractTime, truncating every time it
+// returns to a multiple of the given resolution, as time.Time.Truncate
+// does. This emulates databases and platforms with coarser clocks, so
+// tests can catch the comparison bugs that come from round-tripping
+// timestamps through them.
+//
+// Times delivered on timer and ticker channels are truncated by a
+// goroutine forwarding them from the underlying channel, which exists
+// only while the timer or ticker is armed. Context deadlines are
+// truncated as well.
+type TruncatedTime struct {
+	AbstractTime
+	resolution time.Duration
+}
+
+// NewTruncatedTime wraps the given AbstractTime, truncating times to the
+// given resolution.
+func NewTruncatedTime(at AbstractTime, resolution time.Duration) TruncatedTime {
+	return TruncatedTime{at, resolution}
+}
+
+// realClock reports whether the wrapped clock follows the real clock; see
+// CoalescedTime.
+func (tt TruncatedTime) realClock() bool {
+	return isRealClock(tt.AbstractTime)
+}
+
+// Now returns the truncated Now.
+func (tt TruncatedTime) Now() time.Time {
+	return tt.AbstractTime.Now().Truncate(tt.resolution)
+}
+
+// NowIn returns the truncated Now in the given location.
+func (tt TruncatedTime) NowIn(loc *time.Location) time.Time {
+	return tt.Now().In(loc)
+}
+
+// Since returns the time elapsed since t, according to the truncated Now.
+func (tt TruncatedTime) Since(t time.Time) time.Duration {
+	return tt.Now().Sub(t)
+}
+
+// Until returns the duration until t, according to the truncated Now.
+func (tt TruncatedTime) Until(t time.Time) time.Duration {
+	return t.Sub(tt.Now())
+}
+
+// After wraps After, truncating the delivered time. The channel is
+// buffered, as time.After's is, so the forwarding goroutine never waits on
+// a receiver that has gone away, and is closed if the underlying one is.
+func (tt TruncatedTime) After(d time.Duration, id ID) <-chan time.Time {
+	inner := tt.AbstractTime.After(d, id)
+	out := make(chan time.Time, 1)
+	go func() {
+		fired, ok := <-inner
+		if !ok {
+			close(out)
+			return
+		}
+		out <- fired.Truncate(tt.resolution)
+	}()
+	return out
+}
+
+// Tick wraps Tick, truncating the delivered times.
+func (tt TruncatedTime) Tick(d time.Duration, id ID) <-chan time.Time {
+	return tt.NewTicker(d, id).Channel()
+}
+
+// NewTicker wraps NewTicker, truncating the delivered times.
+func (tt TruncatedTime) NewTicker(d time.Duration, id ID) Ticker {
+	ticker := &truncatedTicker{
+		Ticker:     tt.AbstractTime.NewTicker(d, id),
+		resolution: tt.resolution,
+		c:          make(chan time.Time),
+		stop:       make(chan struct{}),
+	}
+	go ticker.forward(ticker.stop)
+	return ticker
+}
+
+// NewTimer wraps NewTimer, truncating the delivered time.
+func (tt TruncatedTime) NewTimer(d time.Duration, id ID) Timer {
+	return newTruncatedTimer(tt.AbstractTime.NewTimer(d, id), tt.resolution)
+}
+
+// NewTimerAt wraps NewTimerAt, truncating the delivered time.
+func (tt TruncatedTime) NewTimerAt(t time.Time, id ID) Timer {
+	return newTruncatedTimer(tt.AbstractTime.NewTimerAt(t, id), tt.resolution)
+}
+
+// WithDeadline wraps WithDeadline, truncating the context's deadline.
+func (tt TruncatedTime) WithDeadline(parent context.Context, deadline time.Time, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := tt.AbstractTime.WithDeadline(parent, deadline, id)
+	return truncatedContext{ctx, tt.resolution}, cancel
+}
+
+// WithTimeout wraps WithTimeout, truncating the context's deadline.
+func (tt TruncatedTime) WithTimeout(parent context.Context, timeout time.Duration, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := tt.AbstractTime.WithTimeout(parent, timeout, id)
+	return truncatedContext{ctx, tt.resolution}, cancel
+}
+
+// WithDeadlineCause wraps WithDeadlineCause, truncating the context's
+// deadline.
+func (tt TruncatedTime) WithDeadlineCause(parent context.Context, deadline time.Time, cause error, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := tt.AbstractTime.WithDeadlineCause(parent, deadline, cause, id)
+	return truncatedContext{ctx, tt.resolution}, cancel
+}
+
+// WithTimeoutCause wraps WithTimeoutCause, truncating the context's
+// deadline.
+func (tt TruncatedTime) WithTimeoutCause(parent context.Context, timeout time.Duration, cause error, id ID) (context.Context, context.CancelFunc) {
+	ctx, cancel := tt.AbstractTime.WithTimeoutCause(parent, timeout, cause, id)
+	return truncatedContext{ctx, tt.resolution}, cancel
+}
+
+type truncatedContext struct {
+	context.Context
+	resolution time.Duration
+}
+
+func (tc truncatedContext) Deadline() (time.Time, bool) {
+	deadline, hasDeadline := tc.Context.Deadline()
+	return deadline.Truncate(tc.resolution), hasDeadline
+}
+
+type truncatedTicker struct {
+	Ticker
+	resolution time.Duration
+	c          chan time.Time
+
+	// closing stop ends forward; it is nil while the ticker is stopped
+	stop chan struct{}
+	mu   sync.Mutex
+}
+
+func (tt *truncatedTicker) forward(stop chan struct{}) {
+	for {
+		select {
+		case tick := <-tt.Ticker.Channel():
+			select {
+			case tt.c <- tick.Truncate(tt.resolution):
+			case <-stop:
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (tt *truncatedTicker) Channel() <-chan time.Time {
+	return tt.c
+}
+
+func (tt *truncatedTicker) Reset(d time.Duration) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.Ticker.Reset(d)
+	if tt.stop == nil {
+		tt.stop = make(chan struct{})
+		go tt.forward(tt.stop)
+	}
+}
+
+func (tt *truncatedTicker) Stop() {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.Ticker.Stop()
+	if tt.stop != nil {
+		close(tt.stop)
+		tt.stop = nil
+	}
+}
+
+type truncatedTimer struct {
+	Timer
+	resolution time.Duration
+	c          chan time.Time
+
+	// the forwarding goroutine runs while the timer is armed; closing
+	// stop ends it
+	running bool
+	stop    chan struct{}
+	mu      sync.Mutex
+}
+
+func newTruncatedTimer(inner Timer, resolution time.Duration) *truncatedTimer {
+	timer := &truncatedTimer{Timer: inner, resolution: resolution, c: make(chan time.Time)}
+	timer.start()
+	return timer
+}
+
+// start starts the forwarding goroutine, if it isn't running. The lock
+// must be held, or the timer not yet shared.
+func (tt *truncatedTimer) start() {
+	if tt.running {
+		return
+	}
+	tt.running = true
+	stop := make(chan struct{})
+	tt.stop = stop
+	go func() {
+		select {
+		case fired := <-tt.Timer.Channel():
+			select {
+			case tt.c <- fired.Truncate(tt.resolution):
+			case <-stop:
+			}
+		case <-stop:
+		}
+		tt.mu.Lock()
+		if tt.stop == stop {
+			tt.running = false
+		}
+		tt.mu.Unlock()
+	}()
+}
+
+func (tt *truncatedTimer) Channel() <-chan time.Time {
+	return tt.c
+}
+
+func (tt *truncatedTimer) Stop() bool {
+	ret := tt.Timer.Stop()
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.running {
+		close(tt.stop)
+		tt.running = false
+	}
+	return ret
+}
+
+func (tt *truncatedTimer) Reset(d time.Duration) bool {
+	ret := tt.Timer.Reset(d)
+
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	tt.start()
+	return ret
+}
@@ -0,0 +1,69 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTruncatedTime(t *testing.T) {
+	base := time.Date(2012, 3, 28, 12, 0, 0, 0, time.UTC)
+	mt := NewManualAtTime(base.Add(1234567 * time.Nanosecond))
+	at := NewTruncatedTime(mt, time.Millisecond)
+
+	if at.Now() != base.Add(time.Millisecond) {
+		t.Fatalf("Now not truncated: %v", at.Now())
+	}
+	if at.Since(base) != time.Millisecond || at.Until(base) != -time.Millisecond {
+		t.Fatal("Since/Until not based on the truncated Now")
+	}
+
+	ch := at.After(time.Microsecond, afterID)
+	mt.Trigger(afterID)
+	if fired := <-ch; fired != base.Add(time.Millisecond) {
+		t.Fatalf("After delivered %v", fired)
+	}
+
+	ticker := at.NewTicker(time.Millisecond, tickID)
+	mt.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != base.Add(2*time.Millisecond) {
+		t.Fatalf("ticker delivered %v", tick)
+	}
+	ticker.Stop()
+	ticker.Reset(time.Millisecond)
+	mt.Trigger(tickID)
+	if tick := <-ticker.Channel(); tick != base.Add(2*time.Millisecond) {
+		t.Fatalf("ticker Reset after Stop delivered %v", tick)
+	}
+	ticker.Stop()
+
+	timer := at.NewTimerAt(base.Add(5500*time.Microsecond), timerID)
+	mt.Trigger(timerID)
+	if fired := <-timer.Channel(); fired != base.Add(5*time.Millisecond) {
+		t.Fatalf("timer delivered %v", fired)
+	}
+	timer.Reset(time.Millisecond)
+	mt.Trigger(timerID)
+	<-timer.Channel()
+	timer.Reset(time.Millisecond)
+	timer.Stop()
+
+	ctx, cancel := at.WithTimeout(context.Background(), time.Microsecond, contextID)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); deadline != base.Add(time.Millisecond) {
+		t.Fatalf("deadline not truncated: %v", deadline)
+	}
+
+	// an After fired with nobody receiving doesn't hold up its
+	// forwarding, and one closed by Close is closed in turn
+	abandoned := at.After(time.Second, afterID)
+	mt.Trigger(afterID)
+	for len(abandoned) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ch = at.After(time.Second, afterID)
+	mt.Close()
+	if _, ok := <-ch; ok {
+		t.Fatal("After delivered a time after Close")
+	}
+}
@@ -0,0 +1,35 @@
+package abtime
+
+import (
+	"context"
+	"time"
+)
+
+// WaitUntil polls pred every interval on the given clock until it returns
+// true, returning nil, or until the context is done, returning the
+// context's error. pred is checked once straight away, so a condition
+// that already holds doesn't wait at all.
+//
+// The polling is timed with a Ticker under the given id, so on a
+// ManualTime each Trigger of the id is one more check of pred.
+func WaitUntil(ctx context.Context, at AbstractTime, interval time.Duration, id ID, pred func() bool) error {
+	if pred() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ticker := at.NewTicker(interval, id)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.Channel():
+			if pred() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package abtime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitUntil(t *testing.T) {
+	mt := NewManual()
+
+	checks := 0
+	ready := func() bool {
+		checks++
+		return checks == 3
+	}
+	result := make(chan error)
+	go func() {
+		result <- WaitUntil(context.Background(), mt, time.Second, tickID, ready)
+	}()
+	mt.TriggerAndWait(tickID, tickID)
+	if err := <-result; err != nil || checks != 3 {
+		t.Fatalf("unexpected result %v after %d checks", err, checks)
+	}
+	mt.VerifyNoPending(t)
+
+	// a condition that already holds never waits
+	if err := WaitUntil(context.Background(), mt, time.Second, tickID, func() bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	mt.VerifyNoPending(t)
+
+	// and the context ends the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		result <- WaitUntil(ctx, mt, time.Second, tickID, func() bool { return false })
+	}()
+	mt.TriggerAndWait(tickID)
+	cancel()
+	if err := <-result; err != context.Canceled {
+		t.Fatalf("unexpected error %v", err)
+	}
+	mt.VerifyNoPending(t)
+}
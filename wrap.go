@@ -0,0 +1,50 @@
+package abtime
+
+import (
+	"time"
+)
+
+// WrapTimer adapts a *time.Timer created outside of abtime to the Timer
+// interface, so it can be passed to code that has already been converted.
+// This eases migrating a codebase to abtime one package at a time.
+//
+// Timers from time.AfterFunc work too, though their Channel is nil, just
+// as their C is.
+func WrapTimer(t *time.Timer) Timer {
+	return TimerWrap{t}
+}
+
+// WrapTicker adapts a *time.Ticker created outside of abtime to the Ticker
+// interface.
+func WrapTicker(t *time.Ticker) Ticker {
+	return tickerWrapper{t}
+}
+
+// StdTimer returns the *time.Timer underlying a Timer, for handing it to
+// code that still expects the standard library type. This is only
+// possible for the Timers of RealTime and WrapTimer; for any other, such
+// as a ManualTime's, there is no *time.Timer and ok is false.
+func StdTimer(t Timer) (timer *time.Timer, ok bool) {
+	switch wrapped := t.(type) {
+	case TimerWrap:
+		return wrapped.T, true
+	case drainingTimer:
+		return wrapped.T, true
+	default:
+		return nil, false
+	}
+}
+
+// StdTicker returns the *time.Ticker underlying a Ticker, for handing it to
+// code that still expects the standard library type. As with StdTimer,
+// this is only possible for the Tickers of RealTime and WrapTicker.
+func StdTicker(t Ticker) (ticker *time.Ticker, ok bool) {
+	switch wrapped := t.(type) {
+	case tickerWrapper:
+		return wrapped.Ticker, true
+	case drainingTicker:
+		return wrapped.Ticker, true
+	default:
+		return nil, false
+	}
+}
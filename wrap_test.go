@@ -0,0 +1,43 @@
+package abtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWrap(t *testing.T) {
+	std := time.NewTimer(time.Nanosecond)
+	timer := WrapTimer(std)
+	<-timer.Channel()
+	if unwrapped, ok := StdTimer(timer); !ok || unwrapped != std {
+		t.Fatal("could not unwrap a wrapped timer")
+	}
+
+	stdTicker := time.NewTicker(time.Nanosecond)
+	ticker := WrapTicker(stdTicker)
+	<-ticker.Channel()
+	ticker.Stop()
+	if unwrapped, ok := StdTicker(ticker); !ok || unwrapped != stdTicker {
+		t.Fatal("could not unwrap a wrapped ticker")
+	}
+
+	rt := NewRealTimeWithSemantics(Go123Timers)
+	realTimer := rt.NewTimer(time.Hour, 0)
+	defer realTimer.Stop()
+	if _, ok := StdTimer(realTimer); !ok {
+		t.Fatal("could not unwrap a real timer")
+	}
+	realTicker := rt.NewTicker(time.Hour, 0)
+	defer realTicker.Stop()
+	if _, ok := StdTicker(realTicker); !ok {
+		t.Fatal("could not unwrap a real ticker")
+	}
+
+	mt := NewManual()
+	if _, ok := StdTimer(mt.NewTimer(time.Second, timerID)); ok {
+		t.Fatal("unwrapped a manual timer")
+	}
+	if _, ok := StdTicker(mt.NewTicker(time.Second, tickID)); ok {
+		t.Fatal("unwrapped a manual ticker")
+	}
+}